@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/MathieuDoyon/bookshelf/server/repositories"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	viper.SetEnvPrefix("bookshelf")
+	viper.AutomaticEnv()
+	viper.SetDefault("bolt_path", "bookshelf.db")
+}
+
+// migrate copy books and their history between storage backends, ex: `migrate -from mongo -to bolt`.
+//
+// `migrate -authors -from mongo` links the books of a storage to author records instead,
+// `migrate -works -from mongo` groups them into works.
+func main() {
+	from := flag.String("from", repositories.StorageMongo, "storage to copy books from: mongo or bolt")
+	to := flag.String("to", repositories.StorageBolt, "storage to copy books into: mongo or bolt")
+	boltPath := flag.String("bolt-path", viper.GetString("bolt_path"), "bbolt file of the bolt storage")
+	authors := flag.Bool("authors", false, "link books of the -from storage to author records, created from their author text")
+	works := flag.Bool("works", false, "group books of the -from storage into works, created from their title and author")
+	flag.Parse()
+
+	// a book which can't be decoded must stop the migration instead of being left behind
+	options := repositories.Options{BoltPath: *boltPath, StrictDecoding: true}
+
+	if *authors {
+		migrateAuthors(*from, options)
+		return
+	}
+	if *works {
+		migrateWorks(*from, options)
+		return
+	}
+
+	if *from == *to {
+		log.Fatalf("Can't migrate %s storage into itself", *from)
+	}
+
+	source, err := repositories.OpenStorage(*from, options)
+	if err != nil {
+		log.Fatal("Could not open source storage: ", err)
+	}
+	defer source.Close()
+
+	target, err := repositories.OpenStorage(*to, options)
+	if err != nil {
+		log.Fatal("Could not open target storage: ", err)
+	}
+	defer target.Close()
+
+	copied, err := repositories.Migrate(context.Background(), source, target)
+	if err != nil {
+		log.Fatalf("Migration stopped after %d books: %s", copied, err)
+	}
+	log.Printf("Copied %d books from %s to %s", copied, *from, *to)
+}
+
+// migrateAuthors link books of a storage to author records, changes are recorded into the books history
+func migrateAuthors(name string, options repositories.Options) {
+	storage, err := repositories.OpenStorage(name, options)
+	if err != nil {
+		log.Fatal("Could not open storage: ", err)
+	}
+	defer storage.Close()
+
+	books := repositories.NewHistoryRepo(storage.Books, storage.Revisions).WithActor("migrate")
+	linked, err := repositories.MigrateAuthors(context.Background(), books, storage.Authors)
+	if err != nil {
+		log.Fatalf("Author migration stopped after %d books: %s", linked, err)
+	}
+	log.Printf("Linked %d books of %s to their authors", linked, name)
+}
+
+// migrateWorks group books of a storage into works, changes are recorded into the books history
+func migrateWorks(name string, options repositories.Options) {
+	storage, err := repositories.OpenStorage(name, options)
+	if err != nil {
+		log.Fatal("Could not open storage: ", err)
+	}
+	defer storage.Close()
+
+	books := repositories.NewHistoryRepo(storage.Books, storage.Revisions).WithActor("migrate")
+	grouped, err := repositories.MigrateWorks(context.Background(), books, storage.Works)
+	if err != nil {
+		log.Fatalf("Work migration stopped after %d books: %s", grouped, err)
+	}
+	log.Printf("Grouped %d books of %s into works", grouped, name)
+}
@@ -0,0 +1,203 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// Export formats
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+	FormatXLSX   = "xlsx"
+)
+
+// ContentTypes content type of each export format
+var ContentTypes = map[string]string{
+	FormatCSV:    "text/csv; charset=utf-8",
+	FormatNDJSON: "application/x-ndjson",
+	FormatJSON:   "application/json",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ErrUnknownFormat returned for formats missing from ContentTypes
+var ErrUnknownFormat = errors.New("unknown export format, expected csv, ndjson, json or xlsx")
+
+// Encoder write books one row at a time
+type Encoder interface {
+	// Encode write a book row
+	Encode(book *model.Book) error
+	// Flush write buffered rows to the underlying writer
+	Flush() error
+	// Close write what ends the document and flush it
+	Close() error
+}
+
+// NewEncoder build the encoder of a format writing the given columns to w
+func NewEncoder(format string, w io.Writer, columns []string) (Encoder, error) {
+	buffer := bufio.NewWriter(w)
+
+	switch format {
+	case FormatCSV:
+		encoder := &csvEncoder{writer: csv.NewWriter(buffer), columns: columns}
+		return encoder, encoder.writer.Write(columns)
+	case FormatNDJSON:
+		return &jsonEncoder{buffer: buffer, columns: columns}, nil
+	case FormatJSON:
+		return &jsonEncoder{buffer: buffer, columns: columns, array: true}, nil
+	case FormatXLSX:
+		return newXLSXEncoder(w, columns)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// ParseColumns parse a comma separated list of bson field names, every field in struct order when empty
+func ParseColumns(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return model.BookFieldOrder, nil
+	}
+
+	var columns []string
+	seen := map[string]bool{}
+	for _, column := range strings.Split(raw, ",") {
+		column = strings.TrimSpace(column)
+		if _, ok := model.BookFields[column]; !ok {
+			return nil, fmt.Errorf("unknown column %q", column)
+		}
+		if !seen[column] {
+			seen[column] = true
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
+
+// csvEncoder write a header row then a row per book
+type csvEncoder struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+func (e *csvEncoder) Encode(book *model.Book) error {
+	record := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		record[i] = cellText(book.FieldValue(column))
+	}
+	return e.writer.Write(record)
+}
+
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvEncoder) Close() error {
+	return e.Flush()
+}
+
+// jsonEncoder write a JSON object per book, one per line or inside an array
+type jsonEncoder struct {
+	buffer  *bufio.Writer
+	columns []string
+	array   bool
+	count   int
+}
+
+func (e *jsonEncoder) Encode(book *model.Book) error {
+	if e.array {
+		separator := ","
+		if e.count == 0 {
+			separator = "["
+		}
+		e.buffer.WriteString(separator)
+	}
+	e.count++
+
+	// objects are written by hand to keep the columns order
+	e.buffer.WriteByte('{')
+	for i, column := range e.columns {
+		if i > 0 {
+			e.buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		value, err := json.Marshal(jsonValue(book.FieldValue(column)))
+		if err != nil {
+			return err
+		}
+		e.buffer.Write(key)
+		e.buffer.WriteByte(':')
+		e.buffer.Write(value)
+	}
+	e.buffer.WriteByte('}')
+
+	if !e.array {
+		e.buffer.WriteByte('\n')
+	}
+	return nil
+}
+
+func (e *jsonEncoder) Flush() error {
+	return e.buffer.Flush()
+}
+
+func (e *jsonEncoder) Close() error {
+	if e.array {
+		if e.count == 0 {
+			e.buffer.WriteByte('[')
+		}
+		e.buffer.WriteString("]\n")
+	}
+	return e.Flush()
+}
+
+// cellText text of a field value, empty for zero times
+func cellText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case objectid.ObjectID:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Hex()
+	case []objectid.ObjectID:
+		IDs := make([]string, len(v))
+		for i, ID := range v {
+			IDs[i] = ID.Hex()
+		}
+		return strings.Join(IDs, ",")
+	case []model.Contributor:
+		return model.FormatContributors(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// jsonValue JSON value of a field, null for zero times
+func jsonValue(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok && t.IsZero() {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/importer"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testBooks() []*model.Book {
+	illustratorID, _ := objectid.FromHex("5bb0b6e3c8a1f4a7d1e2c3b4")
+	return []*model.Book{
+		{
+			ID:     objectid.New(),
+			Title:  "Sardines, \"the\" trawler",
+			Author: "Mathieu Doyon",
+			Contributors: []model.Contributor{
+				{Name: "Mathieu Doyon", Role: model.RoleAuthor},
+				{Name: "Jane: the translator", Role: model.RoleTranslator},
+				{AuthorID: illustratorID, Name: "John Roe", Role: model.RoleIllustrator},
+			},
+			ISBN10:    "0306406152",
+			ISBN13:    "9780306406157",
+			Rating:    5,
+			Version:   2,
+			CreatedAt: time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{ID: objectid.New(), Title: "Seagulls", Edition: 2},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	columns, _ := ParseColumns("")
+	importFormats := map[string]string{
+		FormatCSV:    importer.CSVContentType,
+		FormatNDJSON: importer.NDJSONContentType,
+		FormatJSON:   importer.JSONContentType,
+	}
+
+	for format, contentType := range importFormats {
+		var buffer bytes.Buffer
+		encoder, err := NewEncoder(format, &buffer, columns)
+		assert.NoError(t, err)
+		for _, book := range testBooks() {
+			assert.NoError(t, encoder.Encode(book))
+		}
+		assert.NoError(t, encoder.Close())
+
+		decoder, err := importer.NewDecoder(contentType, &buffer)
+		assert.NoError(t, err, format)
+
+		for _, expected := range testBooks() {
+			book, err := decoder.Next()
+			assert.NoError(t, err, format)
+			if book == nil {
+				break
+			}
+			// managed fields are not imported from csv
+			book.KeepManagedFields(nil)
+			expected.KeepManagedFields(nil)
+			assert.Equal(t, expected, book, format)
+		}
+		_, err = decoder.Next()
+		assert.Equal(t, io.EOF, err, format)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	columns, err := ParseColumns("rating, title,rating")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rating", "title"}, columns)
+
+	_, err = ParseColumns("title,colour")
+	assert.Error(t, err)
+
+	var buffer bytes.Buffer
+	encoder, _ := NewEncoder(FormatJSON, &buffer, columns)
+	encoder.Encode(testBooks()[1])
+	encoder.Close()
+
+	assert.Equal(t, `[{"rating":0,"title":"Seagulls"}]`+"\n", buffer.String())
+
+	buffer.Reset()
+	encoder, _ = NewEncoder(FormatJSON, &buffer, columns)
+	encoder.Close()
+
+	assert.Equal(t, "[]\n", buffer.String(), "It should write an empty array")
+}
+
+func TestXLSX(t *testing.T) {
+	var buffer bytes.Buffer
+	encoder, err := NewEncoder(FormatXLSX, &buffer, []string{"title", "rating"})
+	assert.NoError(t, err)
+	for _, book := range testBooks() {
+		assert.NoError(t, encoder.Encode(book))
+	}
+	assert.NoError(t, encoder.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+	assert.NoError(t, err)
+
+	var names []string
+	var sheet string
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			r, _ := f.Open()
+			data, _ := ioutil.ReadAll(r)
+			sheet = string(data)
+		}
+	}
+
+	assert.Contains(t, names, "[Content_Types].xml")
+	assert.Contains(t, sheet, `<t xml:space="preserve">Sardines, &#34;the&#34; trawler</t>`)
+	assert.Contains(t, sheet, "<c><v>5</v></c>")
+	assert.Equal(t, 3, strings.Count(sheet, "<row>"))
+}
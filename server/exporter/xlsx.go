@@ -0,0 +1,113 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+)
+
+// xlsxParts static parts of a single sheet workbook, the sheet itself is streamed last
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Books" sheetId="1" r:id="rId1"/></sheets>` +
+		`</workbook>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxEncoder write an Office Open XML workbook, rows are streamed into its only sheet
+type xlsxEncoder struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	columns []string
+}
+
+// newXLSXEncoder write the static parts and open the sheet with its header row
+func newXLSXEncoder(w io.Writer, columns []string) (*xlsxEncoder, error) {
+	archive := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		f, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	e := &xlsxEncoder{archive: archive, sheet: bufio.NewWriter(f), columns: columns}
+
+	e.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	e.sheet.WriteString("<row>")
+	for _, column := range columns {
+		e.writeString(column)
+	}
+	e.sheet.WriteString("</row>")
+
+	return e, nil
+}
+
+func (e *xlsxEncoder) Encode(book *model.Book) error {
+	e.sheet.WriteString("<row>")
+	for _, column := range e.columns {
+		value := book.FieldValue(column)
+		switch n := value.(type) {
+		case int64:
+			e.sheet.WriteString("<c><v>" + strconv.FormatInt(n, 10) + "</v></c>")
+		case float64:
+			e.sheet.WriteString("<c><v>" + strconv.FormatFloat(n, 'f', -1, 64) + "</v></c>")
+		default:
+			e.writeString(cellText(value))
+		}
+	}
+	_, err := e.sheet.WriteString("</row>")
+	return err
+}
+
+func (e *xlsxEncoder) Flush() error {
+	if err := e.sheet.Flush(); err != nil {
+		return err
+	}
+	return e.archive.Flush()
+}
+
+func (e *xlsxEncoder) Close() error {
+	e.sheet.WriteString("</sheetData></worksheet>")
+	if err := e.sheet.Flush(); err != nil {
+		return err
+	}
+	return e.archive.Close()
+}
+
+// writeString write an inline string cell
+func (e *xlsxEncoder) writeString(s string) {
+	e.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+	xml.EscapeText(e.sheet, []byte(s))
+	e.sheet.WriteString("</t></is></c>")
+}
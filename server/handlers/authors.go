@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// AuthorsResource Author router ressources routes
+type AuthorsResource struct {
+	Repo interfaces.IAuthorRepository
+	// Books books linking to authors, their author names are changed with the author
+	Books *BooksResource
+	// ResyncDelay wait before renaming again, in the background, the books of an author whose rename failed. It
+	// doubles for each of the resyncAttempts, zero leaves the books to `POST /authors/{id}/books/sync`
+	ResyncDelay time.Duration
+}
+
+// resyncAttempts number of background renames of the books of an author after a failed rename
+const resyncAttempts = 3
+
+// Routes creates a REST router for the authors resource
+func (rs *AuthorsResource) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", rs.List)    // GET /authors - read every author, ?name= matches names and aliases
+	r.Post("/", rs.Create) // POST /authors - create a new author
+
+	r.Route("/{id}", func(r chi.Router) {
+		r.Use(rs.AuthorCtx)
+		r.Get("/", rs.Get)                  // GET /authors/{id} - read a single author by :id
+		r.Put("/", rs.Update)               // PUT /authors/{id} - update a single author by :id, linked books are renamed
+		r.Delete("/", rs.Delete)            // DELETE /authors/{id} - delete an author no book links to
+		r.Get("/books", rs.ListBooks)       // GET /authors/{id}/books - read a list of the author books, with the books list filters
+		r.Post("/books/sync", rs.SyncBooks) // POST /authors/{id}/books/sync - write the author names into its books again
+	})
+
+	return r
+}
+
+// List get every author sorted by sort name, `?name=` keeps the authors having this name or alias
+func (rs *AuthorsResource) List(w http.ResponseWriter, r *http.Request) {
+	authors, err := rs.Repo.List(r.Context(), r.URL.Query().Get("name"))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, authors)
+}
+
+// Create add a new author
+func (rs *AuthorsResource) Create(w http.ResponseWriter, r *http.Request) {
+	data := &model.AuthorRequest{}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+
+	if created, err := rs.Repo.Create(r.Context(), data.Author); err != nil {
+		renderError(w, r, err)
+	} else {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, created)
+	}
+}
+
+// Get get an author by ID
+func (rs *AuthorsResource) Get(w http.ResponseWriter, r *http.Request) {
+	author := r.Context().Value("author").(*model.Author)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, author)
+}
+
+// Update update an author, books linking to it are renamed when its name changed.
+//
+// The author is answered with `202` when its books couldn't all be renamed, they are renamed again later.
+func (rs *AuthorsResource) Update(w http.ResponseWriter, r *http.Request) {
+	author := r.Context().Value("author").(*model.Author)
+
+	previous := *author
+	data := &model.AuthorRequest{Author: author, Previous: &previous}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+
+	updated, err := rs.Repo.Update(r.Context(), data.Author)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	if updated.Name != previous.Name {
+		if _, err := rs.renameBooks(r.Context(), rs.Books.repo(r), updated.ID); err != nil {
+			// the author is renamed already, renaming its books again writes the names they miss
+			log.Printf("Error renaming books of author %s: %s", updated.ID.Hex(), err)
+			rs.resyncLater(rs.Books.repo(r), updated.ID)
+			render.Status(r, http.StatusAccepted)
+			render.JSON(w, r, updated)
+			return
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, updated)
+}
+
+// SyncBooks write the current names of an author into the books linking to it, after a rename which couldn't
+// rename them all. Books already having the names are left as they are, so it can run again
+func (rs *AuthorsResource) SyncBooks(w http.ResponseWriter, r *http.Request) {
+	author := r.Context().Value("author").(*model.Author)
+
+	renamed, err := rs.renameBooks(r.Context(), rs.Books.repo(r), author.ID)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, &model.AuthorSyncReport{Books: renamed})
+}
+
+// Delete delete an author by ID, authors books still link to answer 409
+func (rs *AuthorsResource) Delete(w http.ResponseWriter, r *http.Request) {
+	author := r.Context().Value("author").(*model.Author)
+
+	// trashed books can be restored, they keep the author in use
+	for _, trashed := range []bool{false, true} {
+		page, err := rs.Books.Repo.List(
+			r.Context(),
+			&model.BookFilter{AuthorID: author.ID, Trashed: trashed},
+			&model.Sorting{},
+			&model.Pagination{Limit: 1},
+		)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+		if page.Total > 0 {
+			renderError(w, r, model.ErrAuthorInUse)
+			return
+		}
+	}
+
+	if deleted, err := rs.Repo.Delete(r.Context(), author.ID); err != nil {
+		renderError(w, r, err)
+	} else {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, deleted)
+	}
+}
+
+// ListBooks get books linking to an author, with the same filters, sorts and pagination as the books list
+func (rs *AuthorsResource) ListBooks(w http.ResponseWriter, r *http.Request) {
+	author := r.Context().Value("author").(*model.Author)
+
+	rs.Books.list(w, r, model.BookFilter{AuthorID: author.ID})
+}
+
+// AuthorCtx build author context and inject `model.Author` into request
+func (rs *AuthorsResource) AuthorCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		author, err := rs.Repo.Get(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "author", author)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// renameBooks write the current names of their authors into books and contributors linking to an author,
+// trashed books too, writing through books. Returns how many books were renamed
+func (rs *AuthorsResource) renameBooks(ctx context.Context, books interfaces.IBookRepository, authorID objectid.ObjectID) (int64, error) {
+	// books are changed once read, a stream can't be written into
+	var linked []model.Book
+	for _, trashed := range []bool{false, true} {
+		err := rs.Books.Repo.Stream(ctx, &model.BookFilter{AuthorID: authorID, Trashed: trashed}, &model.Sorting{Sort: "_id", Direction: 1}, func(book *model.Book) error {
+			linked = append(linked, *book)
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var renamed int64
+	for i := range linked {
+		book := linked[i]
+		book.Contributors = append([]model.Contributor(nil), linked[i].Contributors...)
+		if err := rs.Books.linkAuthors(ctx, &book); err != nil {
+			return renamed, err
+		}
+		fields := model.ChangedFields(&linked[i], &book)
+		if len(fields) == 0 {
+			continue
+		}
+		if _, err := books.Patch(ctx, &book, fields); err != nil {
+			return renamed, err
+		}
+		renamed++
+	}
+	return renamed, nil
+}
+
+// resyncLater rename the books of an author again in the background, waiting ResyncDelay then twice as long
+// before each attempt
+func (rs *AuthorsResource) resyncLater(books interfaces.IBookRepository, authorID objectid.ObjectID) {
+	if rs.ResyncDelay <= 0 {
+		return
+	}
+
+	go func() {
+		delay := rs.ResyncDelay
+		for attempt := 1; attempt <= resyncAttempts; attempt++ {
+			time.Sleep(delay)
+			_, err := rs.renameBooks(context.Background(), books, authorID)
+			if err == nil {
+				return
+			}
+			log.Printf("Error renaming books of author %s, attempt %d of %d: %s", authorID.Hex(), attempt, resyncAttempts, err)
+			delay *= 2
+		}
+	}()
+}
+
+// linkAuthors check the authors a book and its contributors link to exist, their names are embedded and credited
+// as book author and contributor names.
+//
+// Books linking to no author keep their author text. Links are left unchecked without an authors repository.
+func (rs *BooksResource) linkAuthors(ctx context.Context, book *model.Book) error {
+	book.Authors = nil
+	IDs := append([]objectid.ObjectID(nil), book.AuthorIDs...)
+	for _, contributor := range book.Contributors {
+		if contributor.IsLinked() {
+			IDs = append(IDs, contributor.AuthorID)
+		}
+	}
+	if len(IDs) == 0 || rs.Authors == nil {
+		return nil
+	}
+
+	authors, err := rs.Authors.GetMany(ctx, IDs)
+	if err != nil {
+		return err
+	}
+
+	names := authorNames(authors)
+	unknown := func(field string, ID objectid.ObjectID) error {
+		return model.Invalid(model.FieldError{
+			Field:   field,
+			Reason:  model.ReasonUnknown,
+			Message: "links unknown author " + ID.Hex(),
+		})
+	}
+	for i := range book.Contributors {
+		contributor := &book.Contributors[i]
+		if !contributor.IsLinked() {
+			continue
+		}
+		name, ok := names[contributor.AuthorID]
+		if !ok {
+			return unknown("contributors", contributor.AuthorID)
+		}
+		contributor.Name = name.Name
+	}
+	if len(book.Contributors) > 0 {
+		book.ProjectAuthors()
+	}
+
+	for _, ID := range book.AuthorIDs {
+		if _, ok := names[ID]; !ok {
+			return unknown("author_ids", ID)
+		}
+		book.Authors = append(book.Authors, names[ID])
+	}
+	if len(book.Contributors) == 0 {
+		book.Author = model.JoinAuthorNames(book.Authors)
+	}
+
+	return nil
+}
+
+// embedAuthors embed the names of their linked authors into books, authors which no longer exist are left out.
+// Books without contributors list their authors as contributors
+func (rs *BooksResource) embedAuthors(ctx context.Context, books ...*model.Book) error {
+	var IDs []objectid.ObjectID
+	for _, book := range books {
+		IDs = append(IDs, book.AuthorIDs...)
+	}
+
+	names := map[objectid.ObjectID]model.AuthorName{}
+	if len(IDs) > 0 && rs.Authors != nil {
+		authors, err := rs.Authors.GetMany(ctx, IDs)
+		if err != nil {
+			return err
+		}
+		names = authorNames(authors)
+	}
+
+	for _, book := range books {
+		if len(names) > 0 {
+			book.Authors = nil
+			for _, ID := range book.AuthorIDs {
+				if name, ok := names[ID]; ok {
+					book.Authors = append(book.Authors, name)
+				}
+			}
+		}
+		book.ProjectContributors()
+	}
+	return nil
+}
+
+// authorNames names of authors by id
+func authorNames(authors []model.Author) map[objectid.ObjectID]model.AuthorName {
+	names := map[objectid.ObjectID]model.AuthorName{}
+	for _, author := range authors {
+		names[author.ID] = model.AuthorName{ID: author.ID, Name: author.Name}
+	}
+	return names
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces/mocks"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/go-chi/chi"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthorCreate(t *testing.T) {
+	authorMock := &mocks.IAuthorRepository{}
+	authorMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, author *model.Author) *model.Author {
+		return author
+	}, nil)
+
+	authorResource := AuthorsResource{Repo: authorMock, Books: &BooksResource{StrictJSON: true}}
+	r := chi.NewRouter()
+	r.Mount("/authors", authorResource.Routes())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/authors", strings.NewReader(`{"name":"Mathieu Doyon","aliases":["M. Doyon","mathieu doyon"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	author := &model.Author{}
+	json.NewDecoder(w.Body).Decode(author)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "Doyon, Mathieu", author.SortName, "It should default the sort name")
+	assert.Equal(t, []string{"M. Doyon"}, author.Aliases)
+
+	req = httptest.NewRequest("POST", "http://localhost:8080/authors", strings.NewReader(`{"name":"","death_year":1840,"birth_year":1902}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := &ErrResponse{}
+	json.NewDecoder(w.Body).Decode(problem)
+	assert.Equal(t, 422, w.Code)
+	assert.Len(t, problem.Errors, 2)
+	authorMock.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestBookCreateLinksAuthors(t *testing.T) {
+	doyon := model.Author{ID: objectid.New(), Name: "Mathieu Doyon"}
+	unknown := objectid.New()
+
+	authorMock := &mocks.IAuthorRepository{}
+	authorMock.On("GetMany", mock.Anything, []objectid.ObjectID{doyon.ID}).Return([]model.Author{doyon}, nil)
+	authorMock.On("GetMany", mock.Anything, []objectid.ObjectID{doyon.ID, unknown}).Return([]model.Author{doyon}, nil)
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, book *model.Book) *model.Book {
+		return book
+	}, nil)
+
+	bookResource := BooksResource{Repo: repoMock, Authors: authorMock}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/books", strings.NewReader(`{"title":"Sardines","author":"M. Doyon","author_ids":["`+doyon.ID.Hex()+`"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	book := &model.Book{}
+	json.NewDecoder(w.Body).Decode(book)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "Mathieu Doyon", book.Author, "It should credit the linked authors")
+	assert.Equal(t, []model.AuthorName{{ID: doyon.ID, Name: "Mathieu Doyon"}}, book.Authors, "It should embed the author names")
+
+	req = httptest.NewRequest("POST", "http://localhost:8080/books", strings.NewReader(`{"title":"Sardines","author_ids":["`+doyon.ID.Hex()+`","`+unknown.Hex()+`"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := &ErrResponse{}
+	json.NewDecoder(w.Body).Decode(problem)
+
+	assert.Equal(t, 422, w.Code)
+	if assert.Len(t, problem.Errors, 1) {
+		assert.Equal(t, "author_ids", problem.Errors[0].Field)
+		assert.Equal(t, model.ReasonUnknown, problem.Errors[0].Reason)
+	}
+	repoMock.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestAuthorDeleteInUse(t *testing.T) {
+	author := &model.Author{ID: objectid.New(), Name: "Mathieu Doyon"}
+
+	authorMock := &mocks.IAuthorRepository{}
+	authorMock.On("Get", mock.Anything, author.ID.Hex()).Return(author, nil)
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, &model.BookFilter{AuthorID: author.ID}, mock.Anything, mock.Anything).Return(&model.BookPage{Books: []model.Book{}}, nil)
+	repoMock.On("List", mock.Anything, &model.BookFilter{AuthorID: author.ID, Trashed: true}, mock.Anything, mock.Anything).Return(&model.BookPage{Books: []model.Book{{}}, Total: 1}, nil)
+
+	authorResource := AuthorsResource{Repo: authorMock, Books: &BooksResource{Repo: repoMock}}
+	r := chi.NewRouter()
+	r.Mount("/authors", authorResource.Routes())
+
+	req := httptest.NewRequest("DELETE", "http://localhost:8080/authors/"+author.ID.Hex(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := &ErrResponse{}
+	json.NewDecoder(w.Body).Decode(problem)
+
+	assert.Equal(t, 409, w.Code, "It should keep authors of trashed books")
+	assert.Equal(t, model.CodeAuthorInUse, problem.AppCode)
+	authorMock.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestAuthorUpdateRenamesBooks(t *testing.T) {
+	doyon := &model.Author{ID: objectid.New(), Name: "M. Doyon"}
+	cantona := model.Author{ID: objectid.New(), Name: "Eric Cantona"}
+	book := model.Book{ID: objectid.New(), Title: "Seagulls", Author: "Eric Cantona & M. Doyon", AuthorIDs: []objectid.ObjectID{cantona.ID, doyon.ID}, Version: 2}
+
+	authorMock := &mocks.IAuthorRepository{}
+	authorMock.On("Get", mock.Anything, doyon.ID.Hex()).Return(doyon, nil)
+	authorMock.On("Update", mock.Anything, mock.Anything).Return(func(_ context.Context, author *model.Author) *model.Author {
+		return author
+	}, nil)
+	authorMock.On("GetMany", mock.Anything, book.AuthorIDs).Return([]model.Author{cantona, {ID: doyon.ID, Name: "Mathieu Doyon"}}, nil)
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{AuthorID: doyon.ID}, mock.Anything, mock.Anything).Return(func(_ context.Context, _ *model.BookFilter, _ *model.Sorting, fn func(book *model.Book) error) error {
+		return fn(&book)
+	})
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{AuthorID: doyon.ID, Trashed: true}, mock.Anything, mock.Anything).Return(nil)
+	repoMock.On("Patch", mock.Anything, mock.Anything, []string{"author"}).Return(func(_ context.Context, book *model.Book, _ []string) *model.Book {
+		return book
+	}, nil)
+
+	authorResource := AuthorsResource{Repo: authorMock, Books: &BooksResource{Repo: repoMock, Authors: authorMock}}
+	r := chi.NewRouter()
+	r.Mount("/authors", authorResource.Routes())
+
+	req := httptest.NewRequest("PUT", "http://localhost:8080/authors/"+doyon.ID.Hex(), strings.NewReader(`{"name":"Mathieu Doyon"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	repoMock.AssertCalled(t, "Patch", mock.Anything, mock.MatchedBy(func(patched *model.Book) bool {
+		return patched.ID == book.ID && patched.Author == "Eric Cantona & Mathieu Doyon" && patched.Version == 2
+	}), []string{"author"})
+}
+
+func TestAuthorUpdateDefersBookRenames(t *testing.T) {
+	doyon := &model.Author{ID: objectid.New(), Name: "M. Doyon"}
+	book := model.Book{ID: objectid.New(), Title: "Sardines", Author: "M. Doyon", AuthorIDs: []objectid.ObjectID{doyon.ID}, Version: 2}
+
+	authorMock := &mocks.IAuthorRepository{}
+	authorMock.On("Get", mock.Anything, doyon.ID.Hex()).Return(doyon, nil)
+	authorMock.On("Update", mock.Anything, mock.Anything).Return(func(_ context.Context, author *model.Author) *model.Author {
+		return author
+	}, nil)
+	authorMock.On("GetMany", mock.Anything, book.AuthorIDs).Return([]model.Author{{ID: doyon.ID, Name: "Mathieu Doyon"}}, nil)
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{AuthorID: doyon.ID}, mock.Anything, mock.Anything).Return(func(_ context.Context, _ *model.BookFilter, _ *model.Sorting, fn func(book *model.Book) error) error {
+		return fn(&book)
+	})
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{AuthorID: doyon.ID, Trashed: true}, mock.Anything, mock.Anything).Return(nil)
+	repoMock.On("Patch", mock.Anything, mock.Anything, []string{"author"}).Return(nil, model.Unavailable(errors.New("no reachable servers"))).Once()
+	repoMock.On("Patch", mock.Anything, mock.Anything, []string{"author"}).Return(func(_ context.Context, book *model.Book, _ []string) *model.Book {
+		return book
+	}, nil)
+
+	authorResource := AuthorsResource{Repo: authorMock, Books: &BooksResource{Repo: repoMock, Authors: authorMock}}
+	r := chi.NewRouter()
+	r.Mount("/authors", authorResource.Routes())
+
+	req := httptest.NewRequest("PUT", "http://localhost:8080/authors/"+doyon.ID.Hex(), strings.NewReader(`{"name":"Mathieu Doyon"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 202, w.Code, "It should answer the renamed author when its books couldn't be renamed")
+	updated := &model.Author{}
+	json.NewDecoder(w.Body).Decode(updated)
+	assert.Equal(t, "Mathieu Doyon", updated.Name)
+
+	req = httptest.NewRequest("POST", "http://localhost:8080/authors/"+doyon.ID.Hex()+"/books/sync", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.JSONEq(t, `{"books":1}`, w.Body.String(), "It should rename the books left behind")
+	repoMock.AssertNumberOfCalls(t, "Patch", 2)
+}
@@ -2,22 +2,50 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"mime"
 	"net/http"
-	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/MathieuDoyon/bookshelf/server/interfaces"
 	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/patch"
 	"github.com/MathieuDoyon/bookshelf/server/utils"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/render"
-	"github.com/mongodb/mongo-go-driver/bson/objectid"
 )
 
 // BooksResource Book router ressources routes
 type BooksResource struct {
 	Repo interfaces.IBookRepository
 	// Repo *repositories.BookRepo
+	// Authors authors books link to, their names are embedded into responses
+	Authors interfaces.IAuthorRepository
+	// Genres genres tree books are filed under, the default genres when nil
+	Genres interfaces.IGenreRepository
+	// Series series books are part of, series are left unchecked when nil
+	Series interfaces.ISeriesRepository
+	// Works works books are editions of, books aren't grouped into works when nil
+	Works interfaces.IWorkRepository
+
+	// RequireIfMatch answer 428 to updates and deletes sent without If-Match header
+	RequireIfMatch bool
+	// StrictJSON reject bodies with unknown fields, duplicate keys or trailing data
+	StrictJSON bool
+	// MaxBodySize largest accepted body of creates, updates and patches in bytes, 0 is unlimited
+	MaxBodySize int64
+	// MaxImportSize largest accepted import body in bytes, 0 is unlimited
+	MaxImportSize int64
+	// MaxCollapseBooks most matching books `collapse=true` reads, larger lists answer 400. 0 is unlimited
+	MaxCollapseBooks int64
+	// CollapseTimeout deadline of `collapse=true` lists, which read every matching book. 0 is none
+	CollapseTimeout time.Duration
+
+	worksMu sync.Mutex
 }
 
 // Routes creates a REST router for the books resource
@@ -28,11 +56,28 @@ func (rs *BooksResource) Routes() chi.Router {
 	r.Get("/", rs.List)    // GET /books - read a list of books
 	r.Post("/", rs.Create) // POST /books - create a new todo and persist it
 
+	r.Get("/search", rs.Search)   // GET /books/search?q= - full text search
+	r.Get("/trash", rs.ListTrash) // GET /books/trash - read a list of trashed books
+	r.Post("/import", rs.Import)  // POST /books/import - create or update books from a CSV, JSON or NDJSON file
+	r.Get("/export", rs.Export)   // GET /books/export?format= - stream every book as csv, ndjson, json or xlsx
+
 	r.Route("/{id}", func(r chi.Router) {
-		r.Use(rs.BookCtx)        // lets have a books map, and lets actually load/manipulate
-		r.Get("/", rs.Get)       // GET /books/{id} - read a single todo by :id
-		r.Put("/", rs.Update)    // PUT /books/{id} - update a single todo by :id
-		r.Delete("/", rs.Delete) // DELETE /books/{id} - delete a single todo by :id
+		r.Get("/history", rs.History)        // GET /books/{id}/history - read revisions of a book, purged ones too
+		r.Get("/history/{rev}", rs.Revision) // GET /books/{id}/history/{rev} - read a single revision of a book
+
+		r.Group(func(r chi.Router) {
+			r.Use(rs.BookCtx)                  // lets have a books map, and lets actually load/manipulate
+			r.Get("/", rs.Get)                 // GET /books/{id} - read a single todo by :id
+			r.Put("/", rs.Update)              // PUT /books/{id} - update a single todo by :id
+			r.Patch("/", rs.Patch)             // PATCH /books/{id} - partially update a single book by :id
+			r.Post("/revert/{rev}", rs.Revert) // POST /books/{id}/revert/{rev} - restore a book to a past revision
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(rs.AnyBookCtx)           // trashed books are found too
+			r.Delete("/", rs.Delete)       // DELETE /books/{id} - trash a single book by :id, ?purge=true removes it for good
+			r.Post("/restore", rs.Restore) // POST /books/{id}/restore - restore a trashed book by :id
+		})
 	})
 
 	return r
@@ -40,77 +85,146 @@ func (rs *BooksResource) Routes() chi.Router {
 
 // List Get all books and filter by query string and sorts
 func (rs *BooksResource) List(w http.ResponseWriter, r *http.Request) {
-	numberOfPages, err := strconv.ParseInt(r.URL.Query().Get("number_of_pages"), 10, 64)
-	yearOfPublication, err := strconv.ParseInt(r.URL.Query().Get("publication_year"), 10, 64)
-	rating, err := strconv.ParseInt(r.URL.Query().Get("rating"), 10, 64)
+	rs.list(w, r, model.BookFilter{})
+}
 
-	filters := &model.BookFilter{}
-	if r.URL.Query().Get("_id") != "" {
-		objectID, err := objectid.FromHex(r.URL.Query().Get("_id"))
-		if err != nil {
-			render.Render(w, r, ErrInvalidRequest(err))
-			return
-		}
+// ListTrash Get trashed books, last trashed first, with the same filters and sorts as List
+func (rs *BooksResource) ListTrash(w http.ResponseWriter, r *http.Request) {
+	rs.list(w, r, model.BookFilter{Trashed: true})
+}
 
-		filters.ID = objectID
-	}
-	if r.URL.Query().Get("author") != "" {
-		filters.Author = r.URL.Query().Get("author")
+// list list books of a scope, trashed books or the others, optionally of a single author, series or work
+func (rs *BooksResource) list(w http.ResponseWriter, r *http.Request, scope model.BookFilter) {
+	filters, err := parseFilters(r.URL.Query(), listParams...)
+	if err != nil {
+		log.Printf("Error parsing filters: %s", err)
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
 	}
-	if r.URL.Query().Get("genre") != "" {
-		filters.Genre = r.URL.Query().Get("genre")
+	trashed := scope.Trashed
+	filters.Trashed = trashed
+	if !scope.AuthorID.IsZero() {
+		filters.AuthorID = scope.AuthorID
 	}
-	if r.URL.Query().Get("number_of_pages") != "" {
-		filters.NumberOfPages = numberOfPages
+	if scope.Series != "" {
+		filters.Series = scope.Series
 	}
-	if r.URL.Query().Get("publication_year") != "" {
-		filters.YearOfPublication = yearOfPublication
+	if !scope.WorkID.IsZero() {
+		filters.WorkID = scope.WorkID
 	}
-	if r.URL.Query().Get("rating") != "" {
-		filters.Rating = rating
+	if err := rs.filterGenres(r.Context(), filters); err != nil {
+		renderError(w, r, err)
+		return
 	}
 
-	sorting := &model.Sorting{
+	defaultSorting := &model.Sorting{
 		Sort:      "publication_year",
 		Direction: -1,
 	}
-	if r.URL.Query().Get("sort") != "" {
-		if err := utils.ParseString(r.URL.Query().Get("sort"), &sorting.Sort); err != nil {
-			log.Printf("Error parsing sort: %s", err)
-			render.Render(w, r, ErrInvalidRequest(err))
-			return
-		}
+	if trashed {
+		defaultSorting.Sort = "deleted_at"
+	}
+	if scope.Series != "" {
+		readingOrder := model.ReadingOrder
+		defaultSorting = &readingOrder
+	}
+	sorting, err := parseSorting(r.URL.Query(), defaultSorting)
+	if err != nil {
+		log.Printf("Error parsing sort: %s", err)
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	pagination, err := parsePagination(r.URL.Query(), sorting)
+	if err != nil {
+		log.Printf("Error parsing pagination: %s", err)
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	// `collapse=true` lists one edition per work
+	var collapse bool
+	utils.ParseBool(r.URL.Query().Get("collapse"), &collapse)
+	var page *model.BookPage
+	if collapse {
+		page, err = rs.collapse(r.Context(), filters, sorting, pagination)
+	} else {
+		page, err = rs.Repo.List(r.Context(), filters, sorting, pagination)
+	}
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	books := make([]*model.Book, len(page.Books))
+	for i := range page.Books {
+		books[i] = &page.Books[i]
+	}
+	if err := rs.embedAuthors(r.Context(), books...); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	setLinkHeader(w, r, page)
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, page)
+}
+
+// Search full text search books, best match first
+func (rs *BooksResource) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		render.Render(w, r, ErrInvalidRequest(errors.New("missing search query \"q\"")))
+		return
 	}
-	if r.URL.Query().Get("direction") != "" {
-		if err := utils.ParseInt32(r.URL.Query().Get("direction"), &sorting.Direction); err != nil {
-			log.Printf("Error parsing direction: %s", err)
-			render.Render(w, r, ErrInvalidRequest(err))
+
+	limit := model.DefaultLimit
+	if r.URL.Query().Get("limit") != "" {
+		if err := utils.ParseInt64(r.URL.Query().Get("limit"), &limit); err != nil || limit < 1 || limit > model.MaxLimit {
+			render.Render(w, r, ErrInvalidRequest(fmt.Errorf("invalid limit: must be between 1 and %d", model.MaxLimit)))
 			return
 		}
 	}
 
-	books, err := rs.Repo.List(filters, sorting)
+	page, err := rs.Repo.Search(r.Context(), query, limit)
 	if err != nil {
-		render.JSON(w, r, err)
+		renderError(w, r, err)
+		return
+	}
+	books := make([]*model.Book, len(page.Results))
+	for i := range page.Results {
+		books[i] = &page.Results[i].Book
+	}
+	if err := rs.embedAuthors(r.Context(), books...); err != nil {
+		renderError(w, r, err)
 		return
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, books)
+	render.JSON(w, r, page)
 }
 
 // Create Add a new book into collection
 func (rs *BooksResource) Create(w http.ResponseWriter, r *http.Request) {
 	data := &model.BookRequest{}
 
-	if err := render.Bind(r, data); err != nil {
-		render.Render(w, r, ErrInvalidRequest(err))
+	if !rs.bind(w, r, data) {
+		return
+	}
+	if err := rs.link(r.Context(), data.Book, nil); err != nil {
+		renderError(w, r, err)
 		return
 	}
 
-	if created, err := rs.Repo.Create(data.Book); err != nil {
-		render.JSON(w, r, err)
+	var created *model.Book
+	err := rs.fileWork(r.Context(), data.Book, func() (err error) {
+		created, err = rs.repo(r).Create(r.Context(), data.Book)
+		return err
+	})
+	if err != nil {
+		renderError(w, r, err)
 	} else {
+		w.Header().Set("ETag", bookETag(created))
+		created.ProjectContributors()
 		render.Status(r, http.StatusOK)
 		render.JSON(w, r, created)
 	}
@@ -120,6 +234,17 @@ func (rs *BooksResource) Create(w http.ResponseWriter, r *http.Request) {
 func (rs *BooksResource) Get(w http.ResponseWriter, r *http.Request) {
 	book := r.Context().Value("book").(*model.Book)
 
+	etag := bookETag(book)
+	w.Header().Set("ETag", etag)
+	if header := r.Header.Get("If-None-Match"); header != "" && matchETag(header, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if err := rs.embedAuthors(r.Context(), book); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
 	render.Status(r, http.StatusOK)
 	render.JSON(w, r, &book)
 }
@@ -128,47 +253,182 @@ func (rs *BooksResource) Get(w http.ResponseWriter, r *http.Request) {
 func (rs *BooksResource) Update(w http.ResponseWriter, r *http.Request) {
 	book := r.Context().Value("book").(*model.Book)
 
-	data := &model.BookRequest{Book: book}
-	if err := render.Bind(r, data); err != nil {
-		render.Render(w, r, ErrInvalidRequest(err))
+	if !rs.checkIfMatch(w, r, book) {
+		return
+	}
+
+	previous := *book
+	data := &model.BookRequest{Book: book, Previous: &previous}
+	if !rs.bind(w, r, data) {
 		return
 	}
 	book = data.Book
+	if err := rs.link(r.Context(), book, &previous); err != nil {
+		renderError(w, r, err)
+		return
+	}
 
-	if updated, err := rs.Repo.Update(book); err != nil {
-		render.JSON(w, r, err)
+	var updated *model.Book
+	err := rs.fileWork(r.Context(), book, func() (err error) {
+		updated, err = rs.repo(r).Update(r.Context(), book)
+		return err
+	})
+	if err != nil {
+		renderError(w, r, err)
+	} else {
+		w.Header().Set("ETag", bookETag(updated))
+		updated.ProjectContributors()
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, updated)
+	}
+}
+
+// Patch partially update a book with a JSON Merge Patch or a JSON Patch
+func (rs *BooksResource) Patch(w http.ResponseWriter, r *http.Request) {
+	book := r.Context().Value("book").(*model.Book)
+
+	if !rs.checkIfMatch(w, r, book) {
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != patch.MergePatchContentType && contentType != patch.JSONPatchContentType {
+		render.Render(w, r, ErrUnsupportedMediaType(fmt.Errorf("expected %s or %s", patch.MergePatchContentType, patch.JSONPatchContentType)))
+		return
+	}
+
+	body, ok := rs.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	patched, err := applyPatch(book, contentType, body, rs.StrictJSON)
+	if _, ok := err.(*patch.TestFailedError); ok {
+		render.Render(w, r, ErrConflict(err))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if err := rs.link(r.Context(), patched, book); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	var updated *model.Book
+	err = rs.fileWork(r.Context(), patched, func() (err error) {
+		fields := model.ChangedFields(book, patched)
+		// work_id is managed, it only changes when the book joins a work
+		if patched.WorkID != book.WorkID {
+			fields = append(fields, "work_id")
+		}
+		updated, err = rs.repo(r).Patch(r.Context(), patched, fields)
+		return err
+	})
+	if err != nil {
+		renderError(w, r, err)
 	} else {
+		w.Header().Set("ETag", bookETag(updated))
+		updated.ProjectContributors()
 		render.Status(r, http.StatusOK)
 		render.JSON(w, r, updated)
 	}
 }
 
-// Delete remove a book into your bookshelf by ID
+// Delete move a book to trash by ID, or remove it for good with `?purge=true`
 func (rs *BooksResource) Delete(w http.ResponseWriter, r *http.Request) {
 	book := r.Context().Value("book").(*model.Book)
 
-	if deleted, err := rs.Repo.Delete(book.ID); err != nil {
-		render.JSON(w, r, err)
+	var purge bool
+	utils.ParseBool(r.URL.Query().Get("purge"), &purge)
+
+	if book.DeletedAt != nil && !purge {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if !rs.checkIfMatch(w, r, book) {
+		return
+	}
+
+	var deleted int64
+	var err error
+	if purge {
+		deleted, err = rs.repo(r).Purge(r.Context(), book.ID)
+	} else {
+		deleted, err = rs.repo(r).Delete(r.Context(), book.ID, book.Version)
+	}
+
+	if err != nil {
+		renderError(w, r, err)
 	} else {
 		render.Status(r, http.StatusOK)
 		render.JSON(w, r, deleted)
 	}
 }
 
-// BookCtx build book context and inject `model.Book` into request
+// Restore move a book out of trash by ID
+func (rs *BooksResource) Restore(w http.ResponseWriter, r *http.Request) {
+	book := r.Context().Value("book").(*model.Book)
+
+	if book.DeletedAt == nil {
+		render.Render(w, r, ErrConflict(errors.New("book is not in trash")))
+		return
+	}
+	if !rs.checkIfMatch(w, r, book) {
+		return
+	}
+
+	if restored, err := rs.repo(r).Restore(r.Context(), book.ID); err != nil {
+		renderError(w, r, err)
+	} else if err := rs.embedAuthors(r.Context(), restored); err != nil {
+		renderError(w, r, err)
+	} else {
+		w.Header().Set("ETag", bookETag(restored))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, restored)
+	}
+}
+
+// link check the authors a book links to and file it under the genre and series it names, before is the stored
+// book, nil on create. Works are filed by fileWork along the write
+func (rs *BooksResource) link(ctx context.Context, book *model.Book, before *model.Book) error {
+	if err := rs.linkAuthors(ctx, book); err != nil {
+		return err
+	}
+	if err := rs.fileGenre(ctx, book, before); err != nil {
+		return err
+	}
+	return rs.fileSeries(ctx, book, before)
+}
+
+// BookCtx build book context and inject `model.Book` into request, trashed books are not found
 func (rs *BooksResource) BookCtx(next http.Handler) http.Handler {
+	return rs.bookCtx(next, false)
+}
+
+// AnyBookCtx same as BookCtx, but trashed books are found too
+func (rs *BooksResource) AnyBookCtx(next http.Handler) http.Handler {
+	return rs.bookCtx(next, true)
+}
+
+// bookCtx build book context, looking into trash when withTrashed
+func (rs *BooksResource) bookCtx(next http.Handler, withTrashed bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var book *model.Book
 		var err error
 
 		if ID := chi.URLParam(r, "id"); ID != "" {
-			book, err = rs.Repo.Get(ID)
+			book, err = rs.Repo.Get(r.Context(), ID)
+			if err == model.ErrNotFound && withTrashed {
+				book, err = rs.Repo.GetTrashed(r.Context(), ID)
+			}
 		} else {
 			render.Render(w, r, ErrNotFound())
 			return
 		}
 		if err != nil {
-			render.Render(w, r, ErrNotFound())
+			renderError(w, r, err)
 			return
 		}
 
@@ -1,22 +1,32 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/MathieuDoyon/bookshelf/server/interfaces/mocks"
 	"github.com/MathieuDoyon/bookshelf/server/model"
 	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestBookList(t *testing.T) {
-	var expected []model.Book
-
-	expected = append(expected, model.Book{
-		Author: "Mathieu Doyon",
-	})
+	expected := &model.BookPage{
+		Books: []model.Book{
+			{Author: "Mathieu Doyon"},
+		},
+		Total: 1,
+		Limit: 10,
+	}
 
 	filters := &model.BookFilter{}
 
@@ -26,7 +36,11 @@ func TestBookList(t *testing.T) {
 	}
 
 	repoMock := &mocks.IBookRepository{}
-	repoMock.On("List", filters, sorting).Return(expected, nil) // mock the expectation
+	pagination := &model.Pagination{
+		Limit: 10,
+	}
+
+	repoMock.On("List", mock.Anything, filters, sorting, pagination).Return(expected, nil) // mock the expectation
 
 	bookResource := BooksResource{
 		Repo: repoMock,
@@ -41,7 +55,7 @@ func TestBookList(t *testing.T) {
 
 	r.ServeHTTP(w, req)
 
-	response := []model.Book{}
+	response := &model.BookPage{}
 
 	json.NewDecoder(w.Body).Decode(&response)
 
@@ -50,3 +64,788 @@ func TestBookList(t *testing.T) {
 	repoMock.AssertNumberOfCalls(t, "List", 1)
 	repoMock.AssertExpectations(t)
 }
+
+func TestBookListPagination(t *testing.T) {
+	sorting := &model.Sorting{
+		Sort:      "publication_year",
+		Direction: -1,
+	}
+	cursor := &model.Cursor{
+		Sort:      "publication_year",
+		Direction: -1,
+		Value:     int64(2011),
+		ID:        objectid.New(),
+	}
+	next := &model.Cursor{
+		Sort:      "publication_year",
+		Direction: -1,
+		Value:     int64(1999),
+		ID:        objectid.New(),
+	}
+
+	expected := &model.BookPage{
+		Books:      []model.Book{{Author: "Mathieu Doyon"}},
+		Total:      42,
+		Limit:      5,
+		NextCursor: next.Encode(),
+	}
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, &model.BookFilter{}, sorting, mock.MatchedBy(func(p *model.Pagination) bool {
+		return p.Limit == 5 && p.Cursor != nil && p.Cursor.ID == cursor.ID && p.Cursor.Value == int64(2011)
+	})).Return(expected, nil)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books?limit=5&cursor="+cursor.Encode(), nil)
+	w := httptest.NewRecorder()
+
+	r := chi.NewRouter()
+	r.HandleFunc("/books", bookResource.List)
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Header().Get("Link"), `</books?limit=5>; rel="first"`)
+	assert.Contains(t, w.Header().Get("Link"), `</books?cursor=`+next.Encode()+`&limit=5>; rel="next"`)
+	repoMock.AssertExpectations(t)
+}
+
+func TestBookListInvalidPagination(t *testing.T) {
+	bookResource := BooksResource{
+		Repo: &mocks.IBookRepository{},
+	}
+
+	r := chi.NewRouter()
+	r.HandleFunc("/books", bookResource.List)
+
+	for _, query := range []string{"limit=0", "limit=1000", "offset=-1", "cursor=foo", "offset=1&cursor=foo"} {
+		req := httptest.NewRequest("GET", "http://localhost:8080/books?"+query, nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 400, w.Code, query)
+	}
+}
+
+func TestBookListCursorMismatch(t *testing.T) {
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, model.ErrInvalidCursor)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.HandleFunc("/books", bookResource.List)
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code, "It should answer cursors the repository rejects as invalid requests")
+	assert.Contains(t, w.Body.String(), `"code":2006`)
+}
+
+func TestBookListOperatorFilters(t *testing.T) {
+	filters := &model.BookFilter{
+		Author: "Mathieu Doyon",
+		Conditions: []model.FilterCondition{
+			// genres are expanded to their subgenres like `genre=`
+			{Field: "genre", Operator: model.OpIn, Value: []interface{}{"Fiction", "Fantasy", "Historical Fiction", "Horror", "Mystery", "Romance", "Science Fiction", "Thriller"}},
+			{Field: "rating", Operator: model.OpGte, Value: int64(4)},
+		},
+	}
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, filters, mock.Anything, mock.Anything).Return(&model.BookPage{}, nil)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.HandleFunc("/books", bookResource.List)
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books?author=Mathieu+Doyon&rating[gte]=4&genre[in]=Fiction,Fantasy", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	repoMock.AssertExpectations(t)
+
+	var nonFiction []interface{}
+	for _, genre := range model.NewTaxonomy(model.DefaultGenres).Subtree("Non-Fiction") {
+		nonFiction = append(nonFiction, genre)
+	}
+	excluded := &model.BookFilter{Conditions: []model.FilterCondition{
+		{Field: "genre", Operator: model.OpNin, Value: append(nonFiction, "Sardines")},
+	}}
+	repoMock.On("List", mock.Anything, excluded, mock.Anything, mock.Anything).Return(&model.BookPage{}, nil)
+	req = httptest.NewRequest("GET", "http://localhost:8080/books?genre[nin]=Nonfiction,Sardines", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code, "It should exclude synonyms and subgenres, and keep genres unknown to the tree")
+	repoMock.AssertExpectations(t)
+
+	for _, query := range []string{"rating[like]=4", "rating[gte]=four", "ratting[gte]=4", "rating[gte=4", "rating=four", "ratting=5"} {
+		req := httptest.NewRequest("GET", "http://localhost:8080/books?"+query, nil)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, 400, w.Code, query)
+	}
+}
+
+func TestBookSearch(t *testing.T) {
+	expected := &model.SearchPage{
+		Query: "doyon",
+		Results: []model.SearchResult{
+			{Book: model.Book{Author: "Mathieu Doyon"}, Score: 3.75, Highlights: map[string]string{"author": "Mathieu <em>Doyon</em>"}},
+		},
+		Total: 1,
+	}
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Search", mock.Anything, "doyon", int64(10)).Return(expected, nil)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books/search?q=doyon", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	response := &model.SearchPage{}
+	json.NewDecoder(w.Body).Decode(response)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, expected, response)
+	repoMock.AssertExpectations(t)
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/books/search", nil)
+	w = httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code, "It should require a query")
+}
+
+func TestBookPatch(t *testing.T) {
+	ID := objectid.New()
+	book := &model.Book{ID: ID, Title: "Sardines", Author: "Mathieu Doyon", Genre: "Fiction", Rating: 3}
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(func(context.Context, string) *model.Book {
+		copy := *book
+		return &copy
+	}, nil)
+	repoMock.On("Patch", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, b *model.Book, fields []string) *model.Book {
+		return b
+	}, nil)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	cases := []struct {
+		contentType string
+		body        string
+		code        int
+		fields      []string
+	}{
+		{"application/merge-patch+json", `{"rating":5,"genre":null}`, 200, []string{"genre", "rating"}},
+		{"application/json-patch+json", `[{"op":"test","path":"/rating","value":3},{"op":"replace","path":"/title","value":"Trawler"}]`, 200, []string{"title"}},
+		{"application/json-patch+json", `[{"op":"test","path":"/rating","value":4},{"op":"replace","path":"/title","value":"Trawler"}]`, 409, nil},
+		{"application/json-patch+json", `[{"op":"remove","path":"/missing"}]`, 400, nil},
+		{"application/merge-patch+json", `{"_id":"` + objectid.New().Hex() + `"}`, 400, nil},
+		{"application/merge-patch+json", `{"rating":"five"}`, 400, nil},
+		{"application/json", `{"rating":5}`, 415, nil},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("PATCH", "http://localhost:8080/books/"+ID.Hex(), strings.NewReader(c.body))
+		req.Header.Set("Content-Type", c.contentType)
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, c.code, w.Code, c.body)
+		if c.fields != nil {
+			repoMock.AssertCalled(t, "Patch", mock.Anything, mock.Anything, c.fields)
+		}
+	}
+
+	repoMock.AssertNumberOfCalls(t, "Patch", 2)
+}
+
+func TestBookETag(t *testing.T) {
+	ID := objectid.New()
+	book := &model.Book{ID: ID, Title: "Sardines", Version: 3}
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(func(context.Context, string) *model.Book {
+		copy := *book
+		return &copy
+	}, nil)
+	repoMock.On("Delete", mock.Anything, ID, int64(3)).Return(int64(1), nil)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books/"+ID.Hex(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/books/"+ID.Hex(), nil)
+	req.Header.Set("If-None-Match", `W/"3"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 304, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	req = httptest.NewRequest("PUT", "http://localhost:8080/books/"+ID.Hex(), strings.NewReader(`{"rating":5}`))
+	req.Header.Set("If-Match", `"2"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 412, w.Code, "It should reject stale versions")
+	repoMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	req = httptest.NewRequest("DELETE", "http://localhost:8080/books/"+ID.Hex(), nil)
+	req.Header.Set("If-Match", `"1", "3"`)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	repoMock.AssertCalled(t, "Delete", mock.Anything, ID, int64(3))
+
+	bookResource.RequireIfMatch = true
+	r = chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req = httptest.NewRequest("DELETE", "http://localhost:8080/books/"+ID.Hex(), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 428, w.Code, "It should require If-Match when configured")
+	repoMock.AssertNumberOfCalls(t, "Delete", 1)
+}
+
+func TestBookUpdateVersionConflict(t *testing.T) {
+	ID := objectid.New()
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(&model.Book{ID: ID, Title: "Sardines", Version: 3}, nil)
+	repoMock.On("Update", mock.Anything, mock.Anything).Return(nil, model.ErrVersionConflict)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("PUT", "http://localhost:8080/books/"+ID.Hex(), strings.NewReader(`{"rating":5,"version":42}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 412, w.Code, "It should answer 412 when the book changed during the update")
+	repoMock.AssertCalled(t, "Update", mock.Anything, mock.MatchedBy(func(b *model.Book) bool {
+		return b.Version == 3 && b.Rating == 5
+	}))
+}
+
+func TestBookTrash(t *testing.T) {
+	ID := objectid.New()
+	deletedAt := time.Date(2018, 10, 1, 0, 0, 0, 0, time.UTC)
+	trashed := &model.Book{ID: ID, Version: 4, DeletedAt: &deletedAt}
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(nil, model.ErrNotFound)
+	repoMock.On("GetTrashed", mock.Anything, ID.Hex()).Return(trashed, nil)
+	repoMock.On("Restore", mock.Anything, ID).Return(&model.Book{ID: ID, Version: 5}, nil)
+	repoMock.On("Purge", mock.Anything, ID).Return(int64(1), nil)
+	repoMock.On("List", mock.Anything, &model.BookFilter{Trashed: true}, &model.Sorting{Sort: "deleted_at", Direction: -1}, mock.Anything).
+		Return(&model.BookPage{Books: []model.Book{*trashed}, Total: 1}, nil)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books/trash", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	repoMock.AssertNumberOfCalls(t, "List", 1)
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/books/"+ID.Hex(), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code, "It should hide trashed books")
+
+	req = httptest.NewRequest("DELETE", "http://localhost:8080/books/"+ID.Hex(), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code, "It should not trash a book twice")
+	repoMock.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+
+	req = httptest.NewRequest("POST", "http://localhost:8080/books/"+ID.Hex()+"/restore", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+
+	req = httptest.NewRequest("DELETE", "http://localhost:8080/books/"+ID.Hex()+"?purge=true", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	repoMock.AssertCalled(t, "Purge", mock.Anything, ID)
+}
+
+func TestBookRestoreNotTrashed(t *testing.T) {
+	ID := objectid.New()
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(&model.Book{ID: ID, Version: 1}, nil)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/books/"+ID.Hex()+"/restore", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 409, w.Code)
+	repoMock.AssertNotCalled(t, "Restore", mock.Anything, mock.Anything)
+}
+
+func TestBookHistory(t *testing.T) {
+	ID := objectid.New()
+	book := &model.Book{ID: ID, Title: "Sardines", Version: 2}
+	revisions := []model.Revision{
+		{BookID: ID, Number: 1, Action: model.ActionCreate, Book: &model.Book{ID: ID, Title: "Trawler", Version: 1}},
+		{BookID: ID, Number: 2, Action: model.ActionUpdate, Book: book},
+	}
+
+	repoMock := &mocks.IHistoryRepository{}
+	repoMock.On("WithActor", `192.0.2.1:1234 (unverified "mathieu")`).Return(repoMock)
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(book, nil)
+	repoMock.On("History", mock.Anything, ID).Return(revisions, nil)
+	repoMock.On("Revision", mock.Anything, ID, int64(1)).Return(&revisions[0], nil)
+	repoMock.On("Revision", mock.Anything, ID, int64(3)).Return(nil, model.ErrRevisionNotFound)
+	repoMock.On("Revert", mock.Anything, book, int64(1)).Return(&model.Book{ID: ID, Title: "Trawler", Version: 3}, nil)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books/"+ID.Hex()+"/history", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	response := []model.Revision{}
+	json.NewDecoder(w.Body).Decode(&response)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Len(t, response, 2)
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/books/"+ID.Hex()+"/history/1", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/books/"+ID.Hex()+"/history/3", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code)
+
+	req = httptest.NewRequest("POST", "http://localhost:8080/books/"+ID.Hex()+"/revert/1", nil)
+	req.Header.Set(ActorHeader, "mathieu")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	// the claimed actor is recorded unverified, next to the client address
+	repoMock.AssertCalled(t, "WithActor", `192.0.2.1:1234 (unverified "mathieu")`)
+}
+
+func TestBookHistoryDisabled(t *testing.T) {
+	ID := objectid.New()
+
+	bookResource := BooksResource{
+		Repo: &mocks.IBookRepository{},
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books/"+ID.Hex()+"/history", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 404, w.Code, "It should answer 404 when the repository keeps no history")
+}
+
+func TestBookImport(t *testing.T) {
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, b *model.Book) *model.Book {
+		b.ID = objectid.New()
+		return b
+	}, nil)
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/books/import", strings.NewReader("title,author\nSardines,Mathieu Doyon\n"))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"created":1`)
+	assert.Contains(t, w.Body.String(), `"rows":[{"row":1,"status":"created","_id":`)
+	repoMock.AssertCalled(t, "Create", mock.Anything, mock.MatchedBy(func(b *model.Book) bool {
+		return b.Title == "Sardines" && b.Author == "Mathieu Doyon"
+	}))
+
+	req = httptest.NewRequest("POST", "http://localhost:8080/books/import", strings.NewReader("title\n"))
+	req.Header.Set("Content-Type", "text/plain")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 415, w.Code)
+}
+
+func TestBookExport(t *testing.T) {
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{Rating: 5}, &model.Sorting{Sort: "_id", Direction: 1}, mock.Anything).
+		Return(func(_ context.Context, filters *model.BookFilter, sorting *model.Sorting, fn func(*model.Book) error) error {
+			fn(&model.Book{Title: "Sardines", Rating: 5})
+			return fn(&model.Book{Title: "Trawler", Rating: 5})
+		})
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books/export?rating=5&columns=title,rating", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "title,rating\nSardines,5\nTrawler,5\n", w.Body.String())
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/books/export?format=pdf", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code)
+}
+
+func TestBookExportAborted(t *testing.T) {
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{}, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, filters *model.BookFilter, sorting *model.Sorting, fn func(*model.Book) error) error {
+			for i := 0; i < exportFlushEvery; i++ {
+				fn(&model.Book{Title: "Sardines"})
+			}
+			return errors.New("connection closed")
+		})
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{Rating: 1}, mock.Anything, mock.Anything).
+		Return(errors.New("connection closed"))
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Use(Recoverer)
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books/export?format=json&columns=title", nil)
+	w := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() { r.ServeHTTP(w, req) }, "It should abort a response already sent")
+	assert.True(t, strings.HasPrefix(w.Body.String(), `[{"title":"Sardines"}`))
+	assert.False(t, strings.HasSuffix(strings.TrimSpace(w.Body.String()), "]"), "It should not close a truncated document")
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/books/export?rating=1", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 500, w.Code, "It should answer the error while nothing was sent")
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+}
+
+func TestBookContextErrors(t *testing.T) {
+	ID := objectid.New()
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, context.DeadlineExceeded)
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(nil, errors.New("connection closed"))
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 504, w.Code, "It should answer a gateway timeout on deadline")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req = httptest.NewRequest("GET", "http://localhost:8080/books/"+ID.Hex(), nil).WithContext(ctx)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, StatusClientClosedRequest, w.Code, "It should not answer not found when the client went away")
+}
+
+func TestBookProblemDetails(t *testing.T) {
+	ID := objectid.New()
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Get", mock.Anything, "nope").Return(nil, model.ErrInvalidID)
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(nil, model.Unavailable(errors.New("no reachable servers")))
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books/nope", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := map[string]interface{}{}
+	json.NewDecoder(w.Body).Decode(&problem)
+
+	assert.Equal(t, 400, w.Code, "It should not answer not found for invalid ids")
+	assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, float64(400), problem["status"])
+	assert.Equal(t, float64(model.CodeInvalidID), problem["code"])
+	assert.Equal(t, "/books/nope", problem["instance"])
+	assert.NotEmpty(t, problem["request_id"])
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/books/"+ID.Hex(), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 503, w.Code, "It should not answer not found when the storage is down")
+	assert.Contains(t, w.Body.String(), `"code":1001`)
+	assert.NotContains(t, w.Body.String(), "no reachable servers", "It should log the storage error instead of answering it")
+}
+
+func TestBookListUndecodable(t *testing.T) {
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, model.Undecodable("5b9c3f1e8f1c2a0001a1b2c3", errors.New("cannot decode")))
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 500, w.Code, "It should fail when a book can't be decoded in strict mode")
+	assert.Contains(t, w.Body.String(), `"code":1004`)
+	assert.Contains(t, w.Body.String(), "5b9c3f1e8f1c2a0001a1b2c3")
+	assert.NotContains(t, w.Body.String(), "cannot decode")
+}
+
+func TestBookCreateInvalid(t *testing.T) {
+	repoMock := &mocks.IBookRepository{}
+
+	bookResource := BooksResource{
+		Repo: repoMock,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/books", strings.NewReader(`{"author":"Mathieu Doyon","rating":42,"number_of_pages":-1}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := &ErrResponse{}
+	json.NewDecoder(w.Body).Decode(problem)
+
+	assert.Equal(t, 422, w.Code)
+	assert.Equal(t, model.CodeValidation, problem.AppCode)
+	assert.Equal(t, []model.FieldError{
+		{Field: "title", Reason: model.ReasonRequired, Message: "is required"},
+		{Field: "number_of_pages", Reason: model.ReasonOutOfRange, Message: "must be between 1 and 100000"},
+		{Field: "rating", Reason: model.ReasonOutOfRange, Message: "must be between 1 and 5"},
+	}, problem.Errors, "It should list every invalid field")
+	repoMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestBookStrictJSON(t *testing.T) {
+	ID := objectid.New()
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(&model.Book{ID: ID, Title: "Sardines", Rating: 3, Version: 1}, nil)
+
+	bookResource := BooksResource{
+		Repo:          repoMock,
+		StrictJSON:    true,
+		MaxBodySize:   64,
+		MaxImportSize: 16,
+	}
+
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	cases := []struct {
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		detail      string
+	}{
+		{"PUT", "/books/" + ID.Hex(), "application/json", `{"ratting":5}`, 400, `line 1, column 2: unknown field "ratting"`},
+		{"PUT", "/books/" + ID.Hex(), "application/json", `{"rating":5,"rating":4}`, 400, `line 1, column 13: duplicate key "rating"`},
+		{"PUT", "/books/" + ID.Hex(), "application/json", `{"rating":"5"}`, 400, `line 1, column 2: rating expects int64, got string`},
+		{"PUT", "/books/" + ID.Hex(), "application/json", `{"rating":5}{}`, 400, `line 1, column 13: trailing data after the JSON value`},
+		{"PUT", "/books/" + ID.Hex(), "text/plain", `{"rating":5}`, 415, "expected application/json"},
+		{"POST", "/books", "application/json", `{"title":"` + strings.Repeat("a", 64) + `"}`, 413, "request body is larger than 64 bytes"},
+		{"PATCH", "/books/" + ID.Hex(), "application/merge-patch+json", `{"ratting":5}`, 400, `unknown field "ratting"`},
+		{"POST", "/books/import", "text/csv", "title\nSardines\nTrawler\n", 413, "request body is larger than 16 bytes"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, "http://localhost:8080"+c.path, strings.NewReader(c.body))
+		req.Header.Set("Content-Type", c.contentType)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		problem := &ErrResponse{}
+		json.NewDecoder(w.Body).Decode(problem)
+
+		assert.Equal(t, c.status, w.Code, c.body)
+		assert.Equal(t, c.detail, problem.ErrorText, c.body)
+	}
+	repoMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBookContributors(t *testing.T) {
+	ID := objectid.New()
+	book := &model.Book{ID: ID, Title: "Sardinas", Author: "Mathieu Doyon", Contributors: []model.Contributor{
+		{Name: "Mathieu Doyon", Role: model.RoleAuthor},
+		{Name: "Jane Roe", Role: model.RoleTranslator},
+	}}
+	filters := &model.BookFilter{Contributors: []model.Contributor{
+		{Name: "Jane Roe", Role: model.RoleTranslator},
+		{Name: "John Roe", Role: model.RoleNarrator},
+	}}
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, filters, mock.Anything, mock.Anything).Return(&model.BookPage{
+		Books: []model.Book{{ID: objectid.New(), Title: "Seagulls", Author: "Eric Cantona"}},
+	}, nil)
+	repoMock.On("Get", mock.Anything, ID.Hex()).Return(func(context.Context, string) *model.Book {
+		copy := *book
+		return &copy
+	}, nil)
+	repoMock.On("Patch", mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, b *model.Book, fields []string) *model.Book {
+		return b
+	}, nil)
+
+	bookResource := BooksResource{Repo: repoMock}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books?translator=Jane+Roe&narrator=John+Roe", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	page := &model.BookPage{}
+	json.NewDecoder(w.Body).Decode(page)
+	assert.Equal(t, 200, w.Code)
+	if assert.Len(t, page.Books, 1) {
+		assert.Equal(t, []model.Contributor{{Name: "Eric Cantona", Role: model.RoleAuthor}}, page.Books[0].Contributors, "It should list authors of books without contributors")
+	}
+
+	// a client unaware of contributors changes the author
+	req = httptest.NewRequest("PATCH", "http://localhost:8080/books/"+ID.Hex(), strings.NewReader(`{"author":"Eric Cantona"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	patched := &model.Book{}
+	json.NewDecoder(w.Body).Decode(patched)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, []model.Contributor{
+		{Name: "Eric Cantona", Role: model.RoleAuthor},
+		{Name: "Jane Roe", Role: model.RoleTranslator},
+	}, patched.Contributors, "It should replace the author contributors only")
+	repoMock.AssertCalled(t, "Patch", mock.Anything, mock.Anything, []string{"author", "contributors"})
+
+	req = httptest.NewRequest("PATCH", "http://localhost:8080/books/"+ID.Hex(), strings.NewReader(`{"contributors":[{"name":"Jane Roe","role":"colourist"}]}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 422, w.Code)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"github.com/MathieuDoyon/bookshelf/server/strictjson"
+	"github.com/go-chi/render"
+)
+
+// JSONContentType content type of book and author bodies
+const JSONContentType = "application/json"
+
+// errBodyTooLarge returned by readBody when the body is over the limit
+var errBodyTooLarge = errors.New("request body too large")
+
+// bind decode a JSON body into v then bind it with the resource settings, answering the request on error.
+//
+// Returns false when the request was answered.
+func (rs *BooksResource) bind(w http.ResponseWriter, r *http.Request, v render.Binder) bool {
+	return bindJSON(w, r, v, rs.StrictJSON, rs.MaxBodySize)
+}
+
+// readBody read the whole request body up to MaxBodySize, answering the request on error.
+//
+// Returns false when the request was answered.
+func (rs *BooksResource) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	return readBody(w, r, rs.MaxBodySize)
+}
+
+// bindJSON decode a JSON body of at most limit bytes into v then bind it, answering the request on error.
+//
+// Strict decoding rejects unknown fields, duplicate keys and trailing data. Returns false when the request was answered.
+func bindJSON(w http.ResponseWriter, r *http.Request, v render.Binder, strict bool, limit int64) bool {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType != JSONContentType {
+		render.Render(w, r, ErrUnsupportedMediaType(fmt.Errorf("expected %s", JSONContentType)))
+		return false
+	}
+
+	body, ok := readBody(w, r, limit)
+	if !ok {
+		return false
+	}
+
+	var err error
+	if strict {
+		err = strictjson.Decode(body, v)
+	} else {
+		err = json.Unmarshal(body, v)
+	}
+	if err == nil {
+		err = v.Bind(r)
+	}
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return false
+	}
+	return true
+}
+
+// readBody read the whole request body up to limit bytes, answering the request on error.
+//
+// Returns false when the request was answered.
+func readBody(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, bool) {
+	body, err := readAll(r.Body, limit)
+	if err == errBodyTooLarge {
+		render.Render(w, r, ErrRequestEntityTooLarge(fmt.Errorf("request body is larger than %d bytes", limit)))
+		return nil, false
+	}
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return nil, false
+	}
+	return body, true
+}
+
+// readAll read body up to limit bytes, 0 is unlimited
+func readAll(body io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return ioutil.ReadAll(body)
+	}
+
+	// one more byte tells the body is over the limit
+	data, err := ioutil.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errBodyTooLarge
+	}
+	return data, nil
+}
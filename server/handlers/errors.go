@@ -1,8 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"log"
 	"net/http"
 
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/strictjson"
+	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/render"
 )
 
@@ -10,42 +16,277 @@ import (
 // Error response payloads & renderers
 //--
 
-// ErrResponse renderer type for handling all sorts of errors.
+// ProblemContentType content type of error responses, see RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// StatusClientClosedRequest non standard status logged when the client went away before the response, as nginx does
+const StatusClientClosedRequest = 499
+
+func init() {
+	render.Respond = respond
+}
+
+// ErrResponse renderer type for handling all sorts of errors, answered as RFC 7807 problem details.
 //
-// In the best case scenario, the excellent github.com/pkg/errors package
-// helps reveal information on the error, setting it on Err, and in the Render()
-// method, using it to set the application-specific error code in AppCode.
+// AppCode is a stable `model.Code*` value clients can rely on, the request id matches the server logs.
 type ErrResponse struct {
-	Err            error `json:"-"` // low-level runtime error
-	HTTPStatusCode int   `json:"-"` // http response status code
+	Err            error `json:"-"`      // low-level runtime error
+	HTTPStatusCode int   `json:"status"` // http response status code
+
+	Type       string `json:"type"`                 // problem type, the status is enough to describe it
+	StatusText string `json:"title"`                // user-level status message
+	AppCode    int64  `json:"code,omitempty"`       // application-specific error code
+	ErrorText  string `json:"detail,omitempty"`     // application-level error message, for debugging
+	Instance   string `json:"instance,omitempty"`   // path of the request which failed
+	RequestID  string `json:"request_id,omitempty"` // id set by `middleware.RequestID`
 
-	StatusText string `json:"status"`          // user-level status message
-	AppCode    int64  `json:"code,omitempty"`  // application-specific error code
-	ErrorText  string `json:"error,omitempty"` // application-level error message, for debugging
+	Errors []model.FieldError `json:"errors,omitempty"` // every invalid field of a validation error
 }
 
-// Render render function for error handlers
+// Render render function for error handlers, server errors are logged since their detail leaves out the cause
 func (e *ErrResponse) Render(w http.ResponseWriter, r *http.Request) error {
+	e.Type = "about:blank"
+	e.Instance = r.URL.Path
+	e.RequestID = middleware.GetReqID(r.Context())
+	if e.Err != nil && (e.HTTPStatusCode == 500 || e.HTTPStatusCode == 503) {
+		log.Printf("Error answering %s %s [%s]: %s", r.Method, r.URL.Path, e.RequestID, e.Err)
+	}
 	render.Status(r, e.HTTPStatusCode)
 	return nil
 }
 
-// var ErrNotFound = &ErrResponse{HTTPStatusCode: 404, StatusText: "Resource not found."}
+// respond answer problem details with their own content type, anything else like `render.DefaultResponder`
+func respond(w http.ResponseWriter, r *http.Request, v interface{}) {
+	problem, ok := v.(*ErrResponse)
+	if !ok {
+		render.DefaultResponder(w, r, v)
+		return
+	}
+
+	body, err := json.Marshal(problem)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.HTTPStatusCode)
+	w.Write(body)
+}
 
 // ErrNotFound not found
 func ErrNotFound() render.Renderer {
 	return &ErrResponse{
 		HTTPStatusCode: 404,
 		StatusText:     "Resource not found.",
+		AppCode:        model.CodeNotFound,
 	}
 }
 
-// ErrInvalidRequest invalid request
+// ErrInvalidRequest invalid request, typed model errors keep their own status
 func ErrInvalidRequest(err error) render.Renderer {
+	if e, ok := err.(*model.Error); ok {
+		return modelError(e)
+	}
+
+	code := model.CodeInvalidRequest
+	if _, ok := err.(*strictjson.Error); ok {
+		code = model.CodeMalformedJSON
+	}
 	return &ErrResponse{
 		Err:            err,
 		HTTPStatusCode: 400,
 		StatusText:     "Invalid request.",
+		AppCode:        code,
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrConflict conflict with the current state of the resource
+func ErrConflict(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 409,
+		StatusText:     "Conflict.",
+		AppCode:        errorCode(err, model.CodeConflict),
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrRequestEntityTooLarge request body is larger than allowed
+func ErrRequestEntityTooLarge(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 413,
+		StatusText:     "Request entity too large.",
+		AppCode:        model.CodeRequestTooLarge,
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrUnsupportedMediaType request body content type isn't supported
+func ErrUnsupportedMediaType(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 415,
+		StatusText:     "Unsupported media type.",
+		AppCode:        model.CodeUnsupportedMediaType,
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrPreconditionFailed conditional request header didn't match the resource
+func ErrPreconditionFailed(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 412,
+		StatusText:     "Precondition failed.",
+		AppCode:        errorCode(err, model.CodeVersionConflict),
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrPreconditionRequired conditional request header is missing
+func ErrPreconditionRequired(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 428,
+		StatusText:     "Precondition required.",
+		AppCode:        model.CodePreconditionRequired,
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrUnprocessableEntity the request is well formed but some of its values are invalid
+func ErrUnprocessableEntity(err error) render.Renderer {
+	response := &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 422,
+		StatusText:     "Unprocessable entity.",
+		AppCode:        errorCode(err, model.CodeValidation),
 		ErrorText:      err.Error(),
 	}
+	if e, ok := err.(*model.Error); ok {
+		response.Errors = e.Fields
+	}
+	return response
+}
+
+// ErrInternal unexpected error, logged with the request id and answered without its cause
+func ErrInternal(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 500,
+		StatusText:     "Internal server error.",
+		AppCode:        errorCode(err, model.CodeInternal),
+		ErrorText:      serverErrorText(err),
+	}
+}
+
+// ErrServiceUnavailable the storage can't be reached, logged with the request id and answered without its cause
+func ErrServiceUnavailable(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 503,
+		StatusText:     "Service unavailable.",
+		AppCode:        errorCode(err, model.CodeUnavailable),
+		ErrorText:      serverErrorText(err),
+	}
+}
+
+// ErrGatewayTimeout the repository didn't answer in time
+func ErrGatewayTimeout(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: 504,
+		StatusText:     "Gateway timeout.",
+		AppCode:        model.CodeTimeout,
+		ErrorText:      err.Error(),
+	}
+}
+
+// ErrClientClosedRequest the client closed the request before it was answered
+func ErrClientClosedRequest(err error) render.Renderer {
+	return &ErrResponse{
+		Err:            err,
+		HTTPStatusCode: StatusClientClosedRequest,
+		StatusText:     "Client closed request.",
+		AppCode:        model.CodeClientClosedRequest,
+		ErrorText:      err.Error(),
+	}
+}
+
+// renderError render the error returned by a repository, mapped by its kind
+func renderError(w http.ResponseWriter, r *http.Request, err error) {
+	if renderer := contextError(r, err); renderer != nil {
+		render.Render(w, r, renderer)
+		return
+	}
+
+	if e, ok := err.(*model.Error); ok {
+		render.Render(w, r, modelError(e))
+		return
+	}
+	render.Render(w, r, ErrInternal(err))
+}
+
+// modelError renderer of a typed error
+func modelError(err *model.Error) render.Renderer {
+	switch err.Kind {
+	case model.KindNotFound:
+		return &ErrResponse{
+			Err:            err,
+			HTTPStatusCode: 404,
+			StatusText:     "Resource not found.",
+			AppCode:        err.Code,
+			ErrorText:      err.Error(),
+		}
+	case model.KindInvalidID, model.KindInvalidRequest:
+		return &ErrResponse{
+			Err:            err,
+			HTTPStatusCode: 400,
+			StatusText:     "Invalid request.",
+			AppCode:        err.Code,
+			ErrorText:      err.Error(),
+		}
+	case model.KindConflict:
+		// writes are conditioned on the version the client read
+		if err == model.ErrVersionConflict {
+			return ErrPreconditionFailed(err)
+		}
+		return ErrConflict(err)
+	case model.KindUnavailable:
+		return ErrServiceUnavailable(err)
+	case model.KindValidation:
+		return ErrUnprocessableEntity(err)
+	}
+	return ErrInternal(err)
+}
+
+// contextError renderer of an error caused by a deadline or by the client going away, nil for other errors
+func contextError(r *http.Request, err error) render.Renderer {
+	switch {
+	case err == context.DeadlineExceeded:
+		return ErrGatewayTimeout(err)
+	case err == context.Canceled || r.Context().Err() == context.Canceled:
+		return ErrClientClosedRequest(err)
+	}
+	return nil
+}
+
+// serverErrorText detail of a server error, the message of a typed error without its underlying error, nothing
+// for the others so storage and driver messages don't leak
+func serverErrorText(err error) string {
+	if e, ok := err.(*model.Error); ok {
+		return e.Message
+	}
+	return ""
+}
+
+// errorCode code of a typed error, fallback for the others
+func errorCode(err error, fallback int64) int64 {
+	if e, ok := err.(*model.Error); ok {
+		return e.Code
+	}
+	return fallback
 }
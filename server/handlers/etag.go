@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/go-chi/render"
+)
+
+// bookETag strong entity tag of a book version
+func bookETag(book *model.Book) string {
+	return fmt.Sprintf(`"%d"`, book.Version)
+}
+
+// matchETag check if an If-Match or If-None-Match header lists etag.
+//
+// Weak tags (W/"...") only match when weak comparison is allowed.
+func matchETag(header string, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[2:]
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch check the If-Match precondition of a write on book,
+// render the error and return false when the write must not happen
+func (rs *BooksResource) checkIfMatch(w http.ResponseWriter, r *http.Request, book *model.Book) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if rs.RequireIfMatch {
+			render.Render(w, r, ErrPreconditionRequired(errors.New("If-Match header is required")))
+			return false
+		}
+		return true
+	}
+
+	if !matchETag(header, bookETag(book), false) {
+		render.Render(w, r, ErrPreconditionFailed(model.ErrVersionConflict))
+		return false
+	}
+	return true
+}
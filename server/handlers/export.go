@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/MathieuDoyon/bookshelf/server/exporter"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/go-chi/render"
+)
+
+// exportFlushEvery number of books written between two flushes of the response
+const exportFlushEvery = 100
+
+// Export stream every book matching List filters as csv, ndjson, json or xlsx.
+//
+// `?columns=title,author` selects and orders the columns, every field in struct order by default.
+func (rs *BooksResource) Export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filters, err := parseFilters(query, "format", "columns", "sort", "direction", "ignore_articles")
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+	if err := rs.filterGenres(r.Context(), filters); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	format := exporter.FormatCSV
+	if query.Get("format") != "" {
+		format = query.Get("format")
+	}
+	contentType, ok := exporter.ContentTypes[format]
+	if !ok {
+		render.Render(w, r, ErrInvalidRequest(exporter.ErrUnknownFormat))
+		return
+	}
+
+	columns, err := exporter.ParseColumns(query.Get("columns"))
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	// stable order by default, so two exports of the same books are identical
+	sorting, err := parseSorting(query, &model.Sorting{Sort: "_id", Direction: 1})
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="books.`+format+`"`)
+
+	written := &countingWriter{w: w}
+	encoder, err := exporter.NewEncoder(format, written, columns)
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		render.Render(w, r, ErrInternal(err))
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	count := 0
+	err = rs.Repo.Stream(r.Context(), filters, sorting, func(book *model.Book) error {
+		if err := encoder.Encode(book); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := encoder.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil && written.n == 0 {
+		// nothing was sent yet, the error can still be answered
+		w.Header().Del("Content-Disposition")
+		renderError(w, r, err)
+		return
+	}
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil {
+		// the response is already streaming, closing it would pass a truncated file for a complete one
+		log.Printf("Error exporting books: %s", err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// countingWriter count the bytes actually sent, the encoders buffer and flush on their own
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/utils"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// operatorParam match query string keys with an operator suffix, ex: `rating[gte]`
+var operatorParam = regexp.MustCompile(`^([a-z_]+)\[([a-z]+)\]$`)
+
+// listParams query parameters of book lists which aren't filters
+var listParams = []string{"sort", "direction", "ignore_articles", "limit", "offset", "cursor", "collapse"}
+
+// parseFilters build book filters from query string, keys which are neither a filter nor one of params are rejected
+func parseFilters(query url.Values, params ...string) (*model.BookFilter, error) {
+	filters := &model.BookFilter{}
+	known := map[string]bool{"_id": true, "author_id": true, "language": true}
+	for _, param := range params {
+		known[param] = true
+	}
+
+	if query.Get("_id") != "" {
+		objectID, err := objectid.FromHex(query.Get("_id"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter \"_id\": %s", err)
+		}
+
+		filters.ID = objectID
+	}
+	if query.Get("author_id") != "" {
+		authorID, err := objectid.FromHex(query.Get("author_id"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter \"author_id\": %s", err)
+		}
+
+		filters.AuthorID = authorID
+	}
+	stringFilters := map[string]*string{
+		"title":     &filters.Title,
+		"subtitle":  &filters.Subtitle,
+		"author":    &filters.Author,
+		"genre":     &filters.Genre,
+		"series":    &filters.Series,
+		"publisher": &filters.Publisher,
+	}
+	for key, dest := range stringFilters {
+		known[key] = true
+		if query.Get(key) != "" {
+			*dest = query.Get(key)
+		}
+	}
+
+	// authors are filtered by `author`, contributors in other roles by their role, ex: `translator=Jane Doe`
+	for _, role := range model.ContributorRoles {
+		known[role] = true
+		if role != model.RoleAuthor && query.Get(role) != "" {
+			filters.Contributors = append(filters.Contributors, model.Contributor{Name: query.Get(role), Role: role})
+		}
+	}
+
+	intFilters := map[string]*int64{
+		"edition":          &filters.Edition,
+		"number_of_pages":  &filters.NumberOfPages,
+		"publication_year": &filters.YearOfPublication,
+		"rating":           &filters.Rating,
+	}
+	for key, dest := range intFilters {
+		known[key] = true
+		if query.Get(key) != "" {
+			if err := utils.ParseInt64(query.Get(key), dest); err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %s", key, err)
+			}
+		}
+	}
+
+	// ISBN-10 and ISBN-13 are both searched as the stored ISBN-13
+	for _, key := range []string{"isbn", "isbn_10", "isbn_13"} {
+		known[key] = true
+		if query.Get(key) != "" {
+			isbn, err := model.NormalizeISBN(query.Get(key))
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %s", key, err)
+			}
+			filters.ISBN13 = isbn
+		}
+	}
+	if query.Get("language") != "" {
+		language, err := model.NormalizeLanguage(query.Get("language"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter \"language\": %s", err)
+		}
+		filters.Language = language
+	}
+
+	// sort keys so conditions are always built in the same order
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		match := operatorParam.FindStringSubmatch(key)
+		if match == nil {
+			if strings.Contains(key, "[") {
+				return nil, fmt.Errorf("invalid filter %q: malformed operator", key)
+			}
+			if !known[key] {
+				return nil, fmt.Errorf("unknown filter %q", key)
+			}
+			continue
+		}
+
+		for _, raw := range query[key] {
+			condition, err := model.NewFilterCondition(match[1], match[2], raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter %q: %s", key, err)
+			}
+			filters.Conditions = append(filters.Conditions, *condition)
+		}
+	}
+
+	return filters, nil
+}
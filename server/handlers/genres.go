@@ -0,0 +1,413 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/utils"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// GenresResource Genre router ressources routes
+type GenresResource struct {
+	Repo interfaces.IGenreRepository
+	// Books books filed under genres, they are refiled when a genre is renamed or remapped
+	Books *BooksResource
+}
+
+// Routes creates a REST router for the genres resource
+func (rs *GenresResource) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", rs.List)    // GET /genres - read every genre sorted by name
+	r.Post("/", rs.Create) // POST /genres - create a new genre
+
+	r.Get("/tree", rs.Tree)           // GET /genres/tree - read top level genres with their subgenres
+	r.Get("/unknown", rs.ListUnknown) // GET /genres/unknown - read genre texts of books not spelled as a genre
+	r.Post("/remap", rs.Remap)        // POST /genres/remap - refile books from genre texts to genres, ?dry_run=true only counts
+
+	r.Route("/{id}", func(r chi.Router) {
+		r.Use(rs.GenreCtx)
+		r.Get("/", rs.Get)       // GET /genres/{id} - read a single genre by :id
+		r.Put("/", rs.Update)    // PUT /genres/{id} - update a single genre by :id, books and subgenres follow a rename
+		r.Delete("/", rs.Delete) // DELETE /genres/{id} - delete a genre no book or subgenre is filed under
+	})
+
+	return r
+}
+
+// List get every genre sorted by name
+func (rs *GenresResource) List(w http.ResponseWriter, r *http.Request) {
+	genres, err := rs.Repo.List(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, genres)
+}
+
+// Tree get top level genres with their subgenres nested, sorted by name
+func (rs *GenresResource) Tree(w http.ResponseWriter, r *http.Request) {
+	genres, err := rs.Repo.List(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.NewTaxonomy(genres).Tree())
+}
+
+// Create add a new genre, its names and synonyms can't be used by another genre
+func (rs *GenresResource) Create(w http.ResponseWriter, r *http.Request) {
+	data := &model.GenreRequest{}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+	if err := rs.check(r.Context(), data.Genre); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	if created, err := rs.Repo.Create(r.Context(), data.Genre); err != nil {
+		renderError(w, r, err)
+	} else {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, created)
+	}
+}
+
+// Get get a genre by ID
+func (rs *GenresResource) Get(w http.ResponseWriter, r *http.Request) {
+	genre := r.Context().Value("genre").(*model.Genre)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, genre)
+}
+
+// Update update a genre, its subgenres and books follow it when renamed
+func (rs *GenresResource) Update(w http.ResponseWriter, r *http.Request) {
+	genre := r.Context().Value("genre").(*model.Genre)
+
+	previous := *genre
+	data := &model.GenreRequest{Genre: genre, Previous: &previous}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+	if err := rs.check(r.Context(), data.Genre); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	updated, err := rs.Repo.Update(r.Context(), data.Genre)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	if updated.Name != previous.Name {
+		if err := rs.rename(r, previous.Name, updated.Name); err != nil {
+			renderError(w, r, err)
+			return
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, updated)
+}
+
+// Delete delete a genre by ID, genres having subgenres or books filed under answer 409
+func (rs *GenresResource) Delete(w http.ResponseWriter, r *http.Request) {
+	genre := r.Context().Value("genre").(*model.Genre)
+
+	genres, err := rs.Repo.List(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	if len(model.NewTaxonomy(genres).Children(genre.Name)) > 0 {
+		renderError(w, r, model.ErrGenreInUse)
+		return
+	}
+
+	// trashed books can be restored, they keep the genre in use
+	for _, trashed := range []bool{false, true} {
+		page, err := rs.Books.Repo.List(
+			r.Context(),
+			&model.BookFilter{Genre: genre.Name, Trashed: trashed},
+			&model.Sorting{},
+			&model.Pagination{Limit: 1},
+		)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+		if page.Total > 0 {
+			renderError(w, r, model.ErrGenreInUse)
+			return
+		}
+	}
+
+	if deleted, err := rs.Repo.Delete(r.Context(), genre.ID); err != nil {
+		renderError(w, r, err)
+	} else {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, deleted)
+	}
+}
+
+// ListUnknown get the genre texts of books, trashed ones too, which aren't spelled as a genre, most used first.
+// Texts matching a genre synonym or spelled in another case have the genre as canonical
+func (rs *GenresResource) ListUnknown(w http.ResponseWriter, r *http.Request) {
+	taxonomy, err := rs.Books.taxonomy(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	counts := map[string]int64{}
+	for _, trashed := range []bool{false, true} {
+		err := rs.Books.Repo.Stream(r.Context(), &model.BookFilter{Trashed: trashed}, &model.Sorting{Sort: "_id", Direction: 1}, func(book *model.Book) error {
+			if book.Genre != "" && taxonomy.Canonical(book.Genre) != book.Genre {
+				counts[book.Genre]++
+			}
+			return nil
+		})
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+	}
+
+	usages := []model.GenreUsage{}
+	for genre, books := range counts {
+		usages = append(usages, model.GenreUsage{Genre: genre, Books: books, Canonical: taxonomy.Canonical(genre)})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Books != usages[j].Books {
+			return usages[i].Books > usages[j].Books
+		}
+		return usages[i].Genre < usages[j].Genre
+	})
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, usages)
+}
+
+// Remap refile books, trashed ones too, from genre texts to genres in bulk. A mapping to an empty genre refiles
+// books under the genre the text is a synonym of.
+//
+// `?dry_run=true` only counts the books which would be refiled.
+func (rs *GenresResource) Remap(w http.ResponseWriter, r *http.Request) {
+	data := &model.GenreRemapRequest{}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+
+	taxonomy, err := rs.Books.taxonomy(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	mappings, err := data.Resolve(taxonomy)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	report := &model.GenreRemapReport{Genres: []model.GenreUsage{}}
+	utils.ParseBool(r.URL.Query().Get("dry_run"), &report.DryRun)
+	for _, mapping := range mappings {
+		count, err := rs.Books.refile(r, "genre", mapping.Genre, mapping.Canonical, report.DryRun)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+		mapping.Books = count
+		report.Books += count
+		report.Genres = append(report.Genres, mapping)
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, report)
+}
+
+// GenreCtx build genre context and inject `model.Genre` into request
+func (rs *GenresResource) GenreCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		genre, err := rs.Repo.Get(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "genre", genre)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// check check a genre against the stored genres, its parent is spelled as its genre
+func (rs *GenresResource) check(ctx context.Context, genre *model.Genre) error {
+	genres, err := rs.Repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	return model.NewTaxonomy(genres).Check(genre)
+}
+
+// rename move subgenres and books, trashed ones too, from the previous name of a genre to its new name
+func (rs *GenresResource) rename(r *http.Request, from string, to string) error {
+	genres, err := rs.Repo.List(r.Context())
+	if err != nil {
+		return err
+	}
+	for _, genre := range genres {
+		if genre.Parent != from {
+			continue
+		}
+		child := genre
+		child.Parent = to
+		if _, err := rs.Repo.Update(r.Context(), &child); err != nil {
+			return err
+		}
+	}
+
+	_, err = rs.Books.refile(r, "genre", from, to, false)
+	return err
+}
+
+// taxonomy genres tree books are filed under, the default genres without a genres repository
+func (rs *BooksResource) taxonomy(ctx context.Context) (*model.Taxonomy, error) {
+	if rs.Genres == nil {
+		return model.NewTaxonomy(model.DefaultGenres), nil
+	}
+	genres, err := rs.Genres.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return model.NewTaxonomy(genres), nil
+}
+
+// fileGenre spell the genre of a book as the genre it names, by name or synonym. Books keep a genre unknown to
+// the tree only when it didn't change since before
+func (rs *BooksResource) fileGenre(ctx context.Context, book *model.Book, before *model.Book) error {
+	if book.Genre == "" || (before != nil && book.Genre == before.Genre) {
+		return nil
+	}
+
+	taxonomy, err := rs.taxonomy(ctx)
+	if err != nil {
+		return err
+	}
+	canonical := taxonomy.Canonical(book.Genre)
+	if canonical == "" {
+		return model.Invalid(model.FieldError{
+			Field:   "genre",
+			Reason:  model.ReasonUnknown,
+			Message: "names no genre, see /genres",
+		})
+	}
+	book.Genre = canonical
+	return nil
+}
+
+// filterGenres expand genre filters to the genres they name and their subgenres, a genre unknown to the tree is
+// matched as is. `genre[eq]` and `genre[in]` become `genre[in]` of every expanded genre, `genre[ne]` and
+// `genre[nin]` become `genre[nin]`
+func (rs *BooksResource) filterGenres(ctx context.Context, filters *model.BookFilter) error {
+	var conditions []int
+	for i, condition := range filters.Conditions {
+		if condition.Field == "genre" {
+			conditions = append(conditions, i)
+		}
+	}
+	if filters.Genre == "" && len(conditions) == 0 {
+		return nil
+	}
+
+	taxonomy, err := rs.taxonomy(ctx)
+	if err != nil {
+		return err
+	}
+	if genres := taxonomy.Subtree(filters.Genre); genres != nil {
+		filters.Genres = genres
+		filters.Genre = ""
+	}
+
+	for _, i := range conditions {
+		condition := &filters.Conditions[i]
+		values, ok := condition.Value.([]interface{})
+		if !ok {
+			values = []interface{}{condition.Value}
+		}
+		switch condition.Operator {
+		case model.OpEq, model.OpIn:
+			condition.Operator = model.OpIn
+		case model.OpNe, model.OpNin:
+			condition.Operator = model.OpNin
+		default:
+			continue
+		}
+
+		expanded := []interface{}{}
+		seen := map[interface{}]bool{}
+		for _, value := range values {
+			name, _ := value.(string)
+			genres := []interface{}{value}
+			if subtree := taxonomy.Subtree(name); subtree != nil {
+				genres = genres[:0]
+				for _, genre := range subtree {
+					genres = append(genres, genre)
+				}
+			}
+			for _, genre := range genres {
+				if !seen[genre] {
+					seen[genre] = true
+					expanded = append(expanded, genre)
+				}
+			}
+		}
+		condition.Value = expanded
+	}
+	return nil
+}
+
+// refile move books, trashed ones too, from the genre or series text from to the text to, field is "genre" or
+// "series". Returns how many books have from, nothing is written when dryRun
+func (rs *BooksResource) refile(r *http.Request, field string, from string, to string, dryRun bool) (int64, error) {
+	ctx := r.Context()
+	match := []model.FilterCondition{{Field: field, Operator: model.OpEq, Value: from}}
+
+	// books are changed once read, a stream can't be written into
+	var books []model.Book
+	for _, trashed := range []bool{false, true} {
+		err := rs.Repo.Stream(ctx, &model.BookFilter{Conditions: match, Trashed: trashed}, &model.Sorting{Sort: "_id", Direction: 1}, func(book *model.Book) error {
+			books = append(books, *book)
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if dryRun || from == to {
+		return int64(len(books)), nil
+	}
+
+	for i := range books {
+		switch field {
+		case "genre":
+			books[i].Genre = to
+		case "series":
+			books[i].Series = to
+		}
+		if _, err := rs.repo(r).Patch(ctx, &books[i], []string{field}); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(books)), nil
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces/mocks"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/go-chi/chi"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testGenres default genres as stored, with ids
+func testGenres() []model.Genre {
+	genres := append([]model.Genre(nil), model.DefaultGenres...)
+	for i := range genres {
+		genres[i].ID = objectid.New()
+	}
+	return genres
+}
+
+func TestGenreCreate(t *testing.T) {
+	genreMock := &mocks.IGenreRepository{}
+	genreMock.On("List", mock.Anything).Return(testGenres(), nil)
+	genreMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, genre *model.Genre) *model.Genre {
+		return genre
+	}, nil)
+
+	genreResource := GenresResource{Repo: genreMock, Books: &BooksResource{StrictJSON: true}}
+	r := chi.NewRouter()
+	r.Mount("/genres", genreResource.Routes())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/genres", strings.NewReader(`{"name":" Cyberpunk","parent":"sci fi","synonyms":["Cyber Punk","cyberpunk"]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	genre := &model.Genre{}
+	json.NewDecoder(w.Body).Decode(genre)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "Cyberpunk", genre.Name)
+	assert.Equal(t, "Science Fiction", genre.Parent, "It should spell the parent as its genre")
+	assert.Equal(t, []string{"Cyber Punk"}, genre.Synonyms)
+
+	req = httptest.NewRequest("POST", "http://localhost:8080/genres", strings.NewReader(`{"name":"Sci-Fi","parent":"Pulp"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := &ErrResponse{}
+	json.NewDecoder(w.Body).Decode(problem)
+	assert.Equal(t, 422, w.Code)
+	assert.Len(t, problem.Errors, 2)
+	genreMock.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestBookCreateFilesGenre(t *testing.T) {
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, book *model.Book) *model.Book {
+		return book
+	}, nil)
+
+	bookResource := BooksResource{Repo: repoMock}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/books", strings.NewReader(`{"title":"Sardines","genre":"sci-fi"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	book := &model.Book{}
+	json.NewDecoder(w.Body).Decode(book)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "Science Fiction", book.Genre, "It should file the book under the genre of the synonym")
+
+	req = httptest.NewRequest("POST", "http://localhost:8080/books", strings.NewReader(`{"title":"Sardines","genre":"SCI FI & FANTASY"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := &ErrResponse{}
+	json.NewDecoder(w.Body).Decode(problem)
+
+	assert.Equal(t, 422, w.Code)
+	if assert.Len(t, problem.Errors, 1) {
+		assert.Equal(t, "genre", problem.Errors[0].Field)
+		assert.Equal(t, model.ReasonUnknown, problem.Errors[0].Reason)
+	}
+	repoMock.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestBookListGenreSubtree(t *testing.T) {
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&model.BookPage{Books: []model.Book{}}, nil)
+
+	bookResource := BooksResource{Repo: repoMock}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	for _, query := range []string{"genre=SF", "genre=Fiction--"} {
+		req := httptest.NewRequest("GET", "http://localhost:8080/books?"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, 200, w.Code)
+	}
+
+	repoMock.AssertCalled(t, "List", mock.Anything, &model.BookFilter{Genres: []string{"Science Fiction"}}, mock.Anything, mock.Anything)
+	repoMock.AssertCalled(t, "List", mock.Anything, &model.BookFilter{Genre: "Fiction--"}, mock.Anything, mock.Anything)
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books?genre=non+fiction", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	repoMock.AssertCalled(t, "List", mock.Anything, mock.MatchedBy(func(filters *model.BookFilter) bool {
+		return len(filters.Genres) == 11 && filters.Genres[0] == "Non-Fiction" && filters.Genre == ""
+	}), mock.Anything, mock.Anything)
+}
+
+func TestGenreListUnknown(t *testing.T) {
+	books := []model.Book{{Genre: "Fiction--"}, {Genre: "sci-fi"}, {Genre: "Fiction"}, {Genre: "Fiction--"}, {}}
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{}, mock.Anything, mock.Anything).Return(func(_ context.Context, _ *model.BookFilter, _ *model.Sorting, fn func(book *model.Book) error) error {
+		for i := range books {
+			if err := fn(&books[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{Trashed: true}, mock.Anything, mock.Anything).Return(nil)
+
+	genreResource := GenresResource{Books: &BooksResource{Repo: repoMock}}
+	r := chi.NewRouter()
+	r.Mount("/genres", genreResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/genres/unknown", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var usages []model.GenreUsage
+	json.NewDecoder(w.Body).Decode(&usages)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, []model.GenreUsage{
+		{Genre: "Fiction--", Books: 2},
+		{Genre: "sci-fi", Books: 1, Canonical: "Science Fiction"},
+	}, usages, "It should count texts not spelled as a genre, most used first")
+}
+
+func TestGenreRemap(t *testing.T) {
+	book := model.Book{ID: objectid.New(), Title: "Sardines", Genre: "SCI FI & FANTASY", Version: 3}
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{Conditions: []model.FilterCondition{{Field: "genre", Operator: model.OpEq, Value: "SCI FI & FANTASY"}}}, mock.Anything, mock.Anything).Return(func(_ context.Context, _ *model.BookFilter, _ *model.Sorting, fn func(book *model.Book) error) error {
+		stored := book
+		return fn(&stored)
+	})
+	repoMock.On("Stream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	repoMock.On("Patch", mock.Anything, mock.Anything, []string{"genre"}).Return(func(_ context.Context, book *model.Book, _ []string) *model.Book {
+		return book
+	}, nil)
+
+	genreResource := GenresResource{Books: &BooksResource{Repo: repoMock}}
+	r := chi.NewRouter()
+	r.Mount("/genres", genreResource.Routes())
+
+	remap := func(query string, body string) (int, *model.GenreRemapReport) {
+		req := httptest.NewRequest("POST", "http://localhost:8080/genres/remap"+query, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		report := &model.GenreRemapReport{}
+		json.NewDecoder(w.Body).Decode(report)
+		return w.Code, report
+	}
+
+	code, report := remap("?dry_run=true", `{"mappings":{"SCI FI & FANTASY":"sf","sci-fi":""}}`)
+	assert.Equal(t, 200, code)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, int64(1), report.Books)
+	assert.Equal(t, []model.GenreUsage{
+		{Genre: "SCI FI & FANTASY", Books: 1, Canonical: "Science Fiction"},
+		{Genre: "sci-fi", Books: 0, Canonical: "Science Fiction"},
+	}, report.Genres)
+	repoMock.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+
+	code, report = remap("", `{"mappings":{"SCI FI & FANTASY":"Science Fiction"}}`)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, int64(1), report.Books)
+	repoMock.AssertCalled(t, "Patch", mock.Anything, mock.MatchedBy(func(patched *model.Book) bool {
+		return patched.ID == book.ID && patched.Genre == "Science Fiction" && patched.Version == 3
+	}), []string{"genre"})
+
+	code, _ = remap("", `{"mappings":{"SCI FI & FANTASY":"Space Westerns"}}`)
+	assert.Equal(t, 422, code, "It should only remap to genres of the tree")
+}
+
+func TestGenreDeleteInUse(t *testing.T) {
+	genres := testGenres()
+	fiction := genres[0]
+
+	genreMock := &mocks.IGenreRepository{}
+	genreMock.On("Get", mock.Anything, fiction.ID.Hex()).Return(&fiction, nil)
+	genreMock.On("List", mock.Anything).Return(genres, nil)
+
+	genreResource := GenresResource{Repo: genreMock, Books: &BooksResource{}}
+	r := chi.NewRouter()
+	r.Mount("/genres", genreResource.Routes())
+
+	req := httptest.NewRequest("DELETE", "http://localhost:8080/genres/"+fiction.ID.Hex(), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := &ErrResponse{}
+	json.NewDecoder(w.Body).Decode(problem)
+
+	assert.Equal(t, 409, w.Code, "It should keep genres having subgenres")
+	assert.Equal(t, model.CodeGenreInUse, problem.AppCode)
+	genreMock.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
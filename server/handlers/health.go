@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MathieuDoyon/bookshelf/server/jobs"
+	"github.com/go-chi/render"
+)
+
+// Health answer the last report of the background health check, 503 when the storage couldn't be checked
+func Health(check *jobs.HealthCheck) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := check.Report()
+		if report.Status == jobs.HealthUnavailable {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+		render.JSON(w, r, report)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MathieuDoyon/bookshelf/server/jobs"
+	"github.com/stretchr/testify/assert"
+)
+
+// countChecker checker returning a fixed count
+type countChecker struct {
+	count int64
+	err   error
+}
+
+func (c *countChecker) CountUndecodable(ctx context.Context) (int64, error) {
+	return c.count, c.err
+}
+
+func TestHealth(t *testing.T) {
+	checker := &countChecker{count: 2}
+	check := jobs.NewHealthCheck(checker)
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/health", nil)
+	w := httptest.NewRecorder()
+	Health(check)(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"ok"`, "It should be healthy before the first check")
+
+	check.Check(context.Background())
+	w = httptest.NewRecorder()
+	Health(check)(w, req)
+
+	report := jobs.HealthReport{}
+	json.NewDecoder(w.Body).Decode(&report)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, jobs.HealthDegraded, report.Status)
+	assert.Equal(t, int64(2), report.UndecodableBooks)
+
+	checker.err = errors.New("no reachable servers")
+	check.Check(context.Background())
+	w = httptest.NewRecorder()
+	Health(check)(w, req)
+
+	assert.Equal(t, 503, w.Code, "It should be unavailable when the books can't be checked")
+	assert.Contains(t, w.Body.String(), "no reachable servers")
+}
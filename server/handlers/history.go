@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/utils"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// ActorHeader request header naming who makes a write, the client address is used when missing.
+//
+// Requests aren't authenticated, the named actor is recorded as unverified next to the client address.
+const ActorHeader = "X-Actor"
+
+// repo book repository recording writes in the name of the request actor when it keeps history
+func (rs *BooksResource) repo(r *http.Request) interfaces.IBookRepository {
+	if history, ok := rs.Repo.(interfaces.IHistoryRepository); ok {
+		return history.WithActor(requestActor(r))
+	}
+	return rs.Repo
+}
+
+// requestActor who makes the request, the client address followed by the actor it claims to be
+func requestActor(r *http.Request) string {
+	if actor := r.Header.Get(ActorHeader); actor != "" {
+		return fmt.Sprintf("%s (unverified %s)", r.RemoteAddr, strconv.Quote(actor))
+	}
+	return r.RemoteAddr
+}
+
+// History get every revision of a book, oldest first
+func (rs *BooksResource) History(w http.ResponseWriter, r *http.Request) {
+	history, bookID, ok := rs.historyParams(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := history.History(r.Context(), bookID)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	if len(revisions) == 0 {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, revisions)
+}
+
+// Revision get a single revision of a book
+func (rs *BooksResource) Revision(w http.ResponseWriter, r *http.Request) {
+	history, bookID, ok := rs.historyParams(w, r)
+	if !ok {
+		return
+	}
+
+	var number int64
+	if err := parseRevision(r, &number); err != nil {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+
+	if revision, err := history.Revision(r.Context(), bookID, number); err != nil {
+		renderError(w, r, err)
+	} else {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, revision)
+	}
+}
+
+// Revert restore a book to the state of one of its revisions
+func (rs *BooksResource) Revert(w http.ResponseWriter, r *http.Request) {
+	book := r.Context().Value("book").(*model.Book)
+
+	history, ok := rs.repo(r).(interfaces.IHistoryRepository)
+	if !ok {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+
+	var number int64
+	if err := parseRevision(r, &number); err != nil {
+		render.Render(w, r, ErrNotFound())
+		return
+	}
+	if !rs.checkIfMatch(w, r, book) {
+		return
+	}
+
+	if reverted, err := history.Revert(r.Context(), book, number); err != nil {
+		renderError(w, r, err)
+	} else if err := rs.embedAuthors(r.Context(), reverted); err != nil {
+		renderError(w, r, err)
+	} else {
+		w.Header().Set("ETag", bookETag(reverted))
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, reverted)
+	}
+}
+
+// historyParams history repository and book id of a history request,
+// render not found and return false when the book has no history
+func (rs *BooksResource) historyParams(w http.ResponseWriter, r *http.Request) (interfaces.IHistoryRepository, objectid.ObjectID, bool) {
+	history, ok := rs.Repo.(interfaces.IHistoryRepository)
+	if !ok {
+		render.Render(w, r, ErrNotFound())
+		return nil, objectid.ObjectID{}, false
+	}
+
+	bookID, err := objectid.FromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(model.ErrInvalidID))
+		return nil, objectid.ObjectID{}, false
+	}
+
+	return history, bookID, true
+}
+
+// parseRevision parse the revision number url param
+func parseRevision(r *http.Request, number *int64) error {
+	return utils.ParseInt64(chi.URLParam(r, "rev"), number)
+}
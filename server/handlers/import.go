@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/MathieuDoyon/bookshelf/server/importer"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/utils"
+	"github.com/go-chi/render"
+)
+
+// Import create books from a CSV, JSON array or NDJSON body read row by row.
+//
+// `?dry_run=true` only reports what would be written, `?upsert=true` updates books having the same ISBN.
+func (rs *BooksResource) Import(w http.ResponseWriter, r *http.Request) {
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	body := r.Body
+	if rs.MaxImportSize > 0 {
+		if r.ContentLength > rs.MaxImportSize {
+			render.Render(w, r, ErrRequestEntityTooLarge(fmt.Errorf("request body is larger than %d bytes", rs.MaxImportSize)))
+			return
+		}
+		// chunked bodies are cut at the limit, rows after it fail
+		body = http.MaxBytesReader(w, r.Body, rs.MaxImportSize)
+	}
+
+	decoder, err := importer.NewDecoder(contentType, body)
+	if err == importer.ErrUnsupportedFormat {
+		render.Render(w, r, ErrUnsupportedMediaType(err))
+		return
+	}
+	if err != nil {
+		render.Render(w, r, ErrInvalidRequest(err))
+		return
+	}
+
+	repo := rs.repo(r)
+	im := &importer.Importer{Repo: repo, Link: func(ctx context.Context, book *model.Book) error {
+		return rs.link(ctx, book, nil)
+	}, Write: rs.fileWork}
+	utils.ParseBool(r.URL.Query().Get("dry_run"), &im.DryRun)
+	utils.ParseBool(r.URL.Query().Get("upsert"), &im.Upsert)
+
+	report := im.Run(r.Context(), decoder)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, report)
+}
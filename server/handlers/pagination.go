@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/utils"
+)
+
+// parseSorting read sort, direction and ignore_articles from query string, defaults is used without sort.
+//
+// `sort=-rating,author` sorts by rating descending then author, unsigned fields are sorted in `direction`.
+func parseSorting(query url.Values, defaults *model.Sorting) (*model.Sorting, error) {
+	var direction int32
+	if query.Get("direction") != "" {
+		if err := utils.ParseInt32(query.Get("direction"), &direction); err != nil {
+			return nil, fmt.Errorf("invalid direction: %s", err)
+		}
+		if direction != 1 && direction != -1 {
+			return nil, fmt.Errorf("invalid direction: must be 1 or -1")
+		}
+	}
+
+	sorting := defaults
+	if query.Get("sort") != "" {
+		parsed, err := model.ParseSorting(query.Get("sort"), direction)
+		if err != nil {
+			return nil, err
+		}
+		sorting = parsed
+	} else if direction != 0 {
+		sorting.Direction = direction
+	}
+
+	utils.ParseBool(query.Get("ignore_articles"), &sorting.IgnoreArticles)
+	return sorting, nil
+}
+
+// parsePagination read limit, offset and cursor from query string
+func parsePagination(query url.Values, sorting *model.Sorting) (*model.Pagination, error) {
+	pagination := &model.Pagination{
+		Limit: model.DefaultLimit,
+	}
+
+	if query.Get("limit") != "" {
+		if err := utils.ParseInt64(query.Get("limit"), &pagination.Limit); err != nil {
+			return nil, fmt.Errorf("invalid limit: %s", err)
+		}
+		if pagination.Limit < 1 || pagination.Limit > model.MaxLimit {
+			return nil, fmt.Errorf("invalid limit: must be between 1 and %d", model.MaxLimit)
+		}
+	}
+	if query.Get("offset") != "" {
+		if err := utils.ParseInt64(query.Get("offset"), &pagination.Offset); err != nil {
+			return nil, fmt.Errorf("invalid offset: %s", err)
+		}
+		if pagination.Offset < 0 {
+			return nil, fmt.Errorf("invalid offset: must be positive")
+		}
+	}
+	if query.Get("cursor") != "" {
+		if query.Get("offset") != "" {
+			return nil, fmt.Errorf("cursor and offset can't be used together")
+		}
+		cursor, err := model.DecodeCursor(query.Get("cursor"), sorting)
+		if err != nil {
+			return nil, err
+		}
+		pagination.Cursor = cursor
+	}
+
+	return pagination, nil
+}
+
+// setLinkHeader write RFC 5988 Link header for first, prev and next pages
+func setLinkHeader(w http.ResponseWriter, r *http.Request, page *model.BookPage) {
+	links := []string{
+		fmt.Sprintf(`<%s>; rel="first"`, pageURL(r, page.Limit, "")),
+	}
+
+	if page.PrevCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(r, page.Limit, page.PrevCursor)))
+	}
+	if page.NextCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, page.Limit, page.NextCursor)))
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// pageURL copy request url replacing pagination parameters
+func pageURL(r *http.Request, limit int64, cursor string) string {
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Del("cursor")
+	query.Set("limit", strconv.FormatInt(limit, 10))
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+
+	u := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	return u.String()
+}
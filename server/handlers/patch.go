@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/patch"
+	"github.com/MathieuDoyon/bookshelf/server/strictjson"
+)
+
+// applyPatch apply a patch document to a copy of book.
+//
+// Fields removed by the patch are reset to their zero value, `_id` can't be changed.
+// When strict, the patch can't have duplicate keys or trailing data and can't add unknown fields.
+func applyPatch(book *model.Book, contentType string, body []byte, strict bool) (*model.Book, error) {
+	if strict {
+		if err := strictjson.Check(body); err != nil {
+			return nil, err
+		}
+	}
+
+	original, err := json.Marshal(book)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := patch.Decode(original)
+	if err != nil {
+		return nil, err
+	}
+
+	switch contentType {
+	case patch.MergePatchContentType:
+		patchDoc, err := patch.Decode(body)
+		if err != nil {
+			return nil, err
+		}
+		doc = patch.MergePatch(doc, patchDoc)
+	case patch.JSONPatchContentType:
+		var operations []patch.Operation
+		if err := json.Unmarshal(body, &operations); err != nil {
+			return nil, err
+		}
+		if doc, err = patch.JSONPatch(doc, operations); err != nil {
+			return nil, err
+		}
+	}
+
+	object, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("patched book must be an object")
+	}
+	if object["_id"] != book.ID.Hex() {
+		return nil, errors.New("_id can't be changed")
+	}
+	if strict {
+		for field := range object {
+			if _, ok := model.BookFields[field]; !ok {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+		}
+	}
+
+	patchedJSON, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	patched := &model.Book{}
+	if err := json.Unmarshal(patchedJSON, patched); err != nil {
+		return nil, err
+	}
+	patched.KeepManagedFields(book)
+	patched.DropStaleISBN(book)
+	if err := patched.Validate(); err != nil {
+		return nil, err
+	}
+	if err := patched.Normalize(); err != nil {
+		return nil, err
+	}
+	patched.SyncContributors(book)
+
+	return patched, nil
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// Recoverer like `middleware.Recoverer`, except `http.ErrAbortHandler` is panicked again so the server
+// breaks the connection instead of appending a 500 to a response which already started
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rvr := recover()
+			if rvr == nil {
+				return
+			}
+			if rvr == http.ErrAbortHandler {
+				panic(rvr)
+			}
+
+			if logEntry := middleware.GetLogEntry(r); logEntry != nil {
+				logEntry.Panic(rvr, debug.Stack())
+			} else {
+				fmt.Fprintf(os.Stderr, "Panic: %+v\n", rvr)
+				debug.PrintStack()
+			}
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+)
+
+// SeriesResource Series router ressources routes
+type SeriesResource struct {
+	Repo interfaces.ISeriesRepository
+	// Books books of the series, they follow a series when renamed
+	Books *BooksResource
+}
+
+// Routes creates a REST router for the series resource
+func (rs *SeriesResource) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", rs.List)    // GET /series - read every series sorted by name
+	r.Post("/", rs.Create) // POST /series - create a new series
+
+	r.Route("/{id}", func(r chi.Router) {
+		r.Use(rs.SeriesCtx)
+		r.Get("/", rs.Get)            // GET /series/{id} - read a single series by :id
+		r.Put("/", rs.Update)         // PUT /series/{id} - update a single series by :id, books follow a rename
+		r.Delete("/", rs.Delete)      // DELETE /series/{id} - delete a series without books
+		r.Get("/books", rs.ListBooks) // GET /series/{id}/books - read the books of the series in reading order, with the books list filters
+		r.Get("/next", rs.Next)       // GET /series/{id}/next - read the first unread book of the series in reading order
+		r.Get("/gaps", rs.Gaps)       // GET /series/{id}/gaps - read the volumes of the series no book is at
+	})
+
+	return r
+}
+
+// List get every series sorted by name
+func (rs *SeriesResource) List(w http.ResponseWriter, r *http.Request) {
+	list, err := rs.Repo.List(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, list)
+}
+
+// Create add a new series, its name can't be used by another series
+func (rs *SeriesResource) Create(w http.ResponseWriter, r *http.Request) {
+	data := &model.SeriesRequest{}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+	if err := rs.check(r.Context(), data.Series); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	if created, err := rs.Repo.Create(r.Context(), data.Series); err != nil {
+		renderError(w, r, err)
+	} else {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, created)
+	}
+}
+
+// Get get a series by ID
+func (rs *SeriesResource) Get(w http.ResponseWriter, r *http.Request) {
+	series := r.Context().Value("series").(*model.Series)
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, series)
+}
+
+// Update update a series, its books follow it when renamed
+func (rs *SeriesResource) Update(w http.ResponseWriter, r *http.Request) {
+	series := r.Context().Value("series").(*model.Series)
+
+	previous := *series
+	data := &model.SeriesRequest{Series: series, Previous: &previous}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+	if err := rs.check(r.Context(), data.Series); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	updated, err := rs.Repo.Update(r.Context(), data.Series)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	if updated.Name != previous.Name {
+		if _, err := rs.Books.refile(r, "series", previous.Name, updated.Name, false); err != nil {
+			renderError(w, r, err)
+			return
+		}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, updated)
+}
+
+// Delete delete a series by ID, series books are still part of answer 409
+func (rs *SeriesResource) Delete(w http.ResponseWriter, r *http.Request) {
+	series := r.Context().Value("series").(*model.Series)
+
+	// trashed books can be restored, they keep the series in use
+	for _, trashed := range []bool{false, true} {
+		page, err := rs.Books.Repo.List(
+			r.Context(),
+			&model.BookFilter{Series: series.Name, Trashed: trashed},
+			&model.Sorting{},
+			&model.Pagination{Limit: 1},
+		)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+		if page.Total > 0 {
+			renderError(w, r, model.ErrSeriesInUse)
+			return
+		}
+	}
+
+	if deleted, err := rs.Repo.Delete(r.Context(), series.ID); err != nil {
+		renderError(w, r, err)
+	} else {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, deleted)
+	}
+}
+
+// ListBooks get the books of a series in reading order by default, with the same filters, sorts and pagination
+// as the books list
+func (rs *SeriesResource) ListBooks(w http.ResponseWriter, r *http.Request) {
+	series := r.Context().Value("series").(*model.Series)
+
+	rs.Books.list(w, r, model.BookFilter{Series: series.Name})
+}
+
+// Next get the first unread book of a series in reading order, 404 when every book is read
+func (rs *SeriesResource) Next(w http.ResponseWriter, r *http.Request) {
+	series := r.Context().Value("series").(*model.Series)
+
+	books, err := rs.books(r.Context(), series)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	next := model.NextUnread(books)
+	if next == nil {
+		renderError(w, r, model.ErrNoUnreadBook)
+		return
+	}
+	if err := rs.Books.embedAuthors(r.Context(), next); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, next)
+}
+
+// Gaps get the volumes of a series no book is at, up to its volumes or the last position of its books
+func (rs *SeriesResource) Gaps(w http.ResponseWriter, r *http.Request) {
+	series := r.Context().Value("series").(*model.Series)
+
+	books, err := rs.books(r.Context(), series)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, model.FindGaps(series, books))
+}
+
+// SeriesCtx build series context and inject `model.Series` into request
+func (rs *SeriesResource) SeriesCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		series, err := rs.Repo.Get(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "series", series)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// check check the name of a series isn't used by another series
+func (rs *SeriesResource) check(ctx context.Context, series *model.Series) error {
+	list, err := rs.Repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	if found := model.FindSeries(list, series.Name); found != nil && found.ID != series.ID {
+		return model.Invalid(model.FieldError{Field: "name", Reason: model.ReasonRepeated, Message: "is already used by another series"})
+	}
+	return nil
+}
+
+// books live books of a series in reading order, trashed books aren't owned anymore
+func (rs *SeriesResource) books(ctx context.Context, series *model.Series) ([]model.Book, error) {
+	books := []model.Book{}
+	readingOrder := model.ReadingOrder
+	err := rs.Books.Repo.Stream(ctx, &model.BookFilter{Series: series.Name}, &readingOrder, func(book *model.Book) error {
+		books = append(books, *book)
+		return nil
+	})
+	return books, err
+}
+
+// fileSeries spell the series of a book as the series it names. Books keep a series unknown to the repository
+// only when it didn't change since before, series are left unchecked without a series repository
+func (rs *BooksResource) fileSeries(ctx context.Context, book *model.Book, before *model.Book) error {
+	if book.Series == "" || rs.Series == nil || (before != nil && book.Series == before.Series) {
+		return nil
+	}
+
+	list, err := rs.Series.List(ctx)
+	if err != nil {
+		return err
+	}
+	series := model.FindSeries(list, book.Series)
+	if series == nil {
+		return model.Invalid(model.FieldError{
+			Field:   "series",
+			Reason:  model.ReasonUnknown,
+			Message: "names no series, see /series",
+		})
+	}
+	book.Series = series.Name
+	return nil
+}
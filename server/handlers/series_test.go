@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces/mocks"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/go-chi/chi"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSeriesCreate(t *testing.T) {
+	seriesMock := &mocks.ISeriesRepository{}
+	seriesMock.On("List", mock.Anything).Return([]model.Series{{ID: objectid.New(), Name: "Sea"}}, nil)
+	seriesMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, series *model.Series) *model.Series {
+		return series
+	}, nil)
+
+	seriesResource := SeriesResource{Repo: seriesMock, Books: &BooksResource{StrictJSON: true}}
+	r := chi.NewRouter()
+	r.Mount("/series", seriesResource.Routes())
+
+	create := func(body string) (int, *ErrResponse) {
+		req := httptest.NewRequest("POST", "http://localhost:8080/series", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		problem := &ErrResponse{}
+		json.NewDecoder(w.Body).Decode(problem)
+		return w.Code, problem
+	}
+
+	code, _ := create(`{"name":" Birds ","volumes":3}`)
+	assert.Equal(t, 200, code)
+
+	code, problem := create(`{"name":"SEA"}`)
+	assert.Equal(t, 422, code, "It should keep series names unique")
+	if assert.Len(t, problem.Errors, 1) {
+		assert.Equal(t, model.ReasonRepeated, problem.Errors[0].Reason)
+	}
+	seriesMock.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestBookCreateFilesSeries(t *testing.T) {
+	seriesMock := &mocks.ISeriesRepository{}
+	seriesMock.On("List", mock.Anything).Return([]model.Series{{ID: objectid.New(), Name: "Sea"}}, nil)
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, book *model.Book) *model.Book {
+		return book
+	}, nil)
+
+	bookResource := BooksResource{Repo: repoMock, Series: seriesMock}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/books", strings.NewReader(`{"title":"Sardines","series":"sea","series_position":1.5}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	book := &model.Book{}
+	json.NewDecoder(w.Body).Decode(book)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "Sea", book.Series, "It should spell the series as stored")
+	assert.Equal(t, 1.5, book.SeriesPosition)
+
+	req = httptest.NewRequest("POST", "http://localhost:8080/books", strings.NewReader(`{"title":"Sardines","series":"Ocean"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := &ErrResponse{}
+	json.NewDecoder(w.Body).Decode(problem)
+
+	assert.Equal(t, 422, w.Code)
+	if assert.Len(t, problem.Errors, 1) {
+		assert.Equal(t, "series", problem.Errors[0].Field)
+		assert.Equal(t, model.ReasonUnknown, problem.Errors[0].Reason)
+	}
+	repoMock.AssertNumberOfCalls(t, "Create", 1)
+}
+
+func TestSeriesNextAndGaps(t *testing.T) {
+	series := &model.Series{ID: objectid.New(), Name: "Sea", Volumes: 4}
+	books := []model.Book{
+		{Title: "Sardines", Series: "Sea", SeriesPosition: 1, Read: true},
+		{Title: "Novella", Series: "Sea", SeriesPosition: 1.5},
+		{Title: "Trawler", Series: "Sea", SeriesPosition: 3},
+	}
+
+	seriesMock := &mocks.ISeriesRepository{}
+	seriesMock.On("Get", mock.Anything, series.ID.Hex()).Return(series, nil)
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Stream", mock.Anything, &model.BookFilter{Series: "Sea"}, &model.ReadingOrder, mock.Anything).Return(func(_ context.Context, _ *model.BookFilter, _ *model.Sorting, fn func(book *model.Book) error) error {
+		for i := range books {
+			stored := books[i]
+			if err := fn(&stored); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	seriesResource := SeriesResource{Repo: seriesMock, Books: &BooksResource{Repo: repoMock}}
+	r := chi.NewRouter()
+	r.Mount("/series", seriesResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/series/"+series.ID.Hex()+"/next", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	book := &model.Book{}
+	json.NewDecoder(w.Body).Decode(book)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "Novella", book.Title, "It should answer the first unread book in reading order")
+
+	req = httptest.NewRequest("GET", "http://localhost:8080/series/"+series.ID.Hex()+"/gaps", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	gaps := &model.SeriesGaps{}
+	json.NewDecoder(w.Body).Decode(gaps)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, []int64{2, 4}, gaps.Missing)
+
+	books[1].Read = true
+	books[2].Read = true
+	req = httptest.NewRequest("GET", "http://localhost:8080/series/"+series.ID.Hex()+"/next", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	problem := &ErrResponse{}
+	json.NewDecoder(w.Body).Decode(problem)
+	assert.Equal(t, 404, w.Code)
+	assert.Equal(t, model.CodeNoUnreadBook, problem.AppCode)
+}
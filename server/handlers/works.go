@@ -0,0 +1,363 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/go-chi/chi"
+	"github.com/go-chi/render"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// WorksResource Work router ressources routes
+type WorksResource struct {
+	Repo interfaces.IWorkRepository
+	// Books editions of the works, they are moved between works when works are merged or split
+	Books *BooksResource
+}
+
+// Routes creates a REST router for the works resource
+func (rs *WorksResource) Routes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Get("/", rs.List) // GET /works - read every work sorted by title, with the figures of its editions
+
+	r.Route("/{id}", func(r chi.Router) {
+		r.Use(rs.WorkCtx)
+		r.Get("/", rs.Get)                  // GET /works/{id} - read a single work by :id, with the figures of its editions
+		r.Put("/", rs.Update)               // PUT /works/{id} - update the title and author of a single work by :id
+		r.Delete("/", rs.Delete)            // DELETE /works/{id} - delete a work without editions
+		r.Get("/editions", rs.ListEditions) // GET /works/{id}/editions - read the editions of the work, with the books list filters
+		r.Post("/merge", rs.Merge)          // POST /works/{id}/merge - move the editions of other works into the work
+		r.Post("/split", rs.Split)          // POST /works/{id}/split - move editions of the work into a new work
+	})
+
+	return r
+}
+
+// List get every work sorted by title, with its editions count and average rating
+func (rs *WorksResource) List(w http.ResponseWriter, r *http.Request) {
+	works, err := rs.Repo.List(r.Context())
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	summaries := make([]model.WorkSummary, len(works))
+	index := map[objectid.ObjectID]*model.WorkSummary{}
+	for i := range works {
+		summaries[i].Work = works[i]
+		index[works[i].ID] = &summaries[i]
+	}
+	err = rs.Books.Repo.Stream(r.Context(), &model.BookFilter{}, &model.Sorting{Sort: "_id", Direction: 1}, func(book *model.Book) error {
+		if summary, ok := index[book.WorkID]; ok {
+			summary.Tally(book)
+		}
+		return nil
+	})
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, summaries)
+}
+
+// Get get a work by ID, with its editions count and average rating
+func (rs *WorksResource) Get(w http.ResponseWriter, r *http.Request) {
+	work := r.Context().Value("work").(*model.Work)
+
+	summary, err := rs.summary(r.Context(), work)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, summary)
+}
+
+// Update update the title and author of a work, editions are left as they are
+func (rs *WorksResource) Update(w http.ResponseWriter, r *http.Request) {
+	work := r.Context().Value("work").(*model.Work)
+
+	previous := *work
+	data := &model.WorkRequest{Work: work, Previous: &previous}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+
+	updated, err := rs.Repo.Update(r.Context(), data.Work)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, updated)
+}
+
+// Delete delete a work by ID, works having editions answer 409
+func (rs *WorksResource) Delete(w http.ResponseWriter, r *http.Request) {
+	work := r.Context().Value("work").(*model.Work)
+
+	editions, err := rs.editions(r.Context(), work.ID)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	if len(editions) > 0 {
+		renderError(w, r, model.ErrWorkInUse)
+		return
+	}
+
+	if deleted, err := rs.Repo.Delete(r.Context(), work.ID); err != nil {
+		renderError(w, r, err)
+	} else {
+		render.Status(r, http.StatusOK)
+		render.JSON(w, r, deleted)
+	}
+}
+
+// ListEditions get the editions of a work, with the same filters, sorts and pagination as the books list
+func (rs *WorksResource) ListEditions(w http.ResponseWriter, r *http.Request) {
+	work := r.Context().Value("work").(*model.Work)
+
+	rs.Books.list(w, r, model.BookFilter{WorkID: work.ID})
+}
+
+// Merge move every edition of other works, trashed ones too, into the work and delete the other works.
+// Answers the work with its new figures
+func (rs *WorksResource) Merge(w http.ResponseWriter, r *http.Request) {
+	work := r.Context().Value("work").(*model.Work)
+
+	data := &model.WorkMergeRequest{}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+
+	// every work is checked before any edition is moved
+	var fields []model.FieldError
+	for _, ID := range data.Works {
+		if ID == work.ID {
+			fields = append(fields, model.FieldError{Field: "works", Reason: model.ReasonNotAllowed, Message: "can't merge a work into itself"})
+			continue
+		}
+		if _, err := rs.Repo.Get(r.Context(), ID.Hex()); err == model.ErrWorkNotFound {
+			fields = append(fields, model.FieldError{Field: "works", Reason: model.ReasonUnknown, Message: "names unknown work " + ID.Hex()})
+		} else if err != nil {
+			renderError(w, r, err)
+			return
+		}
+	}
+	if len(fields) > 0 {
+		renderError(w, r, model.Invalid(fields...))
+		return
+	}
+
+	for _, ID := range data.Works {
+		editions, err := rs.editions(r.Context(), ID)
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+		if err := rs.move(r, editions, work.ID); err != nil {
+			renderError(w, r, err)
+			return
+		}
+		if _, err := rs.Repo.Delete(r.Context(), ID); err != nil {
+			renderError(w, r, err)
+			return
+		}
+	}
+
+	summary, err := rs.summary(r.Context(), work)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, summary)
+}
+
+// Split move editions of the work into a new work named after the first of them, the work keeps at least one
+// edition. Answers the new work with its figures
+func (rs *WorksResource) Split(w http.ResponseWriter, r *http.Request) {
+	work := r.Context().Value("work").(*model.Work)
+
+	data := &model.WorkSplitRequest{}
+	if !bindJSON(w, r, data, rs.Books.StrictJSON, rs.Books.MaxBodySize) {
+		return
+	}
+
+	editions, err := rs.editions(r.Context(), work.ID)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	byID := map[objectid.ObjectID]model.Book{}
+	for _, edition := range editions {
+		byID[edition.ID] = edition
+	}
+
+	var split []model.Book
+	var fields []model.FieldError
+	seen := map[objectid.ObjectID]bool{}
+	for _, ID := range data.Editions {
+		edition, ok := byID[ID]
+		if !ok {
+			fields = append(fields, model.FieldError{Field: "editions", Reason: model.ReasonUnknown, Message: "names no edition of the work " + ID.Hex()})
+			continue
+		}
+		if !seen[ID] {
+			seen[ID] = true
+			split = append(split, edition)
+		}
+	}
+	if len(fields) == 0 && len(split) == len(editions) {
+		fields = append(fields, model.FieldError{Field: "editions", Reason: model.ReasonNotAllowed, Message: "can't split every edition of the work"})
+	}
+	if len(fields) > 0 {
+		renderError(w, r, model.Invalid(fields...))
+		return
+	}
+
+	created, err := rs.Repo.Create(r.Context(), model.NewWork(&split[0]))
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+	if err := rs.move(r, split, created.ID); err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	summary, err := rs.summary(r.Context(), created)
+	if err != nil {
+		renderError(w, r, err)
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, summary)
+}
+
+// WorkCtx build work context and inject `model.Work` into request
+func (rs *WorksResource) WorkCtx(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		work, err := rs.Repo.Get(r.Context(), chi.URLParam(r, "id"))
+		if err != nil {
+			renderError(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), "work", work)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// editions editions of a work, trashed ones too since they can be restored
+func (rs *WorksResource) editions(ctx context.Context, ID objectid.ObjectID) ([]model.Book, error) {
+	var editions []model.Book
+	for _, trashed := range []bool{false, true} {
+		err := rs.Books.Repo.Stream(ctx, &model.BookFilter{WorkID: ID, Trashed: trashed}, &model.Sorting{Sort: "_id", Direction: 1}, func(book *model.Book) error {
+			editions = append(editions, *book)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return editions, nil
+}
+
+// summary work with the figures of its editions, trashed editions aren't counted
+func (rs *WorksResource) summary(ctx context.Context, work *model.Work) (*model.WorkSummary, error) {
+	summary := &model.WorkSummary{Work: *work}
+	err := rs.Books.Repo.Stream(ctx, &model.BookFilter{WorkID: work.ID}, &model.Sorting{Sort: "_id", Direction: 1}, func(book *model.Book) error {
+		summary.Tally(book)
+		return nil
+	})
+	return summary, err
+}
+
+// move make editions editions of the work ID
+func (rs *WorksResource) move(r *http.Request, editions []model.Book, ID objectid.ObjectID) error {
+	for i := range editions {
+		editions[i].WorkID = ID
+		if _, err := rs.Books.repo(r).Patch(r.Context(), &editions[i], []string{"work_id"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileWork write a book with write once grouped into the oldest work having its title and authors, or into a new
+// work named after it. A new work is removed again when the write fails, so the book and its work are written by
+// a single book write. Books stay editions of their work when their title or authors change
+func (rs *BooksResource) fileWork(ctx context.Context, book *model.Book, write func() error) error {
+	if rs.Works == nil || !book.WorkID.IsZero() {
+		return write()
+	}
+
+	// works are found and created one book at a time, two books of a new work don't create it twice
+	rs.worksMu.Lock()
+	works, err := rs.Works.List(ctx)
+	if err != nil {
+		rs.worksMu.Unlock()
+		return err
+	}
+	if work := model.FindWork(works, book); work != nil {
+		rs.worksMu.Unlock()
+		book.WorkID = work.ID
+		return write()
+	}
+	defer rs.worksMu.Unlock()
+
+	work, err := rs.Works.Create(ctx, model.NewWork(book))
+	if err != nil {
+		return err
+	}
+	book.WorkID = work.ID
+	if err := write(); err != nil {
+		book.WorkID = objectid.ObjectID{}
+		// the request may be over, the work is removed anyway
+		if _, deleteErr := rs.Works.Delete(context.Background(), work.ID); deleteErr != nil {
+			log.Printf("Error removing work %s of an unwritten book: %s", work.ID.Hex(), deleteErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// errTooManyEditions returned when more books match a collapsed list than it reads
+var errTooManyEditions = &model.Error{Kind: model.KindInvalidRequest, Code: model.CodeTooManyBooks, Message: "too many matching books to collapse, narrow the filters"}
+
+// collapse list the first edition of each work in sorting order, with its number of editions matching filters.
+// Matching books are read as a whole to be collapsed, then paginated, at most MaxCollapseBooks of them within
+// CollapseTimeout
+func (rs *BooksResource) collapse(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, pagination *model.Pagination) (*model.BookPage, error) {
+	if rs.CollapseTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rs.CollapseTimeout)
+		defer cancel()
+	}
+
+	books := []model.Book{}
+	err := rs.Repo.Stream(ctx, filters, sorting, func(book *model.Book) error {
+		if rs.MaxCollapseBooks > 0 && int64(len(books)) >= rs.MaxCollapseBooks {
+			return errTooManyEditions
+		}
+		books = append(books, *book)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return model.PageBooks(model.CollapseEditions(books), sorting, pagination)
+}
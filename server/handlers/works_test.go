@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces/mocks"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/go-chi/chi"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// editionsMock book repository streaming books of a work and moving them between works on patch
+func editionsMock(books []model.Book) *mocks.IBookRepository {
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Stream", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(func(_ context.Context, filters *model.BookFilter, _ *model.Sorting, fn func(book *model.Book) error) error {
+		for i := range books {
+			if filters.Trashed || (!filters.WorkID.IsZero() && books[i].WorkID != filters.WorkID) {
+				continue
+			}
+			stored := books[i]
+			if err := fn(&stored); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	repoMock.On("Patch", mock.Anything, mock.Anything, []string{"work_id"}).Return(func(_ context.Context, book *model.Book, _ []string) *model.Book {
+		for i := range books {
+			if books[i].ID == book.ID {
+				books[i].WorkID = book.WorkID
+			}
+		}
+		return book
+	}, nil)
+	return repoMock
+}
+
+func TestBookCreateFilesWork(t *testing.T) {
+	sardines := model.Work{ID: objectid.New(), Title: "Sardines", Author: "Mathieu Doyon & Eric Cantona"}
+	var created objectid.ObjectID
+	workMock := &mocks.IWorkRepository{}
+	workMock.On("List", mock.Anything).Return([]model.Work{sardines}, nil)
+	workMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, work *model.Work) *model.Work {
+		work.ID = objectid.New()
+		created = work.ID
+		return work
+	}, nil)
+	workMock.On("Delete", mock.Anything, mock.Anything).Return(int64(1), nil)
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Create", mock.Anything, mock.MatchedBy(func(book *model.Book) bool {
+		return book.ISBN13 == ""
+	})).Return(func(_ context.Context, book *model.Book) *model.Book {
+		created := *book
+		created.Version = 1
+		return &created
+	}, nil)
+	repoMock.On("Create", mock.Anything, mock.Anything).Return(nil, model.ErrDuplicateISBN)
+
+	bookResource := BooksResource{Repo: repoMock, Works: workMock}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	create := func(body string) (int, *model.Book) {
+		req := httptest.NewRequest("POST", "http://localhost:8080/books", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		book := &model.Book{}
+		json.NewDecoder(w.Body).Decode(book)
+		return w.Code, book
+	}
+
+	code, book := create(`{"title":"sardines","author":"Eric Cantona & Mathieu Doyon","work_id":"` + objectid.New().Hex() + `"}`)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, sardines.ID, book.WorkID, "It should group the book into the work having its title and authors")
+	workMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	code, _ = create(`{"title":"Seagulls","author":"Mathieu Doyon","isbn_13":"9780306406157"}`)
+	assert.Equal(t, 409, code)
+	workMock.AssertNumberOfCalls(t, "Create", 1)
+	// the work of a book which isn't written is removed
+	workMock.AssertCalled(t, "Delete", mock.Anything, created)
+
+	code, book = create(`{"title":"Seagulls","author":"Mathieu Doyon"}`)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, int64(1), book.Version, "It should write the book and its work at once")
+	assert.False(t, book.WorkID.IsZero())
+	assert.NotEqual(t, sardines.ID, book.WorkID)
+	workMock.AssertCalled(t, "Create", mock.Anything, &model.Work{ID: book.WorkID, Title: "Seagulls", Author: "Mathieu Doyon"})
+	repoMock.AssertNotCalled(t, "Patch", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBookCreateFilesWorkOnce(t *testing.T) {
+	var mu sync.Mutex
+	works := []model.Work{}
+	workMock := &mocks.IWorkRepository{}
+	workMock.On("List", mock.Anything).Return(func(context.Context) []model.Work {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]model.Work{}, works...)
+	}, nil)
+	workMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, work *model.Work) *model.Work {
+		mu.Lock()
+		defer mu.Unlock()
+		work.ID = objectid.New()
+		works = append(works, *work)
+		return work
+	}, nil)
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, book *model.Book) *model.Book {
+		return book
+	}, nil)
+
+	bookResource := BooksResource{Repo: repoMock, Works: workMock}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest("POST", "http://localhost:8080/books", strings.NewReader(`{"title":"Seagulls","author":"Mathieu Doyon"}`))
+			req.Header.Set("Content-Type", "application/json")
+			r.ServeHTTP(httptest.NewRecorder(), req)
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, works, 1, "It should create a single work for books created at once")
+}
+
+func TestBookImportDryRunCreatesNoWork(t *testing.T) {
+	workMock := &mocks.IWorkRepository{}
+	workMock.On("List", mock.Anything).Return([]model.Work{}, nil)
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&model.BookPage{}, nil)
+
+	bookResource := BooksResource{Repo: repoMock, Works: workMock}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("POST", "http://localhost:8080/books/import?dry_run=true", strings.NewReader(`{"title":"Sardines"}`+"\n"))
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `"created":1`)
+	workMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	repoMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestWorkMerge(t *testing.T) {
+	sardines := &model.Work{ID: objectid.New(), Title: "Sardines"}
+	sardinas := &model.Work{ID: objectid.New(), Title: "Sardinas"}
+	books := []model.Book{
+		{ID: objectid.New(), Title: "Sardines", WorkID: sardines.ID, Rating: 4},
+		{ID: objectid.New(), Title: "Sardinas", WorkID: sardinas.ID, Rating: 5},
+		{ID: objectid.New(), Title: "Sardinas, pocket", WorkID: sardinas.ID},
+	}
+
+	workMock := &mocks.IWorkRepository{}
+	workMock.On("Get", mock.Anything, sardines.ID.Hex()).Return(sardines, nil)
+	workMock.On("Get", mock.Anything, sardinas.ID.Hex()).Return(sardinas, nil)
+	workMock.On("Get", mock.Anything, mock.Anything).Return(nil, model.ErrWorkNotFound)
+	workMock.On("Delete", mock.Anything, sardinas.ID).Return(int64(1), nil)
+
+	workResource := WorksResource{Repo: workMock, Books: &BooksResource{Repo: editionsMock(books)}}
+	r := chi.NewRouter()
+	r.Mount("/works", workResource.Routes())
+
+	merge := func(body string) (int, []byte) {
+		req := httptest.NewRequest("POST", "http://localhost:8080/works/"+sardines.ID.Hex()+"/merge", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code, w.Body.Bytes()
+	}
+
+	code, body := merge(`{"works":["` + sardines.ID.Hex() + `","` + objectid.New().Hex() + `"]}`)
+	problem := &ErrResponse{}
+	json.Unmarshal(body, problem)
+	assert.Equal(t, 422, code, "It should check every work before moving editions")
+	assert.Len(t, problem.Errors, 2)
+	workMock.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+
+	code, body = merge(`{"works":["` + sardinas.ID.Hex() + `"]}`)
+	summary := &model.WorkSummary{}
+	json.Unmarshal(body, summary)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, int64(3), summary.Editions, "It should move the editions of the merged work")
+	assert.Equal(t, int64(2), summary.Ratings)
+	assert.Equal(t, 4.5, summary.Rating)
+	workMock.AssertCalled(t, "Delete", mock.Anything, sardinas.ID)
+}
+
+func TestWorkSplit(t *testing.T) {
+	sardines := &model.Work{ID: objectid.New(), Title: "Sardines"}
+	books := []model.Book{
+		{ID: objectid.New(), Title: "Sardines", WorkID: sardines.ID},
+		{ID: objectid.New(), Title: "Sardinas", Author: "Eric Cantona", WorkID: sardines.ID, Rating: 3},
+	}
+
+	workMock := &mocks.IWorkRepository{}
+	workMock.On("Get", mock.Anything, sardines.ID.Hex()).Return(sardines, nil)
+	workMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, work *model.Work) *model.Work {
+		work.ID = objectid.New()
+		return work
+	}, nil)
+
+	workResource := WorksResource{Repo: workMock, Books: &BooksResource{Repo: editionsMock(books)}}
+	r := chi.NewRouter()
+	r.Mount("/works", workResource.Routes())
+
+	split := func(body string) (int, []byte) {
+		req := httptest.NewRequest("POST", "http://localhost:8080/works/"+sardines.ID.Hex()+"/split", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code, w.Body.Bytes()
+	}
+
+	code, _ := split(`{"editions":["` + books[0].ID.Hex() + `","` + books[1].ID.Hex() + `"]}`)
+	assert.Equal(t, 422, code, "It should keep an edition in the work")
+	workMock.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	code, body := split(`{"editions":["` + books[1].ID.Hex() + `"]}`)
+	summary := &model.WorkSummary{}
+	json.Unmarshal(body, summary)
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "Sardinas", summary.Title, "It should name the new work after its first edition")
+	assert.Equal(t, int64(1), summary.Editions)
+	assert.Equal(t, 3.0, summary.Rating)
+	assert.Equal(t, sardines.ID, books[0].WorkID)
+	assert.Equal(t, summary.ID, books[1].WorkID)
+}
+
+func TestBookListCollapse(t *testing.T) {
+	work := objectid.New()
+	books := []model.Book{
+		{ID: objectid.New(), Title: "Sardines", WorkID: work},
+		{ID: objectid.New(), Title: "Sardines, pocket", WorkID: work},
+		{ID: objectid.New(), Title: "Seagulls"},
+	}
+
+	bookResource := BooksResource{Repo: editionsMock(books)}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books?collapse=true&limit=1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	page := &model.BookPage{}
+	json.NewDecoder(w.Body).Decode(page)
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, int64(2), page.Total, "It should count one entry per work")
+	if assert.Len(t, page.Books, 1) {
+		assert.Equal(t, "Sardines", page.Books[0].Title)
+		assert.Equal(t, int64(2), page.Books[0].Editions)
+	}
+	assert.NotEmpty(t, page.NextCursor)
+}
+
+func TestBookListCollapseBounded(t *testing.T) {
+	books := []model.Book{
+		{ID: objectid.New(), Title: "Sardines"},
+		{ID: objectid.New(), Title: "Seagulls"},
+		{ID: objectid.New(), Title: "Trawler"},
+	}
+
+	bookResource := BooksResource{Repo: editionsMock(books), MaxCollapseBooks: 2}
+	r := chi.NewRouter()
+	r.Mount("/books", bookResource.Routes())
+
+	req := httptest.NewRequest("GET", "http://localhost:8080/books?collapse=true", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 400, w.Code, "It should not read more books than it collapses")
+	assert.Contains(t, w.Body.String(), `"code":2007`)
+
+	bookResource.MaxCollapseBooks = 3
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 200, w.Code)
+}
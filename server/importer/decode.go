@@ -0,0 +1,214 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+)
+
+// Import content types
+const (
+	CSVContentType    = "text/csv"
+	JSONContentType   = "application/json"
+	NDJSONContentType = "application/x-ndjson"
+)
+
+// maxLineSize longest NDJSON line accepted
+const maxLineSize = 1 << 20
+
+// ErrUnsupportedFormat returned for content types that can't be imported
+var ErrUnsupportedFormat = errors.New("unsupported import format, expected text/csv, application/json or application/x-ndjson")
+
+// RowError error of a single row, the following rows can still be read
+type RowError struct {
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return e.Err.Error()
+}
+
+// Decoder read books one row at a time, `io.EOF` is returned after the last one
+type Decoder interface {
+	Next() (*model.Book, error)
+}
+
+// NewDecoder build the decoder of a content type reading from r
+func NewDecoder(contentType string, r io.Reader) (Decoder, error) {
+	switch contentType {
+	case CSVContentType:
+		return newCSVDecoder(r)
+	case JSONContentType:
+		return newJSONDecoder(r)
+	case NDJSONContentType, "application/ndjson":
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+		return &ndjsonDecoder{scanner: scanner}, nil
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// csvDecoder read books from CSV rows, columns are named by the header row
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+// newCSVDecoder read and check the header row, managed fields columns are ignored
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("missing CSV header row")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if _, ok := model.BookFields[column]; !ok {
+			return nil, fmt.Errorf("unknown CSV column %q", column)
+		}
+		columns[i] = column
+	}
+
+	return &csvDecoder{reader: reader, columns: columns}, nil
+}
+
+func (d *csvDecoder) Next() (*model.Book, error) {
+	record, err := d.reader.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		if parseError, ok := err.(*csv.ParseError); ok && parseError.Err == csv.ErrFieldCount {
+			return nil, &RowError{err}
+		}
+		return nil, err
+	}
+
+	fields := map[string]interface{}{}
+	for i, raw := range record {
+		column := d.columns[i]
+		if model.IsManaged(column) {
+			continue
+		}
+		raw = strings.TrimSpace(raw)
+		if model.BookFields[column] == model.FieldObjectIDs {
+			// ids are comma separated, as exported
+			var IDs []string
+			for _, ID := range strings.Split(raw, ",") {
+				if ID = strings.TrimSpace(ID); ID != "" {
+					IDs = append(IDs, ID)
+				}
+			}
+			if len(IDs) > 0 {
+				fields[column] = IDs
+			}
+			continue
+		}
+		if model.BookFields[column] == model.FieldContributors {
+			// contributors are `role:name` separated by `;`, as exported
+			contributors, err := model.ParseContributors(raw)
+			if err != nil {
+				return nil, &RowError{fmt.Errorf("%s: %s", column, err)}
+			}
+			if len(contributors) > 0 {
+				fields[column] = contributors
+			}
+			continue
+		}
+		fieldType := model.BookFields[column]
+		if fieldType != model.FieldInt && fieldType != model.FieldFloat && fieldType != model.FieldBool {
+			fields[column] = raw
+			continue
+		}
+		if raw == "" {
+			continue
+		}
+		value, err := model.ParseFieldValue(fieldType, raw)
+		if err != nil {
+			return nil, &RowError{fmt.Errorf("%s: %s", column, err)}
+		}
+		fields[column] = value
+	}
+
+	// the map has the json names of the book fields, it is decoded like a JSON row
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return nil, &RowError{err}
+	}
+	return unmarshalBook(data)
+}
+
+// jsonDecoder read books from a JSON array, one element at a time
+type jsonDecoder struct {
+	decoder *json.Decoder
+}
+
+// newJSONDecoder read the opening bracket of the array
+func newJSONDecoder(r io.Reader) (*jsonDecoder, error) {
+	decoder := json.NewDecoder(r)
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token != json.Delim('[') {
+		return nil, errors.New("expected a JSON array of books")
+	}
+	return &jsonDecoder{decoder: decoder}, nil
+}
+
+func (d *jsonDecoder) Next() (*model.Book, error) {
+	if !d.decoder.More() {
+		if _, err := d.decoder.Token(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	var data json.RawMessage
+	if err := d.decoder.Decode(&data); err != nil {
+		return nil, err
+	}
+	return unmarshalBook(data)
+}
+
+// ndjsonDecoder read books from newline delimited JSON, blank lines are skipped
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+}
+
+func (d *ndjsonDecoder) Next() (*model.Book, error) {
+	for d.scanner.Scan() {
+		line := bytes.TrimSpace(d.scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		return unmarshalBook(line)
+	}
+	if err := d.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// unmarshalBook decode a single JSON book, errors only concern this row
+func unmarshalBook(data []byte) (*model.Book, error) {
+	book := &model.Book{}
+	if err := json.Unmarshal(data, book); err != nil {
+		return nil, &RowError{err}
+	}
+	return book, nil
+}
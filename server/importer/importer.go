@@ -0,0 +1,211 @@
+package importer
+
+import (
+	"context"
+	"io"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// Row statuses
+const (
+	StatusCreated = "created"
+	StatusUpdated = "updated"
+	StatusFailed  = "failed"
+)
+
+// Importer write decoded books into a repository one row at a time
+type Importer struct {
+	Repo interfaces.IBookRepository
+	// DryRun check rows and report what would be written without writing
+	DryRun bool
+	// Upsert update the book having the same ISBN instead of failing on duplicates
+	Upsert bool
+	// Link check the authors, genre and series of a checked book, nil leaves them unchecked
+	Link func(ctx context.Context, book *model.Book) error
+	// Write wrap the write of a checked book, to group it into its work. Never called on dry runs, nil just writes
+	Write func(ctx context.Context, book *model.Book, write func() error) error
+
+	// planned ISBN-13 a dry run would have created, the repository can't know them
+	planned map[string]bool
+}
+
+// Report result of an import.
+//
+// Rows has a small result for every row, the body size limit of imports bounds them.
+type Report struct {
+	DryRun  bool        `json:"dry_run"`
+	Created int64       `json:"created"`
+	Updated int64       `json:"updated"`
+	Failed  int64       `json:"failed"`
+	Rows    []RowResult `json:"rows"`
+	// Error stops the import when the rest of the file can't be read
+	Error string `json:"error,omitempty"`
+}
+
+// RowResult result of a single row, rows are numbered from 1.
+//
+// ID is the book written, or the existing book a failed row conflicts with.
+type RowResult struct {
+	Row    int64              `json:"row"`
+	Status string             `json:"status"`
+	ID     *objectid.ObjectID `json:"_id,omitempty"`
+	ISBN13 string             `json:"isbn_13,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// Run read every row of decoder and import it, failed rows don't stop the import
+func (im *Importer) Run(ctx context.Context, decoder Decoder) *Report {
+	report := &Report{DryRun: im.DryRun, Rows: []RowResult{}}
+	im.planned = map[string]bool{}
+
+	for row := int64(1); ; row++ {
+		book, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*RowError); !ok {
+				report.Error = err.Error()
+				break
+			}
+			report.add(RowResult{Row: row, Status: StatusFailed, Error: err.Error()})
+			continue
+		}
+
+		result := im.importBook(ctx, book)
+		result.Row = row
+		report.add(result)
+	}
+
+	return report
+}
+
+// importBook check a book with the same rules as `model.BookRequest.Bind` then write it
+func (im *Importer) importBook(ctx context.Context, book *model.Book) RowResult {
+	request := &model.BookRequest{Book: book}
+	if err := request.Bind(nil); err != nil {
+		return failed(book, err)
+	}
+	if im.Link != nil {
+		if err := im.Link(ctx, book); err != nil {
+			return failed(book, err)
+		}
+	}
+
+	existing, err := im.findByISBN(ctx, book.ISBN13)
+	if err != nil {
+		return failed(book, err)
+	}
+	if existing != nil && !im.Upsert {
+		return conflict(book, existing, model.ErrDuplicateISBN)
+	}
+	if existing == nil && im.planned[book.ISBN13] {
+		// an earlier row of this dry run would have created it
+		if !im.Upsert {
+			return failed(book, model.ErrDuplicateISBN)
+		}
+		return RowResult{Status: StatusUpdated, ISBN13: book.ISBN13}
+	}
+
+	if existing != nil {
+		// bound again over the existing book, like an update
+		request = &model.BookRequest{Book: book, Previous: existing}
+		if err := request.Bind(nil); err != nil {
+			return conflict(book, existing, err)
+		}
+		if !im.DryRun {
+			err := im.write(ctx, book, func() (err error) {
+				book, err = im.Repo.Update(ctx, book)
+				return err
+			})
+			if err != nil {
+				return conflict(request.Book, existing, err)
+			}
+		}
+		return RowResult{Status: StatusUpdated, ID: rowID(book.ID), ISBN13: book.ISBN13}
+	}
+
+	if im.DryRun {
+		if book.ISBN13 != "" {
+			im.planned[book.ISBN13] = true
+		}
+		return RowResult{Status: StatusCreated, ISBN13: book.ISBN13}
+	}
+	err = im.write(ctx, book, func() (err error) {
+		book, err = im.Repo.Create(ctx, book)
+		return err
+	})
+	if err != nil {
+		return failed(request.Book, err)
+	}
+	return RowResult{Status: StatusCreated, ID: rowID(book.ID), ISBN13: book.ISBN13}
+}
+
+// write write a book with the Write hook, if any
+func (im *Importer) write(ctx context.Context, book *model.Book, write func() error) error {
+	if im.Write == nil {
+		return write()
+	}
+	return im.Write(ctx, book, write)
+}
+
+// findByISBN get the book having an ISBN-13, nil when there is none
+func (im *Importer) findByISBN(ctx context.Context, isbn13 string) (*model.Book, error) {
+	if isbn13 == "" {
+		return nil, nil
+	}
+
+	page, err := im.Repo.List(
+		ctx,
+		&model.BookFilter{ISBN13: isbn13},
+		&model.Sorting{Sort: "_id", Direction: 1},
+		&model.Pagination{Limit: 1},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(page.Books) == 0 {
+		return nil, nil
+	}
+	return &page.Books[0], nil
+}
+
+// add append a row result and count it
+func (r *Report) add(result RowResult) {
+	switch result.Status {
+	case StatusCreated:
+		r.Created++
+	case StatusUpdated:
+		r.Updated++
+	case StatusFailed:
+		r.Failed++
+	}
+	r.Rows = append(r.Rows, result)
+}
+
+// failed result of a row that couldn't be imported
+func failed(book *model.Book, err error) RowResult {
+	result := RowResult{Status: StatusFailed, Error: err.Error()}
+	if book != nil {
+		result.ISBN13 = book.ISBN13
+	}
+	return result
+}
+
+// conflict result of a row that couldn't be written over an existing book
+func conflict(book *model.Book, existing *model.Book, err error) RowResult {
+	result := failed(book, err)
+	result.ID = rowID(existing.ID)
+	return result
+}
+
+// rowID id of a row result, nil when the book has none yet
+func rowID(ID objectid.ObjectID) *objectid.ObjectID {
+	if ID.IsZero() {
+		return nil
+	}
+	return &ID
+}
@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces/mocks"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDecoders(t *testing.T) {
+	bodies := map[string]string{
+		CSVContentType:    "title,isbn_10,rating,_id\nSardines,0-306-40615-2,5,ignored\n\"Trawler, the\",,4,\n",
+		JSONContentType:   `[{"title":"Sardines","isbn_10":"0-306-40615-2","rating":5},{"title":"Trawler, the","rating":4}]`,
+		NDJSONContentType: "{\"title\":\"Sardines\",\"isbn_10\":\"0-306-40615-2\",\"rating\":5}\n\n{\"title\":\"Trawler, the\",\"rating\":4}\n",
+	}
+
+	for contentType, body := range bodies {
+		decoder, err := NewDecoder(contentType, strings.NewReader(body))
+		assert.NoError(t, err, contentType)
+
+		var books []*model.Book
+		for {
+			book, err := decoder.Next()
+			if err == io.EOF {
+				break
+			}
+			assert.NoError(t, err, contentType)
+			books = append(books, book)
+		}
+
+		assert.Equal(t, []*model.Book{
+			{Title: "Sardines", ISBN10: "0-306-40615-2", Rating: 5},
+			{Title: "Trawler, the", Rating: 4},
+		}, books, contentType)
+	}
+
+	_, err := NewDecoder("text/plain", strings.NewReader(""))
+	assert.Equal(t, ErrUnsupportedFormat, err)
+
+	_, err = NewDecoder(CSVContentType, strings.NewReader("title,colour\n"))
+	assert.Error(t, err, "It should reject unknown columns")
+}
+
+func TestDecoderRowErrors(t *testing.T) {
+	decoder, _ := NewDecoder(CSVContentType, strings.NewReader("title,rating\nSardines,five\nTrawler,4\n"))
+
+	_, err := decoder.Next()
+	assert.IsType(t, &RowError{}, err)
+
+	book, err := decoder.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "Trawler", book.Title)
+
+	decoder, _ = NewDecoder(NDJSONContentType, strings.NewReader("{\"title\":\n{\"title\":\"Trawler\"}\n"))
+
+	_, err = decoder.Next()
+	assert.IsType(t, &RowError{}, err, "It should skip to the next line")
+
+	book, err = decoder.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "Trawler", book.Title)
+}
+
+func TestImporterRun(t *testing.T) {
+	existing := model.Book{ID: objectid.New(), Title: "Sardines", ISBN13: "9780306406157", Version: 2}
+	body := `[
+		{"title":"Sardines, revised","isbn_10":"0-306-40615-2"},
+		{"title":"Trawler"},
+		{"title":"Seagulls","isbn_13":"9780306406158"},
+		{"title":"Boat","language":"klingon"}
+	]`
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, &model.BookFilter{ISBN13: "9780306406157"}, mock.Anything, mock.Anything).
+		Return(&model.BookPage{Books: []model.Book{existing}}, nil)
+	repoMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, b *model.Book) *model.Book {
+		b.ID = objectid.New()
+		return b
+	}, nil)
+	repoMock.On("Update", mock.Anything, mock.Anything).Return(func(_ context.Context, b *model.Book) *model.Book {
+		return b
+	}, nil)
+
+	decoder, _ := NewDecoder(JSONContentType, strings.NewReader(body))
+	report := (&Importer{Repo: repoMock}).Run(context.Background(), decoder)
+
+	assert.Equal(t, int64(1), report.Created)
+	assert.Equal(t, int64(0), report.Updated)
+	assert.Equal(t, int64(3), report.Failed)
+	assert.Len(t, report.Rows, 4, "It should report every row")
+	assert.Equal(t, int64(1), report.Rows[0].Row)
+	assert.Equal(t, model.ErrDuplicateISBN.Error(), report.Rows[0].Error)
+	assert.Equal(t, &existing.ID, report.Rows[0].ID)
+	assert.Equal(t, StatusCreated, report.Rows[1].Status)
+	assert.NotNil(t, report.Rows[1].ID)
+	assert.Contains(t, report.Rows[2].Error, "isbn_13")
+	assert.Nil(t, report.Rows[2].ID)
+	assert.Contains(t, report.Rows[3].Error, "language")
+	repoMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	decoder, _ = NewDecoder(JSONContentType, strings.NewReader(body))
+	report = (&Importer{Repo: repoMock, Upsert: true, DryRun: true}).Run(context.Background(), decoder)
+
+	assert.True(t, report.DryRun)
+	assert.Equal(t, int64(1), report.Created)
+	assert.Equal(t, int64(1), report.Updated)
+	assert.Equal(t, &existing.ID, report.Rows[0].ID)
+	assert.Nil(t, report.Rows[1].ID, "It should not report an id nothing was written to")
+	repoMock.AssertNumberOfCalls(t, "Create", 1)
+	repoMock.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+	decoder, _ = NewDecoder(JSONContentType, strings.NewReader(body))
+	report = (&Importer{Repo: repoMock, Upsert: true}).Run(context.Background(), decoder)
+
+	assert.Equal(t, int64(1), report.Updated)
+	repoMock.AssertCalled(t, "Update", mock.Anything, mock.MatchedBy(func(b *model.Book) bool {
+		return b.ID == existing.ID && b.Version == 2 && b.Title == "Sardines, revised"
+	}))
+}
+
+func TestImporterDryRunRepeatedISBN(t *testing.T) {
+	body := "{\"title\":\"Sardines\",\"isbn_13\":\"9780306406157\"}\n{\"title\":\"Sardines, revised\",\"isbn_13\":\"9780306406157\"}\n"
+
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("List", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&model.BookPage{}, nil)
+
+	decoder, _ := NewDecoder(NDJSONContentType, strings.NewReader(body))
+	report := (&Importer{Repo: repoMock, DryRun: true}).Run(context.Background(), decoder)
+
+	assert.Equal(t, int64(1), report.Created)
+	assert.Equal(t, int64(1), report.Failed, "It should fail an ISBN created earlier in the same run")
+	assert.Equal(t, model.ErrDuplicateISBN.Error(), report.Rows[1].Error)
+
+	decoder, _ = NewDecoder(NDJSONContentType, strings.NewReader(body))
+	report = (&Importer{Repo: repoMock, DryRun: true, Upsert: true}).Run(context.Background(), decoder)
+
+	assert.Equal(t, int64(1), report.Created)
+	assert.Equal(t, int64(1), report.Updated)
+	assert.Equal(t, StatusUpdated, report.Rows[1].Status)
+}
+
+func TestImporterStopsOnSyntaxError(t *testing.T) {
+	repoMock := &mocks.IBookRepository{}
+	repoMock.On("Create", mock.Anything, mock.Anything).Return(func(_ context.Context, b *model.Book) *model.Book { return b }, nil)
+
+	decoder, _ := NewDecoder(JSONContentType, strings.NewReader(`[{"title":"Sardines"}, {"title":`))
+	report := (&Importer{Repo: repoMock}).Run(context.Background(), decoder)
+
+	assert.Equal(t, int64(1), report.Created)
+	assert.NotEmpty(t, report.Error)
+}
@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// IAuthorRepository Author repository interface
+type IAuthorRepository interface {
+	List(ctx context.Context, name string) ([]model.Author, error)
+	Get(ctx context.Context, ID string) (*model.Author, error)
+	GetMany(ctx context.Context, IDs []objectid.ObjectID) ([]model.Author, error)
+	Create(ctx context.Context, author *model.Author) (*model.Author, error)
+	Update(ctx context.Context, author *model.Author) (*model.Author, error)
+	Delete(ctx context.Context, ID objectid.ObjectID) (int64, error)
+}
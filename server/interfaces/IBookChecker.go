@@ -0,0 +1,8 @@
+package interfaces
+
+import "context"
+
+// IBookChecker storage able to check the books it stores
+type IBookChecker interface {
+	CountUndecodable(ctx context.Context) (int64, error)
+}
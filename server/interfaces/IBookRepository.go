@@ -1,7 +1,9 @@
 package interfaces
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/MathieuDoyon/bookshelf/server/model"
 	"github.com/mongodb/mongo-go-driver/bson/objectid"
@@ -9,10 +11,17 @@ import (
 
 // IBookRepository Book repository interface
 type IBookRepository interface {
-	List(filters *model.BookFilter, sorting *model.Sorting) ([]model.Book, error)
-	Create(book *model.Book) (*model.Book, error)
-	Get(ID string) (*model.Book, error)
-	Update(book *model.Book) (*model.Book, error)
-	Delete(ID objectid.ObjectID) (int64, error)
+	List(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, pagination *model.Pagination) (*model.BookPage, error)
+	Stream(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, fn func(book *model.Book) error) error
+	Search(ctx context.Context, query string, limit int64) (*model.SearchPage, error)
+	Create(ctx context.Context, book *model.Book) (*model.Book, error)
+	Get(ctx context.Context, ID string) (*model.Book, error)
+	GetTrashed(ctx context.Context, ID string) (*model.Book, error)
+	Update(ctx context.Context, book *model.Book) (*model.Book, error)
+	Patch(ctx context.Context, book *model.Book, fields []string) (*model.Book, error)
+	Delete(ctx context.Context, ID objectid.ObjectID, version int64) (int64, error)
+	Restore(ctx context.Context, ID objectid.ObjectID) (*model.Book, error)
+	Purge(ctx context.Context, ID objectid.ObjectID) (int64, error)
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
 	BookCtx(next http.Handler) http.Handler
 }
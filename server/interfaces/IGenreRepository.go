@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// IGenreRepository Genre repository interface
+type IGenreRepository interface {
+	List(ctx context.Context) ([]model.Genre, error)
+	Get(ctx context.Context, ID string) (*model.Genre, error)
+	Create(ctx context.Context, genre *model.Genre) (*model.Genre, error)
+	Update(ctx context.Context, genre *model.Genre) (*model.Genre, error)
+	Delete(ctx context.Context, ID objectid.ObjectID) (int64, error)
+}
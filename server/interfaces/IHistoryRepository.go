@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// IHistoryRepository Book repository recording a revision for every write
+type IHistoryRepository interface {
+	IBookRepository
+	WithActor(actor string) IHistoryRepository
+	History(ctx context.Context, bookID objectid.ObjectID) ([]model.Revision, error)
+	Revision(ctx context.Context, bookID objectid.ObjectID, number int64) (*model.Revision, error)
+	Revert(ctx context.Context, book *model.Book, number int64) (*model.Book, error)
+}
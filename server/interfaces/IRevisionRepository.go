@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// IRevisionRepository append only store of book revisions
+type IRevisionRepository interface {
+	Append(ctx context.Context, revision *model.Revision) error
+	List(ctx context.Context, bookID objectid.ObjectID) ([]model.Revision, error)
+	Get(ctx context.Context, bookID objectid.ObjectID, number int64) (*model.Revision, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// ISeriesRepository Series repository interface
+type ISeriesRepository interface {
+	List(ctx context.Context) ([]model.Series, error)
+	Get(ctx context.Context, ID string) (*model.Series, error)
+	Create(ctx context.Context, series *model.Series) (*model.Series, error)
+	Update(ctx context.Context, series *model.Series) (*model.Series, error)
+	Delete(ctx context.Context, ID objectid.ObjectID) (int64, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// IWorkRepository Work repository interface
+type IWorkRepository interface {
+	List(ctx context.Context) ([]model.Work, error)
+	Get(ctx context.Context, ID string) (*model.Work, error)
+	Create(ctx context.Context, work *model.Work) (*model.Work, error)
+	Update(ctx context.Context, work *model.Work) (*model.Work, error)
+	Delete(ctx context.Context, ID objectid.ObjectID) (int64, error)
+}
@@ -2,11 +2,13 @@
 
 package mocks
 
+import context "context"
 import http "net/http"
 
 import mock "github.com/stretchr/testify/mock"
 import model "github.com/MathieuDoyon/bookshelf/server/model"
 import objectid "github.com/mongodb/mongo-go-driver/bson/objectid"
+import time "time"
 
 // IBookRepository is an autogenerated mock type for the IBookRepository type
 type IBookRepository struct {
@@ -29,13 +31,13 @@ func (_m *IBookRepository) BookCtx(next http.Handler) http.Handler {
 	return r0
 }
 
-// Create provides a mock function with given fields: book
-func (_m *IBookRepository) Create(book *model.Book) (*model.Book, error) {
-	ret := _m.Called(book)
+// Create provides a mock function with given fields: ctx, book
+func (_m *IBookRepository) Create(ctx context.Context, book *model.Book) (*model.Book, error) {
+	ret := _m.Called(ctx, book)
 
 	var r0 *model.Book
-	if rf, ok := ret.Get(0).(func(*model.Book) *model.Book); ok {
-		r0 = rf(book)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Book) *model.Book); ok {
+		r0 = rf(ctx, book)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Book)
@@ -43,8 +45,8 @@ func (_m *IBookRepository) Create(book *model.Book) (*model.Book, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*model.Book) error); ok {
-		r1 = rf(book)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Book) error); ok {
+		r1 = rf(ctx, book)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -52,20 +54,20 @@ func (_m *IBookRepository) Create(book *model.Book) (*model.Book, error) {
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: ID
-func (_m *IBookRepository) Delete(ID objectid.ObjectID) (int64, error) {
-	ret := _m.Called(ID)
+// Delete provides a mock function with given fields: ctx, ID, version
+func (_m *IBookRepository) Delete(ctx context.Context, ID objectid.ObjectID, version int64) (int64, error) {
+	ret := _m.Called(ctx, ID, version)
 
 	var r0 int64
-	if rf, ok := ret.Get(0).(func(objectid.ObjectID) int64); ok {
-		r0 = rf(ID)
+	if rf, ok := ret.Get(0).(func(context.Context, objectid.ObjectID, int64) int64); ok {
+		r0 = rf(ctx, ID, version)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(objectid.ObjectID) error); ok {
-		r1 = rf(ID)
+	if rf, ok := ret.Get(1).(func(context.Context, objectid.ObjectID, int64) error); ok {
+		r1 = rf(ctx, ID, version)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -73,13 +75,13 @@ func (_m *IBookRepository) Delete(ID objectid.ObjectID) (int64, error) {
 	return r0, r1
 }
 
-// Get provides a mock function with given fields: ID
-func (_m *IBookRepository) Get(ID string) (*model.Book, error) {
-	ret := _m.Called(ID)
+// Get provides a mock function with given fields: ctx, ID
+func (_m *IBookRepository) Get(ctx context.Context, ID string) (*model.Book, error) {
+	ret := _m.Called(ctx, ID)
 
 	var r0 *model.Book
-	if rf, ok := ret.Get(0).(func(string) *model.Book); ok {
-		r0 = rf(ID)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Book); ok {
+		r0 = rf(ctx, ID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Book)
@@ -87,8 +89,8 @@ func (_m *IBookRepository) Get(ID string) (*model.Book, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(ID)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -96,22 +98,22 @@ func (_m *IBookRepository) Get(ID string) (*model.Book, error) {
 	return r0, r1
 }
 
-// List provides a mock function with given fields: filters, sorting
-func (_m *IBookRepository) List(filters *model.BookFilter, sorting *model.Sorting) ([]model.Book, error) {
-	ret := _m.Called(filters, sorting)
+// GetTrashed provides a mock function with given fields: ctx, ID
+func (_m *IBookRepository) GetTrashed(ctx context.Context, ID string) (*model.Book, error) {
+	ret := _m.Called(ctx, ID)
 
-	var r0 []model.Book
-	if rf, ok := ret.Get(0).(func(*model.BookFilter, *model.Sorting) []model.Book); ok {
-		r0 = rf(filters, sorting)
+	var r0 *model.Book
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.Book); ok {
+		r0 = rf(ctx, ID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]model.Book)
+			r0 = ret.Get(0).(*model.Book)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*model.BookFilter, *model.Sorting) error); ok {
-		r1 = rf(filters, sorting)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -119,13 +121,161 @@ func (_m *IBookRepository) List(filters *model.BookFilter, sorting *model.Sortin
 	return r0, r1
 }
 
-// Update provides a mock function with given fields: book
-func (_m *IBookRepository) Update(book *model.Book) (*model.Book, error) {
-	ret := _m.Called(book)
+// List provides a mock function with given fields: ctx, filters, sorting, pagination
+func (_m *IBookRepository) List(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, pagination *model.Pagination) (*model.BookPage, error) {
+	ret := _m.Called(ctx, filters, sorting, pagination)
+
+	var r0 *model.BookPage
+	if rf, ok := ret.Get(0).(func(context.Context, *model.BookFilter, *model.Sorting, *model.Pagination) *model.BookPage); ok {
+		r0 = rf(ctx, filters, sorting, pagination)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.BookPage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.BookFilter, *model.Sorting, *model.Pagination) error); ok {
+		r1 = rf(ctx, filters, sorting, pagination)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: ctx, book, fields
+func (_m *IBookRepository) Patch(ctx context.Context, book *model.Book, fields []string) (*model.Book, error) {
+	ret := _m.Called(ctx, book, fields)
+
+	var r0 *model.Book
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Book, []string) *model.Book); ok {
+		r0 = rf(ctx, book, fields)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Book)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Book, []string) error); ok {
+		r1 = rf(ctx, book, fields)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, ID
+func (_m *IBookRepository) Purge(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	ret := _m.Called(ctx, ID)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, objectid.ObjectID) int64); ok {
+		r0 = rf(ctx, ID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, objectid.ObjectID) error); ok {
+		r1 = rf(ctx, ID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PurgeTrash provides a mock function with given fields: ctx, before
+func (_m *IBookRepository) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	ret := _m.Called(ctx, before)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, ID
+func (_m *IBookRepository) Restore(ctx context.Context, ID objectid.ObjectID) (*model.Book, error) {
+	ret := _m.Called(ctx, ID)
+
+	var r0 *model.Book
+	if rf, ok := ret.Get(0).(func(context.Context, objectid.ObjectID) *model.Book); ok {
+		r0 = rf(ctx, ID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.Book)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, objectid.ObjectID) error); ok {
+		r1 = rf(ctx, ID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, query, limit
+func (_m *IBookRepository) Search(ctx context.Context, query string, limit int64) (*model.SearchPage, error) {
+	ret := _m.Called(ctx, query, limit)
+
+	var r0 *model.SearchPage
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *model.SearchPage); ok {
+		r0 = rf(ctx, query, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.SearchPage)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, query, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Stream provides a mock function with given fields: ctx, filters, sorting, fn
+func (_m *IBookRepository) Stream(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, fn func(*model.Book) error) error {
+	ret := _m.Called(ctx, filters, sorting, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.BookFilter, *model.Sorting, func(*model.Book) error) error); ok {
+		r0 = rf(ctx, filters, sorting, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: ctx, book
+func (_m *IBookRepository) Update(ctx context.Context, book *model.Book) (*model.Book, error) {
+	ret := _m.Called(ctx, book)
 
 	var r0 *model.Book
-	if rf, ok := ret.Get(0).(func(*model.Book) *model.Book); ok {
-		r0 = rf(book)
+	if rf, ok := ret.Get(0).(func(context.Context, *model.Book) *model.Book); ok {
+		r0 = rf(ctx, book)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*model.Book)
@@ -133,8 +283,8 @@ func (_m *IBookRepository) Update(book *model.Book) (*model.Book, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(*model.Book) error); ok {
-		r1 = rf(book)
+	if rf, ok := ret.Get(1).(func(context.Context, *model.Book) error); ok {
+		r1 = rf(ctx, book)
 	} else {
 		r1 = ret.Error(1)
 	}
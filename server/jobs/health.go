@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+)
+
+// Health statuses
+const (
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthUnavailable = "unavailable"
+)
+
+// HealthReport result of the last health check
+type HealthReport struct {
+	Status string `json:"status"`
+	// UndecodableBooks stored books which can't be read back and need to be repaired
+	UndecodableBooks int64     `json:"undecodable_books"`
+	Error            string    `json:"error,omitempty"`
+	CheckedAt        time.Time `json:"checked_at"`
+}
+
+// HealthCheck periodically check the stored books, a nil Checker is always healthy
+type HealthCheck struct {
+	Checker interfaces.IBookChecker
+
+	mu     sync.RWMutex
+	report HealthReport
+}
+
+// NewHealthCheck create a health check reporting ok until it runs
+func NewHealthCheck(checker interfaces.IBookChecker) *HealthCheck {
+	return &HealthCheck{
+		Checker: checker,
+		report:  HealthReport{Status: HealthOK},
+	}
+}
+
+// Run check the stored books every interval
+func (hc *HealthCheck) Run(every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		report := hc.Check(context.Background())
+		if report.Status != HealthOK {
+			log.Printf("Health check %s: %d undecodable books %s", report.Status, report.UndecodableBooks, report.Error)
+		}
+		<-ticker.C
+	}
+}
+
+// Check check the stored books now and keep the report
+func (hc *HealthCheck) Check(ctx context.Context) HealthReport {
+	report := HealthReport{Status: HealthOK, CheckedAt: time.Now()}
+	if hc.Checker != nil {
+		count, err := hc.Checker.CountUndecodable(ctx)
+		switch {
+		case err != nil:
+			report.Status = HealthUnavailable
+			report.Error = err.Error()
+		case count > 0:
+			report.Status = HealthDegraded
+		}
+		report.UndecodableBooks = count
+	}
+
+	hc.mu.Lock()
+	hc.report = report
+	hc.mu.Unlock()
+	return report
+}
+
+// Report last health report
+func (hc *HealthCheck) Report() HealthReport {
+	hc.mu.RLock()
+	defer hc.mu.RUnlock()
+	return hc.report
+}
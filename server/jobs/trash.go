@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+)
+
+// PurgeTrash permanently remove books trashed for longer than retention, checking every interval
+func PurgeTrash(repo interfaces.IBookRepository, retention time.Duration, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+
+	for {
+		purged, err := repo.PurgeTrash(context.Background(), time.Now().Add(-retention))
+		if err != nil {
+			log.Printf("Error purging trash: %s", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d books from trash", purged)
+		}
+		<-ticker.C
+	}
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
-	"github.com/MathieuDoyon/bookshelf/server/db"
 	"github.com/MathieuDoyon/bookshelf/server/handlers"
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/jobs"
 	"github.com/MathieuDoyon/bookshelf/server/repositories"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
@@ -16,27 +19,115 @@ import (
 func init() {
 	viper.SetEnvPrefix("bookshelf")
 	viper.AutomaticEnv()
-
-	db.Configure()
+	viper.SetDefault("trash_retention", 30*24*time.Hour)
+	viper.SetDefault("storage", "mongo")
+	viper.SetDefault("bolt_path", "bookshelf.db")
+	viper.SetDefault("timeout_read", 5*time.Second)
+	viper.SetDefault("timeout_search", 10*time.Second)
+	viper.SetDefault("timeout_write", 10*time.Second)
+	// a whole export, a stalled client doesn't hold its read forever
+	viper.SetDefault("timeout_stream", 10*time.Minute)
+	viper.SetDefault("health_interval", 10*time.Minute)
+	viper.SetDefault("strict_json", true)
+	viper.SetDefault("collation", "en")
+	viper.SetDefault("max_body_size", "1MB")
+	viper.SetDefault("max_import_size", "32MB")
+	viper.SetDefault("max_collapse_books", 10000)
+	viper.SetDefault("author_resync_delay", time.Minute)
 }
 
 func main() {
-	defer db.Client.Disconnect(nil)
+	storage, err := repositories.OpenStorage(viper.GetString("storage"), repositories.Options{
+		BoltPath:       viper.GetString("bolt_path"),
+		StrictDecoding: viper.GetBool("strict_decoding"),
+		Collation:      viper.GetString("collation"),
+	})
+	if err != nil {
+		log.Fatal("Could not open storage: ", err)
+	}
+	defer storage.Close()
+	if viper.GetString("storage") == repositories.StorageMemory {
+		log.Println("Books are stored in memory, they are lost on restart")
+	}
 
+	timeouts := repositories.Timeouts{
+		Read:   viper.GetDuration("timeout_read"),
+		Search: viper.GetDuration("timeout_search"),
+		Write:  viper.GetDuration("timeout_write"),
+		Stream: viper.GetDuration("timeout_stream"),
+	}
+
+	// every write is recorded into the book history
+	bookRepo := repositories.NewHistoryRepo(
+		repositories.NewTimeoutRepo(storage.Books, timeouts),
+		repositories.NewTimeoutRevisionRepo(storage.Revisions, timeouts),
+	)
+	authorRepo := repositories.NewTimeoutAuthorRepo(storage.Authors, timeouts)
+	genreRepo := repositories.NewTimeoutGenreRepo(storage.Genres, timeouts)
+	seriesRepo := repositories.NewTimeoutSeriesRepo(storage.Series, timeouts)
+	workRepo := repositories.NewTimeoutWorkRepo(storage.Works, timeouts)
+	if seeded, err := repositories.SeedGenres(context.Background(), genreRepo); err != nil {
+		log.Fatal("Could not create default genres: ", err)
+	} else if seeded > 0 {
+		log.Printf("Created %d default genres", seeded)
+	}
 	bookResource := handlers.BooksResource{
-		Repo: &repositories.BookRepo{},
+		Repo:           bookRepo,
+		Authors:        authorRepo,
+		Genres:         genreRepo,
+		Series:         seriesRepo,
+		Works:          workRepo,
+		RequireIfMatch: viper.GetBool("require_if_match"),
+		StrictJSON:     viper.GetBool("strict_json"),
+		MaxBodySize:    int64(viper.GetSizeInBytes("max_body_size")),
+		MaxImportSize:  int64(viper.GetSizeInBytes("max_import_size")),
+		// collapsed lists are plain reads, bounded like them
+		MaxCollapseBooks: viper.GetInt64("max_collapse_books"),
+		CollapseTimeout:  viper.GetDuration("timeout_read"),
 	}
+	authorResource := handlers.AuthorsResource{
+		Repo:        authorRepo,
+		Books:       &bookResource,
+		ResyncDelay: viper.GetDuration("author_resync_delay"),
+	}
+	genreResource := handlers.GenresResource{
+		Repo:  genreRepo,
+		Books: &bookResource,
+	}
+	seriesResource := handlers.SeriesResource{
+		Repo:  seriesRepo,
+		Books: &bookResource,
+	}
+	workResource := handlers.WorksResource{
+		Repo:  workRepo,
+		Books: &bookResource,
+	}
+
+	// trashed books are purged after retention, 0 keeps them forever
+	if retention := viper.GetDuration("trash_retention"); retention > 0 {
+		go jobs.PurgeTrash(bookRepo, retention, time.Hour)
+	}
+
+	// backends which can't hold undecodable books are always healthy
+	checker, _ := storage.Books.(interfaces.IBookChecker)
+	healthCheck := jobs.NewHealthCheck(checker)
+	go healthCheck.Run(viper.GetDuration("health_interval"))
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
+	r.Use(handlers.Recoverer)
 
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("When the seagulls follow the trawler, it's because they think sardines will be thrown into the sea."))
 	})
+	r.Get("/health", handlers.Health(healthCheck))
 	r.Mount("/books", bookResource.Routes())
+	r.Mount("/authors", authorResource.Routes())
+	r.Mount("/genres", genreResource.Routes())
+	r.Mount("/series", seriesResource.Routes())
+	r.Mount("/works", workResource.Routes())
 
 	log.Fatal(http.ListenAndServe(":8080", r))
 	fmt.Println("server is listening on port :8080")
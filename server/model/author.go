@@ -0,0 +1,188 @@
+package model
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// ErrAuthorNotFound returned when an author doesn't exist
+var ErrAuthorNotFound = &Error{Kind: KindNotFound, Code: CodeAuthorNotFound, Message: "author not found"}
+
+// ErrAuthorInUse returned when deleting an author books still link to
+var ErrAuthorInUse = &Error{Kind: KindConflict, Code: CodeAuthorInUse, Message: "author is linked to books"}
+
+// Author author model structure, books link to authors by id
+type Author struct {
+	ID objectid.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	// Name name books are credited with
+	Name string `bson:"name" json:"name"`
+	// SortName name authors are sorted by, "Doyon, Mathieu" by default
+	SortName  string `bson:"sort_name" json:"sort_name"`
+	BirthYear int64  `bson:"birth_year,omitempty" json:"birth_year,omitempty"`
+	DeathYear int64  `bson:"death_year,omitempty" json:"death_year,omitempty"`
+	// Aliases other spellings of the name, ex: "M. Doyon"
+	Aliases   []string  `bson:"aliases" json:"aliases"`
+	Biography string    `bson:"biography" json:"biography"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// AuthorName name of a linked author, embedded into book responses
+type AuthorName struct {
+	ID   objectid.ObjectID `json:"_id"`
+	Name string            `json:"name"`
+}
+
+// Validate check every field of an author, all the invalid fields are returned in a single error
+func (a *Author) Validate() error {
+	var fields []FieldError
+	check := func(field string, value interface{}, rules ...Rule) {
+		for _, rule := range rules {
+			if err := rule(value); err != nil {
+				err.Field = field
+				fields = append(fields, *err)
+				return
+			}
+		}
+	}
+
+	check("name", a.Name, Required, Length(1, 200))
+	check("sort_name", a.SortName, Length(1, 200))
+	check("birth_year", a.BirthYear, Range(1, 9999), NotInFuture)
+	check("death_year", a.DeathYear, Range(1, 9999), NotInFuture)
+	for _, alias := range a.Aliases {
+		check("aliases", alias, Required, Length(1, 200))
+	}
+	check("biography", a.Biography, Length(1, 10000))
+
+	if a.BirthYear != 0 && a.DeathYear != 0 && a.DeathYear < a.BirthYear {
+		fields = append(fields, FieldError{Field: "death_year", Reason: ReasonOutOfRange, Message: "can't be before birth_year"})
+	}
+
+	if len(fields) > 0 {
+		return &Error{Kind: KindValidation, Code: CodeValidation, Message: "invalid author", Fields: fields}
+	}
+	return nil
+}
+
+// Normalize trim the names, default the sort name and drop aliases repeating the name or each other
+func (a *Author) Normalize() {
+	a.Name = strings.TrimSpace(a.Name)
+	a.SortName = strings.TrimSpace(a.SortName)
+	if a.SortName == "" {
+		a.SortName = DefaultSortName(a.Name)
+	}
+
+	aliases := []string{}
+	for _, alias := range a.Aliases {
+		alias = strings.TrimSpace(alias)
+		if alias != "" && !SameName(alias, a.Name) && !containsName(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	a.Aliases = aliases
+}
+
+// HasName check if name is the name or an alias of the author, case and accent insensitive
+func (a *Author) HasName(name string) bool {
+	return SameName(a.Name, name) || containsName(a.Aliases, name)
+}
+
+// DefaultSortName last word of the name first, "Mathieu Doyon" sorts as "Doyon, Mathieu"
+func DefaultSortName(name string) string {
+	words := strings.Fields(name)
+	if len(words) < 2 {
+		return strings.Join(words, " ")
+	}
+	return words[len(words)-1] + ", " + strings.Join(words[:len(words)-1], " ")
+}
+
+// SameName compare names the way authors are matched, case and accent insensitive
+func SameName(a string, b string) bool {
+	return CollationKey(strings.Join(strings.Fields(a), " ")) == CollationKey(strings.Join(strings.Fields(b), " "))
+}
+
+// SortAuthors sort authors by sort name then name
+func SortAuthors(authors []Author) {
+	sort.SliceStable(authors, func(i, j int) bool {
+		if c := CompareStrings(authors[i].SortName, authors[j].SortName); c != 0 {
+			return c < 0
+		}
+		return CompareStrings(authors[i].Name, authors[j].Name) < 0
+	})
+}
+
+// SplitAuthorNames split a free text author into the names it credits, "A & B" and "A; B" credit two authors
+func SplitAuthorNames(author string) []string {
+	names := []string{}
+	for _, part := range strings.FieldsFunc(author, func(r rune) bool { return r == '&' || r == ';' }) {
+		if name := strings.TrimSpace(part); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// JoinAuthorNames free text author of linked authors, names in link order
+func JoinAuthorNames(authors []AuthorName) string {
+	names := make([]string, len(authors))
+	for i, author := range authors {
+		names[i] = author.Name
+	}
+	return strings.Join(names, " & ")
+}
+
+// containsName check if names has name, case and accent insensitive
+func containsName(names []string, name string) bool {
+	for _, known := range names {
+		if SameName(known, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorRequest small hack to protect ID of being posted and update from body payload
+type AuthorRequest struct {
+	*Author
+
+	// Previous author state before the body was bound over it, nil on create
+	Previous *Author `json:"-"`
+
+	ProtectedID string `json:"_id"` // override '_id' json to have more control
+}
+
+// Bind bind body into author struct
+func (a *AuthorRequest) Bind(r *http.Request) error {
+	if a.Author == nil {
+		return errors.New("missing required Author fields.")
+	}
+
+	a.ProtectedID = ""
+
+	// timestamps are only changed by the repository
+	previous := a.Previous
+	if previous == nil {
+		previous = &Author{}
+	}
+	a.Author.ID = previous.ID
+	a.Author.CreatedAt = previous.CreatedAt
+	a.Author.UpdatedAt = previous.UpdatedAt
+
+	if err := a.Author.Validate(); err != nil {
+		return err
+	}
+	a.Author.Normalize()
+	return nil
+}
+
+// AuthorSyncReport result of writing the names of an author into its books again
+type AuthorSyncReport struct {
+	// Books number of books renamed, the others had the names already
+	Books int64 `json:"books"`
+}
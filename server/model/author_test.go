@@ -0,0 +1,49 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultSortName(t *testing.T) {
+	assert.Equal(t, "Doyon, Mathieu", DefaultSortName("Mathieu Doyon"))
+	assert.Equal(t, "Tolkien, J. R. R.", DefaultSortName(" J. R. R.  Tolkien "))
+	assert.Equal(t, "Homer", DefaultSortName("Homer"))
+}
+
+func TestAuthorNormalize(t *testing.T) {
+	author := &Author{Name: " Mathieu Doyon ", Aliases: []string{"M. Doyon", "mathieu doyon", " m. doyon", ""}}
+	author.Normalize()
+
+	assert.Equal(t, "Mathieu Doyon", author.Name)
+	assert.Equal(t, "Doyon, Mathieu", author.SortName)
+	assert.Equal(t, []string{"M. Doyon"}, author.Aliases, "It should drop aliases repeating the name or each other")
+
+	assert.True(t, author.HasName("MATHIEU  DOYON"))
+	assert.True(t, author.HasName("m. doyon"))
+	assert.False(t, author.HasName("Doyon"))
+}
+
+func TestAuthorValidate(t *testing.T) {
+	assert.NoError(t, (&Author{Name: "Émile Zola", BirthYear: 1840, DeathYear: 1902}).Validate())
+
+	err := (&Author{BirthYear: 1902, DeathYear: 1840, Aliases: []string{" "}}).Validate()
+	if assert.IsType(t, &Error{}, err) {
+		assert.Equal(t, KindValidation, err.(*Error).Kind)
+		assert.Equal(t, []FieldError{
+			{Field: "name", Reason: ReasonRequired, Message: "is required"},
+			{Field: "aliases", Reason: ReasonRequired, Message: "is required"},
+			{Field: "death_year", Reason: ReasonOutOfRange, Message: "can't be before birth_year"},
+		}, err.(*Error).Fields)
+	}
+}
+
+func TestSplitAuthorNames(t *testing.T) {
+	assert.Equal(t, []string{"Eric Cantona", "Mathieu Doyon"}, SplitAuthorNames("Eric Cantona & Mathieu Doyon"))
+	assert.Equal(t, []string{"Doyon, Mathieu", "Zola"}, SplitAuthorNames("Doyon, Mathieu; Zola;"))
+	assert.Empty(t, SplitAuthorNames("  "))
+
+	names := []AuthorName{{Name: "Eric Cantona"}, {Name: "Mathieu Doyon"}}
+	assert.Equal(t, "Eric Cantona & Mathieu Doyon", JoinAuthorNames(names))
+}
@@ -1,36 +1,294 @@
 package model
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"net/http"
+	"reflect"
+	"time"
 
 	"github.com/mongodb/mongo-go-driver/bson/objectid"
 )
 
 // Book book model structure
 type Book struct {
-	ID                objectid.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	Author            string            `bson:"author" json:"author"`
-	Genre             string            `bson:"genre" json:"genre"`
-	NumberOfPages     int64             `bson:"number_of_pages" json:"number_of_pages"`
-	YearOfPublication int64             `bson:"publication_year" json:"publication_year"`
-	Rating            int64             `bson:"rating" json:"rating"`
+	ID                objectid.ObjectID   `bson:"_id,omitempty" json:"_id,omitempty"`
+	Title             string              `bson:"title" json:"title"`
+	Subtitle          string              `bson:"subtitle" json:"subtitle"`
+	Author            string              `bson:"author" json:"author"`
+	AuthorIDs         []objectid.ObjectID `bson:"author_ids,omitempty" json:"author_ids,omitempty"`
+	Contributors      []Contributor       `bson:"contributors,omitempty" json:"contributors,omitempty"`
+	WorkID            objectid.ObjectID   `bson:"work_id,omitempty" json:"work_id,omitempty"`
+	Genre             string              `bson:"genre" json:"genre"`
+	Series            string              `bson:"series" json:"series"`
+	SeriesPosition    float64             `bson:"series_position" json:"series_position"`
+	ISBN10            string              `bson:"isbn_10,omitempty" json:"isbn_10,omitempty"`
+	ISBN13            string              `bson:"isbn_13,omitempty" json:"isbn_13,omitempty"`
+	Publisher         string              `bson:"publisher" json:"publisher"`
+	Language          string              `bson:"language" json:"language"`
+	Description       string              `bson:"description" json:"description"`
+	Edition           int64               `bson:"edition" json:"edition"`
+	NumberOfPages     int64               `bson:"number_of_pages" json:"number_of_pages"`
+	YearOfPublication int64               `bson:"publication_year" json:"publication_year"`
+	Rating            int64               `bson:"rating" json:"rating"`
+	Read              bool                `bson:"read" json:"read"`
+	Version           int64               `bson:"version" json:"version"`
+	CreatedAt         time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time           `bson:"updated_at" json:"updated_at"`
+	DeletedAt         *time.Time          `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+
+	// Authors names of the linked authors, embedded into responses and never stored
+	Authors []AuthorName `bson:"-" json:"authors,omitempty"`
+	// Editions number of editions of the work the book stands for, only set in lists collapsing editions
+	Editions int64 `bson:"-" json:"editions,omitempty"`
+}
+
+// bookJSON JSON form of a book without its methods
+type bookJSON Book
+
+// MarshalJSON encode a book without work id when it isn't an edition of a work
+func (b Book) MarshalJSON() ([]byte, error) {
+	data := struct {
+		bookJSON
+		WorkID string `json:"work_id,omitempty"`
+	}{bookJSON: bookJSON(b)}
+	if !b.WorkID.IsZero() {
+		data.WorkID = b.WorkID.Hex()
+	}
+	return json.Marshal(data)
+}
+
+// ManagedFields fields only written by the repository, never by clients. Editions are moved between works
+// by merging and splitting works
+var ManagedFields = []string{"_id", "work_id", "version", "created_at", "updated_at", "deleted_at"}
+
+// ErrVersionConflict returned when a book changed since the version a write was based on
+var ErrVersionConflict = &Error{Kind: KindConflict, Code: CodeVersionConflict, Message: "book was modified by another request"}
+
+// FieldValue return the value of a field by its bson name
+func (b *Book) FieldValue(field string) interface{} {
+	switch field {
+	case "_id":
+		return b.ID
+	case "title":
+		return b.Title
+	case "subtitle":
+		return b.Subtitle
+	case "author":
+		return b.Author
+	case "author_ids":
+		// an empty list is the same as no list
+		if len(b.AuthorIDs) == 0 {
+			return []objectid.ObjectID(nil)
+		}
+		return b.AuthorIDs
+	case "contributors":
+		// an empty list is the same as no list
+		if len(b.Contributors) == 0 {
+			return []Contributor(nil)
+		}
+		return b.Contributors
+	case "work_id":
+		return b.WorkID
+	case "genre":
+		return b.Genre
+	case "series":
+		return b.Series
+	case "series_position":
+		return b.SeriesPosition
+	case "isbn_10":
+		return b.ISBN10
+	case "isbn_13":
+		return b.ISBN13
+	case "publisher":
+		return b.Publisher
+	case "language":
+		return b.Language
+	case "description":
+		return b.Description
+	case "edition":
+		return b.Edition
+	case "number_of_pages":
+		return b.NumberOfPages
+	case "publication_year":
+		return b.YearOfPublication
+	case "rating":
+		return b.Rating
+	case "read":
+		return b.Read
+	case "version":
+		return b.Version
+	case "created_at":
+		return b.CreatedAt
+	case "updated_at":
+		return b.UpdatedAt
+	case "deleted_at":
+		if b.DeletedAt == nil {
+			return time.Time{}
+		}
+		return *b.DeletedAt
+	}
+	return nil
+}
+
+// CopyField copy the value of a field by its bson name from another book
+func (b *Book) CopyField(from *Book, field string) {
+	dst := reflect.ValueOf(b).Elem()
+	src := reflect.ValueOf(from).Elem()
+	for i := 0; i < dst.NumField(); i++ {
+		if bsonName(dst.Type().Field(i)) == field {
+			dst.Field(i).Set(src.Field(i))
+			return
+		}
+	}
+}
+
+// ChangedFields list bson names of the fields whose value differs between books,
+// managed fields are never listed
+func ChangedFields(before *Book, after *Book) []string {
+	var fields []string
+	for _, field := range FieldNames() {
+		if IsManaged(field) {
+			continue
+		}
+		if !reflect.DeepEqual(before.FieldValue(field), after.FieldValue(field)) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// KeepManagedFields copy managed fields from before, nil before resets them
+func (b *Book) KeepManagedFields(before *Book) {
+	if before == nil {
+		before = &Book{}
+	}
+	b.ID = before.ID
+	b.WorkID = before.WorkID
+	b.Version = before.Version
+	b.CreatedAt = before.CreatedAt
+	b.UpdatedAt = before.UpdatedAt
+	b.DeletedAt = before.DeletedAt
+}
+
+// IsManaged check if field is one of the ManagedFields
+func IsManaged(field string) bool {
+	for _, managed := range ManagedFields {
+		if field == managed {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAuthor check if the book links to the author, as author or as a contributor in any role
+func (b *Book) HasAuthor(ID objectid.ObjectID) bool {
+	for _, authorID := range b.AuthorIDs {
+		if bytes.Equal(authorID[:], ID[:]) {
+			return true
+		}
+	}
+	for _, contributor := range b.Contributors {
+		if bytes.Equal(contributor.AuthorID[:], ID[:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// DropStaleISBN clear the ISBN left unchanged since before when the other one changed,
+// Normalize derives it again from the changed one
+func (b *Book) DropStaleISBN(before *Book) {
+	if before == nil {
+		return
+	}
+
+	changed10 := b.ISBN10 != before.ISBN10
+	changed13 := b.ISBN13 != before.ISBN13
+	if changed13 && !changed10 {
+		b.ISBN10 = ""
+	}
+	if changed10 && !changed13 {
+		b.ISBN13 = ""
+	}
+}
+
+// Normalize check ISBN and language, ISBN-10 is converted and stored as ISBN-13, contributor roles are lower cased.
+//
+// Genres are checked against the genres tree by `BooksResource`, they aren't known here
+func (b *Book) Normalize() error {
+	isbn13 := ""
+	if b.ISBN13 != "" {
+		if err := ISBN(13)(b.ISBN13); err != nil {
+			err.Field = "isbn_13"
+			return Invalid(*err)
+		}
+		isbn13, _ = NormalizeISBN(b.ISBN13)
+	}
+	if b.ISBN10 != "" {
+		if err := ISBN(10)(b.ISBN10); err != nil {
+			err.Field = "isbn_10"
+			return Invalid(*err)
+		}
+		if err := matchingISBN(b); err != nil {
+			return Invalid(*err)
+		}
+		isbn13, _ = NormalizeISBN(b.ISBN10)
+	}
+	b.ISBN13 = isbn13
+	b.ISBN10 = ISBN10(isbn13)
+
+	if b.Language != "" {
+		if err := Language(b.Language); err != nil {
+			err.Field = "language"
+			return Invalid(*err)
+		}
+		b.Language, _ = NormalizeLanguage(b.Language)
+	}
+
+	b.normalizeContributors()
+
+	return nil
 }
 
 // BookFilter book filter model structure
 type BookFilter struct {
 	ID                objectid.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Title             string            `bson:"title" json:"title"`
+	Subtitle          string            `bson:"subtitle" json:"subtitle"`
 	Author            string            `bson:"author" json:"author"`
 	Genre             string            `bson:"genre" json:"genre"`
+	Series            string            `bson:"series" json:"series"`
+	ISBN13            string            `bson:"isbn_13" json:"isbn_13"`
+	Publisher         string            `bson:"publisher" json:"publisher"`
+	Language          string            `bson:"language" json:"language"`
+	Edition           int64             `bson:"edition" json:"edition"`
 	NumberOfPages     int64             `bson:"number_of_pages" json:"number_of_pages"`
 	YearOfPublication int64             `bson:"publication_year" json:"publication_year"`
 	Rating            int64             `bson:"rating" json:"rating"`
+
+	// Conditions operator filters, ex: `rating[gte]=4`
+	Conditions []FilterCondition `bson:"-" json:"-"`
+	// AuthorID books linked to this author, as author or as a contributor in any role
+	AuthorID objectid.ObjectID `bson:"-" json:"-"`
+	// Contributors books having each of these contributors, by role and name, ex: `translator=Jane Doe`
+	Contributors []Contributor `bson:"-" json:"-"`
+	// Genres books filed under any of these genres, `genre=Fiction` lists the subgenres of Fiction
+	Genres []string `bson:"-" json:"-"`
+	// WorkID books which are editions of this work
+	WorkID objectid.ObjectID `bson:"-" json:"-"`
+	// Trashed list trashed books instead of the others
+	Trashed bool `bson:"-" json:"-"`
 }
 
 // BookRequest small hack to protect ID of being posted and update from body payload
 type BookRequest struct {
 	*Book
 
+	// Previous book state before the body was bound over it, nil on create
+	Previous *Book `json:"-"`
+
 	ProtectedID string `json:"_id"` // override '_id' json to have more control
 }
 
@@ -48,6 +306,21 @@ func (b *BookRequest) Bind(r *http.Request) error {
 
 	// just a post-process after a decode..
 	b.ProtectedID = "" // unset the protected ID
+	// names and editions are embedded on responses
+	b.Authors = nil
+	b.Editions = 0
+
+	// version and timestamps are only changed by the repository
+	b.Book.KeepManagedFields(b.Previous)
+
+	b.Book.DropStaleISBN(b.Previous)
+	if err := b.Book.Validate(); err != nil {
+		return err
+	}
+	if err := b.Book.Normalize(); err != nil {
+		return err
+	}
+	b.Book.SyncContributors(b.Previous)
 	// b.Book.Title = strings.ToLower(b.Book.Title) // as an example, we down-case
 	return nil
 }
@@ -0,0 +1,275 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// Contributor roles
+const (
+	RoleAuthor      = "author"
+	RoleTranslator  = "translator"
+	RoleEditor      = "editor"
+	RoleIllustrator = "illustrator"
+	RoleNarrator    = "narrator"
+	RoleForeword    = "foreword"
+)
+
+// ContributorRoles every role a contributor can have
+var ContributorRoles = []string{RoleAuthor, RoleTranslator, RoleEditor, RoleIllustrator, RoleNarrator, RoleForeword}
+
+// Contributor person who contributed to a book in a role
+type Contributor struct {
+	// AuthorID author record of the person, its name replaces Name
+	AuthorID objectid.ObjectID `bson:"author_id,omitempty" json:"author_id,omitempty"`
+	Name     string            `bson:"name" json:"name"`
+	// Role one of the Role* constants
+	Role string `bson:"role" json:"role"`
+}
+
+// contributorJSON JSON form of a contributor, the author id is left out when unlinked
+type contributorJSON struct {
+	AuthorID string `json:"author_id,omitempty"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+}
+
+// MarshalJSON encode a contributor without author id when unlinked
+func (c Contributor) MarshalJSON() ([]byte, error) {
+	data := contributorJSON{Name: c.Name, Role: c.Role}
+	if c.IsLinked() {
+		data.AuthorID = c.AuthorID.Hex()
+	}
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON decode a contributor, an empty author id is unlinked
+func (c *Contributor) UnmarshalJSON(b []byte) error {
+	var data contributorJSON
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+
+	*c = Contributor{Name: data.Name, Role: data.Role}
+	if data.AuthorID != "" {
+		ID, err := objectid.FromHex(data.AuthorID)
+		if err != nil {
+			return fmt.Errorf("author_id %q is not an object id", data.AuthorID)
+		}
+		c.AuthorID = ID
+	}
+	return nil
+}
+
+// IsLinked check if the contributor links to an author record
+func (c *Contributor) IsLinked() bool {
+	return !c.AuthorID.IsZero()
+}
+
+// SamePerson check if both contributors are the same person, by author id when linked or by name
+func (c *Contributor) SamePerson(other *Contributor) bool {
+	if c.IsLinked() || other.IsLinked() {
+		return c.IsLinked() && other.IsLinked() && bytes.Equal(c.AuthorID[:], other.AuthorID[:])
+	}
+	return SameName(c.Name, other.Name)
+}
+
+// String text of a contributor as exported, `role:name` or `role:name:author id` when linked
+func (c Contributor) String() string {
+	text := c.Role + ":" + c.Name
+	if c.IsLinked() {
+		text += ":" + c.AuthorID.Hex()
+	}
+	return text
+}
+
+// ParseContributor parse the text of a contributor, see Contributor.String
+func ParseContributor(text string) (*Contributor, error) {
+	parts := strings.SplitN(text, ":", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("contributor %q isn't role:name", text)
+	}
+
+	contributor := &Contributor{Role: strings.TrimSpace(parts[0]), Name: strings.TrimSpace(parts[1])}
+	// names may have colons, only a trailing object id is a link
+	if i := strings.LastIndex(contributor.Name, ":"); i >= 0 {
+		if ID, err := objectid.FromHex(strings.TrimSpace(contributor.Name[i+1:])); err == nil {
+			contributor.AuthorID = ID
+			contributor.Name = strings.TrimSpace(contributor.Name[:i])
+		}
+	}
+	return contributor, nil
+}
+
+// FormatContributors text of contributors separated by `;`
+func FormatContributors(contributors []Contributor) string {
+	texts := make([]string, len(contributors))
+	for i, contributor := range contributors {
+		texts[i] = contributor.String()
+	}
+	return strings.Join(texts, ";")
+}
+
+// ParseContributors parse contributors separated by `;`, see FormatContributors
+func ParseContributors(text string) ([]Contributor, error) {
+	var contributors []Contributor
+	for _, part := range strings.Split(text, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		contributor, err := ParseContributor(part)
+		if err != nil {
+			return nil, err
+		}
+		contributors = append(contributors, *contributor)
+	}
+	return contributors, nil
+}
+
+// HasContributor check if the book has a contributor with this name in this role, books without contributors
+// have none
+func (b *Book) HasContributor(role string, name string) bool {
+	for _, contributor := range b.Contributors {
+		if contributor.Role == role && contributor.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ContributorList contributors of the book, books without contributors list their authors
+func (b *Book) ContributorList() []Contributor {
+	if len(b.Contributors) > 0 {
+		return b.Contributors
+	}
+	return b.authorContributors()
+}
+
+// ProjectContributors list the authors of a book without contributors as its contributors, for responses
+func (b *Book) ProjectContributors() {
+	b.Contributors = b.ContributorList()
+}
+
+// SyncContributors keep contributors and the author fields projecting the author contributors in step.
+//
+// Author fields changed since before by a client unaware of contributors replace the author contributors, as do
+// the author fields of books listing no author contributor. Otherwise the author fields are derived from contributors.
+// Contributors only repeating the author fields aren't kept, the book only has author fields like before contributors.
+func (b *Book) SyncContributors(before *Book) {
+	if len(b.Contributors) == 0 {
+		return
+	}
+
+	if b.authorsChanged(before) || len(withRole(b.Contributors, RoleAuthor)) == 0 {
+		contributors := b.authorContributors()
+		for _, contributor := range b.Contributors {
+			if contributor.Role != RoleAuthor {
+				contributors = append(contributors, contributor)
+			}
+		}
+		b.Contributors = contributors
+	}
+	b.ProjectAuthors()
+
+	if len(withRole(b.Contributors, RoleAuthor)) == len(b.Contributors) && sameContributors(b.Contributors, b.authorContributors()) {
+		b.Contributors = nil
+	}
+}
+
+// ProjectAuthors derive the author fields from the author contributors, names in contributors order
+func (b *Book) ProjectAuthors() {
+	var names []string
+	var IDs []objectid.ObjectID
+	for _, contributor := range withRole(b.Contributors, RoleAuthor) {
+		if contributor.Name != "" {
+			names = append(names, contributor.Name)
+		}
+		if contributor.IsLinked() {
+			IDs = append(IDs, contributor.AuthorID)
+		}
+	}
+	b.Author = strings.Join(names, " & ")
+	b.AuthorIDs = IDs
+}
+
+// authorsChanged check if only the author fields changed since before, contributors left as they were
+func (b *Book) authorsChanged(before *Book) bool {
+	if before == nil || len(before.Contributors) == 0 {
+		return false
+	}
+	if b.Author == before.Author && sameIDs(b.AuthorIDs, before.AuthorIDs) {
+		return false
+	}
+	return sameContributors(withRole(b.Contributors, RoleAuthor), withRole(before.Contributors, RoleAuthor))
+}
+
+// authorContributors author contributors projected from the author fields, linked when the book links to authors
+func (b *Book) authorContributors() []Contributor {
+	names := SplitAuthorNames(b.Author)
+	contributors := []Contributor{}
+	if len(b.AuthorIDs) == 0 {
+		for _, name := range names {
+			contributors = append(contributors, Contributor{Name: name, Role: RoleAuthor})
+		}
+		return contributors
+	}
+
+	for i, ID := range b.AuthorIDs {
+		contributor := Contributor{AuthorID: ID, Role: RoleAuthor}
+		// the author text has the names of the linked authors
+		if len(names) == len(b.AuthorIDs) {
+			contributor.Name = names[i]
+		}
+		contributors = append(contributors, contributor)
+	}
+	return contributors
+}
+
+// normalizeContributors trim names and lower case roles
+func (b *Book) normalizeContributors() {
+	for i := range b.Contributors {
+		b.Contributors[i].Name = strings.TrimSpace(b.Contributors[i].Name)
+		b.Contributors[i].Role = strings.ToLower(strings.TrimSpace(b.Contributors[i].Role))
+	}
+}
+
+// withRole contributors having role
+func withRole(contributors []Contributor, role string) []Contributor {
+	var found []Contributor
+	for _, contributor := range contributors {
+		if contributor.Role == role {
+			found = append(found, contributor)
+		}
+	}
+	return found
+}
+
+// sameContributors check if both lists have the same persons in the same roles and order
+func sameContributors(a []Contributor, b []Contributor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Role != b[i].Role || !a[i].SamePerson(&b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameIDs check if both lists have the same ids in the same order
+func sameIDs(a []objectid.ObjectID, b []objectid.ObjectID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i][:], b[i][:]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContributors(t *testing.T) {
+	ID := objectid.New()
+	contributors := []Contributor{
+		{Name: "Mathieu Doyon", Role: RoleAuthor},
+		{Name: "Jane: the translator", Role: RoleTranslator},
+		{AuthorID: ID, Name: "John Roe", Role: RoleNarrator},
+	}
+
+	text := FormatContributors(contributors)
+	assert.Equal(t, "author:Mathieu Doyon;translator:Jane: the translator;narrator:John Roe:"+ID.Hex(), text)
+
+	parsed, err := ParseContributors(text)
+	assert.NoError(t, err)
+	assert.Equal(t, contributors, parsed)
+
+	_, err = ParseContributors("Mathieu Doyon")
+	assert.Error(t, err)
+}
+
+func TestContributorJSON(t *testing.T) {
+	ID := objectid.New()
+	data, _ := json.Marshal([]Contributor{{Name: "Jane Roe", Role: RoleEditor}, {AuthorID: ID, Name: "John Roe", Role: RoleNarrator}})
+	assert.Equal(t, `[{"name":"Jane Roe","role":"editor"},{"author_id":"`+ID.Hex()+`","name":"John Roe","role":"narrator"}]`, string(data), "It should leave out unlinked author ids")
+
+	var contributors []Contributor
+	assert.NoError(t, json.Unmarshal(data, &contributors))
+	assert.Equal(t, ID, contributors[1].AuthorID)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"author_id":"doyon","role":"author"}`), &Contributor{}))
+}
+
+func TestValidContributors(t *testing.T) {
+	assert.Nil(t, ValidContributors([]Contributor{{Name: "Jane Roe", Role: "Translator"}, {AuthorID: objectid.New(), Role: RoleAuthor}}))
+
+	tests := []struct {
+		contributors []Contributor
+		reason       string
+	}{
+		{[]Contributor{{Name: "Jane Roe", Role: "colourist"}}, ReasonNotAllowed},
+		{[]Contributor{{Name: "Jane Roe"}}, ReasonNotAllowed},
+		{[]Contributor{{Name: " ", Role: RoleEditor}}, ReasonRequired},
+		{[]Contributor{{Name: "Jane Roe", Role: RoleEditor}, {Name: "jane roe", Role: RoleEditor}}, ReasonRepeated},
+	}
+	for _, tt := range tests {
+		err := ValidContributors(tt.contributors)
+		if assert.NotNil(t, err, "%v", tt.contributors) {
+			assert.Equal(t, tt.reason, err.Reason)
+		}
+	}
+
+	// the same person in two roles
+	assert.Nil(t, ValidContributors([]Contributor{{Name: "Jane Roe", Role: RoleAuthor}, {Name: "Jane Roe", Role: RoleIllustrator}}))
+}
+
+func TestSyncContributors(t *testing.T) {
+	doyon := objectid.New()
+
+	book := &Book{Author: "Eric Cantona", Contributors: []Contributor{
+		{AuthorID: doyon, Name: "Mathieu Doyon", Role: RoleAuthor},
+		{Name: "Jane Roe", Role: RoleTranslator},
+		{Name: "Eric Cantona", Role: RoleAuthor},
+	}}
+	book.SyncContributors(nil)
+	assert.Equal(t, "Mathieu Doyon & Eric Cantona", book.Author, "It should derive the author fields from contributors")
+	assert.Equal(t, []objectid.ObjectID{doyon}, book.AuthorIDs)
+	assert.Len(t, book.Contributors, 3)
+
+	before := *book
+	book.Author = "Mathieu Doyon"
+	book.AuthorIDs = nil
+	book.SyncContributors(&before)
+	assert.Equal(t, []Contributor{{Name: "Mathieu Doyon", Role: RoleAuthor}, {Name: "Jane Roe", Role: RoleTranslator}}, book.Contributors, "It should replace the authors changed by a client unaware of contributors")
+
+	book = &Book{Author: "Eric Cantona", Contributors: []Contributor{{Name: "Jane Roe", Role: RoleTranslator}}}
+	book.SyncContributors(nil)
+	assert.Equal(t, []Contributor{{Name: "Eric Cantona", Role: RoleAuthor}, {Name: "Jane Roe", Role: RoleTranslator}}, book.Contributors, "It should keep authors of books listing no author contributor")
+	assert.Equal(t, "Eric Cantona", book.Author)
+
+	book = &Book{Author: "Eric Cantona", Contributors: []Contributor{{AuthorID: doyon, Name: "Mathieu Doyon", Role: RoleAuthor}}}
+	book.SyncContributors(nil)
+	assert.Nil(t, book.Contributors, "It should only keep author fields for contributors repeating them")
+	assert.Equal(t, "Mathieu Doyon", book.Author)
+	assert.Equal(t, []objectid.ObjectID{doyon}, book.AuthorIDs)
+
+	book.ProjectContributors()
+	assert.Equal(t, []Contributor{{AuthorID: doyon, Name: "Mathieu Doyon", Role: RoleAuthor}}, book.Contributors, "It should list the authors of books without contributors")
+}
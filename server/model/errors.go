@@ -0,0 +1,107 @@
+package model
+
+import "strings"
+
+// ErrorKind category of an error, each kind is answered with its own HTTP status
+type ErrorKind int
+
+const (
+	// KindInternal unexpected error
+	KindInternal ErrorKind = iota
+	// KindNotFound the resource doesn't exist
+	KindNotFound
+	// KindInvalidID an id isn't a valid ObjectID
+	KindInvalidID
+	// KindConflict the write conflicts with the stored state
+	KindConflict
+	// KindUnavailable the storage can't be reached
+	KindUnavailable
+	// KindValidation a field value is invalid
+	KindValidation
+	// KindInvalidRequest a query parameter is invalid
+	KindInvalidRequest
+)
+
+// Error codes answered as problem details `code`, a code never changes meaning once released
+const (
+	CodeInternal             int64 = 1000
+	CodeUnavailable          int64 = 1001
+	CodeTimeout              int64 = 1002
+	CodeClientClosedRequest  int64 = 1003
+	CodeUndecodable          int64 = 1004
+	CodeInvalidRequest       int64 = 2000
+	CodeInvalidID            int64 = 2001
+	CodeUnsupportedMediaType int64 = 2002
+	CodePreconditionRequired int64 = 2003
+	CodeMalformedJSON        int64 = 2004
+	CodeRequestTooLarge      int64 = 2005
+	CodeInvalidCursor        int64 = 2006
+	CodeTooManyBooks         int64 = 2007
+	CodeValidation           int64 = 2100
+	CodeNotFound             int64 = 3000
+	CodeBookNotFound         int64 = 3001
+	CodeRevisionNotFound     int64 = 3002
+	CodeAuthorNotFound       int64 = 3003
+	CodeGenreNotFound        int64 = 3004
+	CodeSeriesNotFound       int64 = 3005
+	CodeNoUnreadBook         int64 = 3006
+	CodeWorkNotFound         int64 = 3007
+	CodeConflict             int64 = 4000
+	CodeVersionConflict      int64 = 4001
+	CodeDuplicateISBN        int64 = 4002
+	CodeAuthorInUse          int64 = 4003
+	CodeGenreInUse           int64 = 4004
+	CodeSeriesInUse          int64 = 4005
+	CodeWorkInUse            int64 = 4006
+)
+
+// Error typed error returned by repositories and model checks
+type Error struct {
+	Kind    ErrorKind
+	Code    int64
+	Message string
+	// Err underlying error, nil for sentinel errors
+	Err error
+	// Fields every invalid field of a validation error
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	if len(e.Fields) > 0 {
+		invalid := make([]string, len(e.Fields))
+		for i, field := range e.Fields {
+			invalid[i] = field.Field + " " + field.Message
+		}
+		return e.Message + ": " + strings.Join(invalid, ", ")
+	}
+	return e.Message
+}
+
+var (
+	// ErrNotFound returned when a book doesn't exist, or is trashed when looking for live books
+	ErrNotFound = &Error{Kind: KindNotFound, Code: CodeBookNotFound, Message: "book not found"}
+	// ErrInvalidID returned for ids which aren't 24 hexadecimal characters
+	ErrInvalidID = &Error{Kind: KindInvalidID, Code: CodeInvalidID, Message: "invalid id, expected 24 hexadecimal characters"}
+)
+
+// Unavailable wrap a storage error, the storage couldn't answer
+func Unavailable(err error) *Error {
+	return &Error{Kind: KindUnavailable, Code: CodeUnavailable, Message: "storage unavailable", Err: err}
+}
+
+// Undecodable error of a stored book which can't be read back, ID is empty when even the id is unreadable
+func Undecodable(ID string, err error) *Error {
+	message := "undecodable book"
+	if ID != "" {
+		message += " " + ID
+	}
+	return &Error{Kind: KindInternal, Code: CodeUndecodable, Message: message, Err: err}
+}
+
+// Invalid validation error listing invalid fields
+func Invalid(fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: CodeValidation, Message: "invalid book", Fields: fields}
+}
@@ -0,0 +1,214 @@
+package model
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// FieldType type of a book field as stored into database
+type FieldType int
+
+const (
+	// FieldString string field
+	FieldString FieldType = iota
+	// FieldInt integer field
+	FieldInt
+	// FieldObjectID mongo object id field
+	FieldObjectID
+	// FieldTime date and time field
+	FieldTime
+	// FieldObjectIDs list of mongo object ids field
+	FieldObjectIDs
+	// FieldContributors list of contributors field
+	FieldContributors
+	// FieldFloat decimal number field
+	FieldFloat
+	// FieldBool boolean field
+	FieldBool
+)
+
+// Filter operators accepted as query string suffix, ex: `rating[gte]=4`
+const (
+	OpEq      = "eq"
+	OpNe      = "ne"
+	OpGt      = "gt"
+	OpGte     = "gte"
+	OpLt      = "lt"
+	OpLte     = "lte"
+	OpIn      = "in"
+	OpNin     = "nin"
+	OpBetween = "between"
+)
+
+// BookFields book fields by bson name, derived from `Book` struct tags
+var BookFields = bookFields()
+
+// BookFieldOrder bson names of every book field, in `Book` struct order
+var BookFieldOrder = bookFieldOrder()
+
+// FieldNames bson names of every book field, sorted
+func FieldNames() []string {
+	names := make([]string, 0, len(BookFields))
+	for name := range BookFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// FilterCondition single operator condition on a book field
+type FilterCondition struct {
+	// Field bson name of the field
+	Field string
+	// Operator one of the Op* constants
+	Operator string
+	// Value typed value, a slice for in, nin and between operators
+	Value interface{}
+}
+
+// NewFilterCondition parse raw query string value into a typed condition
+func NewFilterCondition(field string, operator string, raw string) (*FilterCondition, error) {
+	fieldType, ok := BookFields[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+	if fieldType == FieldObjectIDs || fieldType == FieldContributors {
+		return nil, fmt.Errorf("field %q can't be filtered with operators", field)
+	}
+
+	condition := &FilterCondition{Field: field, Operator: operator}
+	switch operator {
+	case OpEq, OpNe, OpGt, OpGte, OpLt, OpLte:
+		value, err := ParseFieldValue(fieldType, raw)
+		if err != nil {
+			return nil, err
+		}
+		condition.Value = value
+	case OpIn, OpNin, OpBetween:
+		parts := strings.Split(raw, ",")
+		if operator == OpBetween && len(parts) != 2 {
+			return nil, fmt.Errorf("between expects 2 comma separated values")
+		}
+		values := make([]interface{}, 0, len(parts))
+		for _, part := range parts {
+			value, err := ParseFieldValue(fieldType, strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		condition.Value = values
+	default:
+		return nil, fmt.Errorf("unknown operator %q", operator)
+	}
+
+	return condition, nil
+}
+
+// ParseFieldValue convert a raw string into the go type of a book field
+func ParseFieldValue(fieldType FieldType, raw string) (interface{}, error) {
+	switch fieldType {
+	case FieldInt:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", raw)
+		}
+		return n, nil
+	case FieldFloat:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return f, nil
+	case FieldBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", raw)
+		}
+		return b, nil
+	case FieldObjectID:
+		id, err := objectid.FromHex(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an object id", raw)
+		}
+		return id, nil
+	case FieldTime:
+		return ParseTime(raw)
+	}
+	return raw, nil
+}
+
+// ParseTime parse a RFC 3339 date time or a `YYYY-MM-DD` date as UTC
+func ParseTime(raw string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t.UTC(), nil
+	}
+	if t, err := time.Parse("2006-01-02", raw); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a RFC 3339 date time", raw)
+}
+
+// bookFields list book fields and their type using bson struct tags
+func bookFields() map[string]FieldType {
+	fields := map[string]FieldType{}
+
+	t := reflect.TypeOf(Book{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := bsonName(f)
+		if name == "" {
+			continue
+		}
+
+		switch {
+		case f.Type == reflect.TypeOf(objectid.ObjectID{}):
+			fields[name] = FieldObjectID
+		case f.Type == reflect.TypeOf([]objectid.ObjectID{}):
+			fields[name] = FieldObjectIDs
+		case f.Type == reflect.TypeOf([]Contributor{}):
+			fields[name] = FieldContributors
+		case f.Type == reflect.TypeOf(time.Time{}), f.Type == reflect.TypeOf(&time.Time{}):
+			fields[name] = FieldTime
+		case f.Type.Kind() == reflect.Int64:
+			fields[name] = FieldInt
+		case f.Type.Kind() == reflect.Float64:
+			fields[name] = FieldFloat
+		case f.Type.Kind() == reflect.Bool:
+			fields[name] = FieldBool
+		case f.Type.Kind() == reflect.String:
+			fields[name] = FieldString
+		}
+	}
+
+	return fields
+}
+
+// bookFieldOrder list bson names of book fields in struct order
+func bookFieldOrder() []string {
+	var names []string
+
+	t := reflect.TypeOf(Book{})
+	for i := 0; i < t.NumField(); i++ {
+		if name := bsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// bsonName bson name of a struct field, empty when it isn't stored
+func bsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("bson"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
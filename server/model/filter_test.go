@@ -0,0 +1,54 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFilterCondition(t *testing.T) {
+	condition, err := NewFilterCondition("rating", OpGte, "4")
+
+	assert.Nil(t, err)
+	assert.Equal(t, &FilterCondition{Field: "rating", Operator: OpGte, Value: int64(4)}, condition)
+
+	condition, err = NewFilterCondition("genre", OpIn, "Fiction, Fantasy")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"Fiction", "Fantasy"}, condition.Value)
+
+	condition, err = NewFilterCondition("publication_year", OpBetween, "1990,2000")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{int64(1990), int64(2000)}, condition.Value)
+}
+
+func TestNewFilterConditionInvalid(t *testing.T) {
+	_, err := NewFilterCondition("ratting", OpGte, "4")
+	assert.NotNil(t, err, "It should reject unknown fields")
+
+	_, err = NewFilterCondition("rating", "like", "4")
+	assert.NotNil(t, err, "It should reject unknown operators")
+
+	_, err = NewFilterCondition("rating", OpGte, "four")
+	assert.NotNil(t, err, "It should reject values of the wrong type")
+
+	_, err = NewFilterCondition("rating", OpBetween, "1,2,3")
+	assert.NotNil(t, err, "It should reject between without 2 bounds")
+}
+
+func TestNewFilterConditionTime(t *testing.T) {
+	condition, err := NewFilterCondition("updated_at", OpGt, "2018-10-01T12:30:00+02:00")
+
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2018, 10, 1, 10, 30, 0, 0, time.UTC), condition.Value)
+
+	condition, err = NewFilterCondition("created_at", OpBetween, "2018-01-01,2018-12-31")
+
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 12, 31, 0, 0, 0, 0, time.UTC)}, condition.Value)
+
+	_, err = NewFilterCondition("updated_at", OpGt, "yesterday")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,382 @@
+package model
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// ErrGenreNotFound returned when a genre doesn't exist
+var ErrGenreNotFound = &Error{Kind: KindNotFound, Code: CodeGenreNotFound, Message: "genre not found"}
+
+// ErrGenreInUse returned when deleting a genre books or subgenres are filed under
+var ErrGenreInUse = &Error{Kind: KindConflict, Code: CodeGenreInUse, Message: "genre has books or subgenres"}
+
+// Genre genre model structure, books are filed under a genre by its name
+type Genre struct {
+	ID objectid.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	// Name canonical name books are filed under
+	Name string `bson:"name" json:"name"`
+	// Parent name of the parent genre, empty for top level genres
+	Parent string `bson:"parent" json:"parent"`
+	// Synonyms other spellings normalized to the name, ex: "Sci-Fi"
+	Synonyms  []string  `bson:"synonyms" json:"synonyms"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// DefaultGenres genres created when there is none
+var DefaultGenres = []Genre{
+	{Name: "Fiction", Synonyms: []string{"Novel", "Novels"}},
+	{Name: "Fantasy", Parent: "Fiction"},
+	{Name: "Science Fiction", Parent: "Fiction", Synonyms: []string{"Sci-Fi", "Sci Fi", "SF"}},
+	{Name: "Mystery", Parent: "Fiction", Synonyms: []string{"Crime", "Detective"}},
+	{Name: "Thriller", Parent: "Fiction", Synonyms: []string{"Suspense"}},
+	{Name: "Horror", Parent: "Fiction"},
+	{Name: "Romance", Parent: "Fiction"},
+	{Name: "Historical Fiction", Parent: "Fiction"},
+	{Name: "Non-Fiction", Synonyms: []string{"Nonfiction", "Non Fiction"}},
+	{Name: "Biography", Parent: "Non-Fiction", Synonyms: []string{"Autobiography", "Memoir"}},
+	{Name: "History", Parent: "Non-Fiction"},
+	{Name: "Science", Parent: "Non-Fiction"},
+	{Name: "Philosophy", Parent: "Non-Fiction"},
+	{Name: "Essay", Parent: "Non-Fiction", Synonyms: []string{"Essays"}},
+	{Name: "Travel", Parent: "Non-Fiction"},
+	{Name: "Cooking", Parent: "Non-Fiction", Synonyms: []string{"Cookbook", "Cookbooks"}},
+	{Name: "Art", Parent: "Non-Fiction"},
+	{Name: "Self-Help", Parent: "Non-Fiction", Synonyms: []string{"Self Help"}},
+	{Name: "Reference", Parent: "Non-Fiction"},
+	{Name: "Poetry"},
+	{Name: "Drama", Synonyms: []string{"Plays", "Theatre", "Theater"}},
+	{Name: "Comics", Synonyms: []string{"Graphic Novel", "Graphic Novels", "Manga"}},
+	{Name: "Children", Synonyms: []string{"Kids"}},
+	{Name: "Young Adult", Synonyms: []string{"YA"}},
+}
+
+// Validate check every field of a genre, all the invalid fields are returned in a single error
+func (g *Genre) Validate() error {
+	var fields []FieldError
+	check := func(field string, value interface{}, rules ...Rule) {
+		for _, rule := range rules {
+			if err := rule(value); err != nil {
+				err.Field = field
+				fields = append(fields, *err)
+				return
+			}
+		}
+	}
+
+	check("name", g.Name, Required, Length(1, 100))
+	check("parent", g.Parent, Length(1, 100))
+	for _, synonym := range g.Synonyms {
+		check("synonyms", synonym, Required, Length(1, 100))
+	}
+
+	if len(fields) > 0 {
+		return &Error{Kind: KindValidation, Code: CodeValidation, Message: "invalid genre", Fields: fields}
+	}
+	return nil
+}
+
+// Normalize trim the names and drop synonyms repeating the name or each other
+func (g *Genre) Normalize() {
+	g.Name = strings.TrimSpace(g.Name)
+	g.Parent = strings.TrimSpace(g.Parent)
+
+	synonyms := []string{}
+	for _, synonym := range g.Synonyms {
+		synonym = strings.TrimSpace(synonym)
+		if synonym != "" && !SameName(synonym, g.Name) && !containsName(synonyms, synonym) {
+			synonyms = append(synonyms, synonym)
+		}
+	}
+	g.Synonyms = synonyms
+}
+
+// HasName check if name is the name or a synonym of the genre, case and accent insensitive
+func (g *Genre) HasName(name string) bool {
+	return SameName(g.Name, name) || containsName(g.Synonyms, name)
+}
+
+// SortGenres sort genres by name
+func SortGenres(genres []Genre) {
+	sort.SliceStable(genres, func(i, j int) bool {
+		return CompareStrings(genres[i].Name, genres[j].Name) < 0
+	})
+}
+
+// GenreNode genre with its subgenres, a node of the genres tree
+type GenreNode struct {
+	Genre
+	Children []GenreNode `json:"children"`
+}
+
+// GenreUsage genre text books are filed under and how many books have it
+type GenreUsage struct {
+	Genre string `json:"genre"`
+	Books int64  `json:"books"`
+	// Canonical genre the text normalizes to, empty when it matches no genre
+	Canonical string `json:"canonical,omitempty"`
+}
+
+// Taxonomy genres tree, genres are found by name or synonym, case and accent insensitive
+type Taxonomy struct {
+	genres []Genre
+	// byKey genre index by collation key of its name and synonyms
+	byKey map[string]int
+	// children genre indexes by collation key of the parent name
+	children map[string][]int
+}
+
+// NewTaxonomy build the tree of genres, genres are linked to their parent by name
+func NewTaxonomy(genres []Genre) *Taxonomy {
+	t := &Taxonomy{
+		genres:   append([]Genre(nil), genres...),
+		byKey:    map[string]int{},
+		children: map[string][]int{},
+	}
+	SortGenres(t.genres)
+
+	for i, genre := range t.genres {
+		t.byKey[genreKey(genre.Name)] = i
+	}
+	for i, genre := range t.genres {
+		for _, synonym := range genre.Synonyms {
+			// a name wins over a synonym of another genre
+			if _, ok := t.byKey[genreKey(synonym)]; !ok {
+				t.byKey[genreKey(synonym)] = i
+			}
+		}
+		if genre.Parent != "" {
+			t.children[genreKey(genre.Parent)] = append(t.children[genreKey(genre.Parent)], i)
+		}
+	}
+
+	return t
+}
+
+// Find find the genre having name as name or synonym, nil when unknown
+func (t *Taxonomy) Find(name string) *Genre {
+	i, ok := t.byKey[genreKey(name)]
+	if !ok {
+		return nil
+	}
+	return &t.genres[i]
+}
+
+// Canonical canonical name of the genre having name as name or synonym, empty when unknown
+func (t *Taxonomy) Canonical(name string) string {
+	if genre := t.Find(name); genre != nil {
+		return genre.Name
+	}
+	return ""
+}
+
+// Subtree canonical names of the genre having name and of all its subgenres, nil when unknown
+func (t *Taxonomy) Subtree(name string) []string {
+	genre := t.Find(name)
+	if genre == nil {
+		return nil
+	}
+
+	names := []string{}
+	seen := map[string]bool{}
+	var walk func(name string)
+	walk = func(name string) {
+		// a stored cycle can't loop forever
+		if seen[genreKey(name)] {
+			return
+		}
+		seen[genreKey(name)] = true
+		names = append(names, name)
+		for _, i := range t.children[genreKey(name)] {
+			walk(t.genres[i].Name)
+		}
+	}
+	walk(genre.Name)
+
+	return names
+}
+
+// Children subgenres of the genre named name, sorted by name
+func (t *Taxonomy) Children(name string) []Genre {
+	children := []Genre{}
+	for _, i := range t.children[genreKey(name)] {
+		children = append(children, t.genres[i])
+	}
+	return children
+}
+
+// Tree top level genres with their subgenres, sorted by name. Genres whose parent doesn't exist are top level
+func (t *Taxonomy) Tree() []GenreNode {
+	var node func(genre Genre, seen map[string]bool) GenreNode
+	node = func(genre Genre, seen map[string]bool) GenreNode {
+		seen[genreKey(genre.Name)] = true
+		n := GenreNode{Genre: genre, Children: []GenreNode{}}
+		for _, child := range t.Children(genre.Name) {
+			if !seen[genreKey(child.Name)] {
+				n.Children = append(n.Children, node(child, seen))
+			}
+		}
+		return n
+	}
+
+	roots := []GenreNode{}
+	for _, genre := range t.genres {
+		if genre.Parent == "" || t.Find(genre.Parent) == nil {
+			roots = append(roots, node(genre, map[string]bool{}))
+		}
+	}
+	return roots
+}
+
+// Check check a genre against the other genres of the tree: names and synonyms are used by a single genre,
+// the parent exists and the genre isn't its own ancestor. Genres are matched to the stored ones by id
+func (t *Taxonomy) Check(genre *Genre) error {
+	var fields []FieldError
+	other := func(name string) *Genre {
+		for i := range t.genres {
+			if t.genres[i].ID != genre.ID && t.genres[i].HasName(name) {
+				return &t.genres[i]
+			}
+		}
+		return nil
+	}
+
+	if found := other(genre.Name); found != nil {
+		fields = append(fields, FieldError{Field: "name", Reason: ReasonRepeated, Message: "is already used by genre " + found.Name})
+	}
+	for _, synonym := range genre.Synonyms {
+		if found := other(synonym); found != nil {
+			fields = append(fields, FieldError{Field: "synonyms", Reason: ReasonRepeated, Message: synonym + " is already used by genre " + found.Name})
+			break
+		}
+	}
+
+	if genre.Parent != "" {
+		parent := other(genre.Parent)
+		switch {
+		case parent == nil && SameName(genre.Parent, genre.Name):
+			fields = append(fields, FieldError{Field: "parent", Reason: ReasonNotAllowed, Message: "can't be the genre itself"})
+		case parent == nil:
+			fields = append(fields, FieldError{Field: "parent", Reason: ReasonUnknown, Message: "names no genre"})
+		case t.isDescendant(parent.Name, genre):
+			fields = append(fields, FieldError{Field: "parent", Reason: ReasonNotAllowed, Message: "can't be a subgenre of the genre"})
+		default:
+			genre.Parent = parent.Name
+		}
+	}
+
+	if len(fields) > 0 {
+		return Invalid(fields...)
+	}
+	return nil
+}
+
+// isDescendant check if the genre named name is under genre in the tree, the stored genre is looked up by id
+func (t *Taxonomy) isDescendant(name string, genre *Genre) bool {
+	if genre.ID.IsZero() {
+		return false
+	}
+	for _, stored := range t.genres {
+		if stored.ID == genre.ID {
+			for _, descendant := range t.Subtree(stored.Name) {
+				if SameName(descendant, name) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// genreKey key genres are matched by, case and accent insensitive
+func genreKey(name string) string {
+	return CollationKey(strings.Join(strings.Fields(name), " "))
+}
+
+// GenreRequest small hack to protect ID of being posted and update from body payload
+type GenreRequest struct {
+	*Genre
+
+	// Previous genre state before the body was bound over it, nil on create
+	Previous *Genre `json:"-"`
+
+	ProtectedID string `json:"_id"` // override '_id' json to have more control
+}
+
+// Bind bind body into genre struct
+func (g *GenreRequest) Bind(r *http.Request) error {
+	if g.Genre == nil {
+		return errors.New("missing required Genre fields.")
+	}
+
+	g.ProtectedID = ""
+
+	// timestamps are only changed by the repository
+	previous := g.Previous
+	if previous == nil {
+		previous = &Genre{}
+	}
+	g.Genre.ID = previous.ID
+	g.Genre.CreatedAt = previous.CreatedAt
+	g.Genre.UpdatedAt = previous.UpdatedAt
+
+	if err := g.Genre.Validate(); err != nil {
+		return err
+	}
+	g.Genre.Normalize()
+	return nil
+}
+
+// GenreRemapRequest genre texts of books mapped to the genres books are refiled under, by text
+type GenreRemapRequest struct {
+	// Mappings genre by genre text, an empty genre is the genre the text is a synonym of
+	Mappings map[string]string `json:"mappings"`
+}
+
+// Bind check there is something to remap
+func (g *GenreRemapRequest) Bind(r *http.Request) error {
+	if len(g.Mappings) == 0 {
+		return Invalid(FieldError{Field: "mappings", Reason: ReasonRequired, Message: "is required"})
+	}
+	return nil
+}
+
+// Resolve spell the mapped genres as genres of the tree, sorted by genre text. Texts mapped to no genre are all
+// reported in a single error
+func (g *GenreRemapRequest) Resolve(taxonomy *Taxonomy) ([]GenreUsage, error) {
+	var mappings []GenreUsage
+	var fields []FieldError
+	for from, to := range g.Mappings {
+		if to == "" {
+			to = from
+		}
+		canonical := taxonomy.Canonical(to)
+		if canonical == "" {
+			fields = append(fields, FieldError{Field: "mappings", Reason: ReasonUnknown, Message: from + " maps to no genre"})
+			continue
+		}
+		mappings = append(mappings, GenreUsage{Genre: from, Canonical: canonical})
+	}
+
+	sort.Slice(mappings, func(i, j int) bool { return mappings[i].Genre < mappings[j].Genre })
+	if len(fields) > 0 {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Message < fields[j].Message })
+		return nil, Invalid(fields...)
+	}
+	return mappings, nil
+}
+
+// GenreRemapReport result of a genres remap
+type GenreRemapReport struct {
+	DryRun bool `json:"dry_run"`
+	// Books number of books refiled, or which would be on a dry run
+	Books int64 `json:"books"`
+	// Genres refiled genre texts with their books and the genre they are filed under
+	Genres []GenreUsage `json:"genres"`
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testTaxonomy() *Taxonomy {
+	genres := append([]Genre(nil), DefaultGenres...)
+	for i := range genres {
+		genres[i].ID = objectid.New()
+	}
+	genres = append(genres, Genre{ID: objectid.New(), Name: "Space Opera", Parent: "Science Fiction"})
+	return NewTaxonomy(genres)
+}
+
+func TestTaxonomyCanonical(t *testing.T) {
+	taxonomy := testTaxonomy()
+
+	assert.Equal(t, "Science Fiction", taxonomy.Canonical("science FICTION"))
+	assert.Equal(t, "Science Fiction", taxonomy.Canonical(" sci-fi"), "It should normalize synonyms")
+	assert.Equal(t, "Non-Fiction", taxonomy.Canonical("nonfiction"))
+	assert.Equal(t, "", taxonomy.Canonical("SCI FI & FANTASY"))
+	assert.Nil(t, taxonomy.Find("Fiction--"))
+}
+
+func TestTaxonomySubtree(t *testing.T) {
+	taxonomy := testTaxonomy()
+
+	assert.Equal(t, []string{"Science Fiction", "Space Opera"}, taxonomy.Subtree("SF"))
+	assert.Contains(t, taxonomy.Subtree("Fiction"), "Space Opera", "It should list every descendant")
+	assert.NotContains(t, taxonomy.Subtree("Fiction"), "Biography")
+	assert.Nil(t, taxonomy.Subtree("Fiction--"))
+
+	tree := taxonomy.Tree()
+	assert.Len(t, tree, 7)
+	assert.Equal(t, "Children", tree[0].Name)
+	for _, node := range tree {
+		if node.Name == "Fiction" {
+			assert.Len(t, node.Children, 7)
+		}
+	}
+}
+
+func TestTaxonomyCheck(t *testing.T) {
+	taxonomy := testTaxonomy()
+
+	genre := &Genre{Name: "Cyberpunk", Parent: "sci fi", Synonyms: []string{"Cyber Punk"}}
+	assert.NoError(t, taxonomy.Check(genre))
+	assert.Equal(t, "Science Fiction", genre.Parent, "It should spell the parent as its genre")
+
+	err := taxonomy.Check(&Genre{Name: "Sci-Fi", Parent: "Pulp", Synonyms: []string{"Kids"}})
+	if assert.IsType(t, &Error{}, err) {
+		assert.Equal(t, []FieldError{
+			{Field: "name", Reason: ReasonRepeated, Message: "is already used by genre Science Fiction"},
+			{Field: "synonyms", Reason: ReasonRepeated, Message: "Kids is already used by genre Children"},
+			{Field: "parent", Reason: ReasonUnknown, Message: "names no genre"},
+		}, err.(*Error).Fields)
+	}
+
+	fiction := *taxonomy.Find("Fiction")
+	fiction.Parent = "Space Opera"
+	err = taxonomy.Check(&fiction)
+	if assert.IsType(t, &Error{}, err) {
+		assert.Equal(t, ReasonNotAllowed, err.(*Error).Fields[0].Reason, "It should refuse cycles")
+	}
+}
+
+func TestGenreNormalize(t *testing.T) {
+	genre := &Genre{Name: " Science Fiction ", Synonyms: []string{"SF", "science fiction", " sf", ""}}
+	genre.Normalize()
+
+	assert.Equal(t, "Science Fiction", genre.Name)
+	assert.Equal(t, []string{"SF"}, genre.Synonyms)
+
+	err := (&Genre{Synonyms: []string{" "}}).Validate()
+	if assert.IsType(t, &Error{}, err) {
+		assert.Len(t, err.(*Error).Fields, 2)
+	}
+}
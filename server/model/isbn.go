@@ -0,0 +1,98 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidISBN returned when an ISBN has a wrong length, character or checksum
+	ErrInvalidISBN = errors.New("invalid ISBN")
+	// ErrDuplicateISBN returned when another book already has the same ISBN
+	ErrDuplicateISBN = &Error{Kind: KindConflict, Code: CodeDuplicateISBN, Message: "a book with this ISBN already exists"}
+)
+
+// NormalizeISBN validate an ISBN-10 or ISBN-13 checksum and return it as ISBN-13
+func NormalizeISBN(isbn string) (string, error) {
+	clean := cleanISBN(isbn)
+
+	switch len(clean) {
+	case 10:
+		if !validISBN10(clean) {
+			return "", ErrInvalidISBN
+		}
+		isbn13 := "978" + clean[:9]
+		return isbn13 + isbn13CheckDigit(isbn13), nil
+	case 13:
+		if !validISBN13(clean) {
+			return "", ErrInvalidISBN
+		}
+		return clean, nil
+	}
+
+	return "", ErrInvalidISBN
+}
+
+// ISBN10 convert an ISBN-13 to ISBN-10, empty when it has no ISBN-10 equivalent
+func ISBN10(isbn13 string) string {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return ""
+	}
+
+	body := isbn13[3:12]
+	sum := 0
+	for i, c := range body {
+		sum += (10 - i) * int(c-'0')
+	}
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return body + "X"
+	}
+	return body + string(rune('0'+check))
+}
+
+// validISBN10 check ISBN-10 characters and checksum, last character can be X
+func validISBN10(isbn string) bool {
+	sum := 0
+	for i, c := range isbn {
+		var digit int
+		switch {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case c == 'X' && i == 9:
+			digit = 10
+		default:
+			return false
+		}
+		sum += (10 - i) * digit
+	}
+	return sum%11 == 0
+}
+
+// validISBN13 check ISBN-13 characters and checksum
+func validISBN13(isbn string) bool {
+	for _, c := range isbn {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return isbn13CheckDigit(isbn[:12]) == isbn[12:]
+}
+
+// isbn13CheckDigit compute the check digit of the first 12 digits of an ISBN-13
+func isbn13CheckDigit(body string) string {
+	sum := 0
+	for i, c := range body {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(c-'0')
+	}
+	return string(rune('0' + (10-sum%10)%10))
+}
+
+// cleanISBN remove ISBN separators
+func cleanISBN(isbn string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+}
@@ -0,0 +1,51 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	isbn, err := NormalizeISBN("0-306-40615-2")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "9780306406157", isbn, "It should convert ISBN-10 to ISBN-13")
+
+	isbn, err = NormalizeISBN("978-0-306-40615-7")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "9780306406157", isbn)
+
+	isbn, err = NormalizeISBN("080442957x")
+
+	assert.Nil(t, err)
+	assert.Equal(t, "9780804429573", isbn, "It should accept X check digit")
+}
+
+func TestNormalizeISBNInvalid(t *testing.T) {
+	for _, isbn := range []string{"0-306-40615-3", "978-0-306-40615-8", "12345", "X306406152"} {
+		_, err := NormalizeISBN(isbn)
+
+		assert.Equal(t, ErrInvalidISBN, err, isbn)
+	}
+}
+
+func TestISBN10(t *testing.T) {
+	assert.Equal(t, "0306406152", ISBN10("9780306406157"))
+	assert.Equal(t, "080442957X", ISBN10("9780804429573"))
+	assert.Equal(t, "", ISBN10("9791034304809"), "It should ignore 979 prefix")
+}
+
+func TestBookNormalize(t *testing.T) {
+	book := &Book{ISBN10: "0-306-40615-2", Language: "FR"}
+
+	assert.Nil(t, book.Normalize())
+	assert.Equal(t, "9780306406157", book.ISBN13)
+	assert.Equal(t, "0306406152", book.ISBN10)
+	assert.Equal(t, "fr", book.Language)
+
+	assert.NotNil(t, (&Book{ISBN10: "9780306406157"}).Normalize(), "It should reject ISBN-13 as ISBN-10")
+	assert.NotNil(t, (&Book{ISBN10: "0306406152", ISBN13: "9780804429573"}).Normalize(), "It should reject mismatching ISBN")
+	assert.NotNil(t, (&Book{Language: "french"}).Normalize())
+}
@@ -0,0 +1,206 @@
+package model
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+const (
+	// DefaultLimit number of books returned when no limit is requested
+	DefaultLimit int64 = 10
+	// MaxLimit maximum number of books a client can request at once
+	MaxLimit int64 = 100
+)
+
+// ErrInvalidCursor returned when a cursor can't be decoded or doesn't match the active sorting
+var ErrInvalidCursor = &Error{Kind: KindInvalidRequest, Code: CodeInvalidCursor, Message: "invalid cursor"}
+
+// Pagination pagination structure
+type Pagination struct {
+	// Limit maximum number of books to return
+	Limit int64
+	// Offset number of books to skip, ignored when a cursor is set
+	Offset int64
+	// Cursor keyset position to start from
+	Cursor *Cursor
+}
+
+// Cursor keyset position inside a sorted list of books
+type Cursor struct {
+	// Sort field the cursor was built for
+	Sort string `json:"s"`
+	// Direction sort direction the cursor was built for
+	Direction int32 `json:"d"`
+	// Value value of the sort field at the cursor position
+	Value interface{} `json:"v,omitempty"`
+	// ThenKeys following sort keys the cursor was built for, as written in the `sort` query parameter
+	ThenKeys string `json:"k,omitempty"`
+	// ThenValues values of the following sort keys at the cursor position
+	ThenValues []interface{} `json:"t,omitempty"`
+	// IgnoreArticles true when titles were sorted without their article
+	IgnoreArticles bool `json:"a,omitempty"`
+	// ID id of the book at the cursor position, used as tie breaker
+	ID objectid.ObjectID `json:"id"`
+	// Backward true when the cursor points to the previous page
+	Backward bool `json:"b,omitempty"`
+}
+
+// BookPage page of books with pagination metadata
+type BookPage struct {
+	Books      []Book `json:"data"`
+	Total      int64  `json:"total"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	// Warnings books which couldn't be read and were left out of the page
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// NewCursor build a cursor positioned on book for the given sorting
+func NewCursor(book *Book, sorting *Sorting, backward bool) *Cursor {
+	cursor := &Cursor{
+		Sort:           sorting.Sort,
+		Direction:      sorting.Direction,
+		ThenKeys:       keysString(sorting.Then),
+		ID:             book.ID,
+		Backward:       backward,
+		IgnoreArticles: sorting.IgnoreArticles,
+	}
+	if sorting.Sort != "_id" {
+		cursor.Value = sorting.Value(book, sorting.Sort)
+	}
+	for _, key := range sorting.Then {
+		var value interface{}
+		if key.Field != "_id" {
+			value = sorting.Value(book, key.Field)
+		}
+		cursor.ThenValues = append(cursor.ThenValues, value)
+	}
+	return cursor
+}
+
+// Values values of every sort key at the cursor position, nil for `_id` which is the cursor ID
+func (c *Cursor) Values() []interface{} {
+	return append([]interface{}{c.Value}, c.ThenValues...)
+}
+
+// Encode encode cursor into an opaque url safe string
+func (c *Cursor) Encode() string {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// PageBooks page of books sorted as a whole in memory, cursors are positioned by the id of their book
+func PageBooks(books []Book, sorting *Sorting, pagination *Pagination) (*BookPage, error) {
+	limit := DefaultLimit
+	if pagination.Limit > 0 {
+		limit = pagination.Limit
+	}
+
+	total := int64(len(books))
+	start := pagination.Offset
+	if start > total {
+		start = total
+	}
+	end := start + limit
+	if cursor := pagination.Cursor; cursor != nil {
+		at := int64(-1)
+		for i := range books {
+			if books[i].ID == cursor.ID {
+				at = int64(i)
+				break
+			}
+		}
+		if at < 0 {
+			return nil, ErrInvalidCursor
+		}
+		start, end = at+1, at+1+limit
+		if cursor.Backward {
+			start, end = at-limit, at
+		}
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > total {
+		end = total
+	}
+
+	page := &BookPage{Books: books[start:end], Total: total, Limit: limit, Offset: pagination.Offset}
+	if pagination.Cursor != nil {
+		page.Offset = 0
+	}
+	if start < end {
+		if end < total {
+			page.NextCursor = NewCursor(&books[end-1], sorting, false).Encode()
+		}
+		if start > 0 {
+			page.PrevCursor = NewCursor(&books[start], sorting, true).Encode()
+		}
+	}
+	return page, nil
+}
+
+// DecodeCursor decode an opaque cursor and check it matches sorting
+func DecodeCursor(s string, sorting *Sorting) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != sorting.Sort || cursor.Direction != sorting.Direction ||
+		cursor.ThenKeys != keysString(sorting.Then) || cursor.IgnoreArticles != sorting.IgnoreArticles ||
+		len(cursor.ThenValues) != len(sorting.Then) {
+		return nil, ErrInvalidCursor
+	}
+
+	if cursor.Value, err = cursorValue(cursor.Sort, cursor.Value); err != nil {
+		return nil, err
+	}
+	for i, key := range sorting.Then {
+		if cursor.ThenValues[i], err = cursorValue(key.Field, cursor.ThenValues[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return cursor, nil
+}
+
+// cursorValue convert back a decoded cursor value of field so it compares with stored values
+func cursorValue(field string, value interface{}) (interface{}, error) {
+	if n, ok := value.(json.Number); ok && BookFields[field] == FieldFloat {
+		f, err := n.Float64()
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return f, nil
+	}
+	if n, ok := value.(json.Number); ok {
+		i, err := n.Int64()
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return i, nil
+	}
+	if raw, ok := value.(string); ok && BookFields[field] == FieldTime {
+		t, err := ParseTime(raw)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return t, nil
+	}
+	return value, nil
+}
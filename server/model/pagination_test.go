@@ -0,0 +1,71 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	book := &Book{
+		ID:                objectid.New(),
+		YearOfPublication: 2011,
+		UpdatedAt:         time.Date(2018, 10, 1, 10, 30, 0, 0, time.UTC),
+	}
+
+	for _, sorting := range []*Sorting{
+		{Sort: "publication_year", Direction: -1},
+		{Sort: "updated_at", Direction: 1},
+		{Sort: "_id", Direction: 1},
+	} {
+		cursor, err := DecodeCursor(NewCursor(book, sorting, true).Encode(), sorting)
+
+		assert.Nil(t, err)
+		assert.Equal(t, book.ID, cursor.ID)
+		assert.True(t, cursor.Backward)
+		if sorting.Sort != "_id" {
+			assert.Equal(t, book.FieldValue(sorting.Sort), cursor.Value)
+		}
+	}
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	sorting := &Sorting{Sort: "publication_year", Direction: -1}
+	encoded := NewCursor(&Book{ID: objectid.New()}, sorting, false).Encode()
+
+	_, err := DecodeCursor(encoded, &Sorting{Sort: "rating", Direction: -1})
+	assert.Equal(t, ErrInvalidCursor, err, "It should reject cursors built for another sorting")
+
+	_, err = DecodeCursor("not a cursor", sorting)
+	assert.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestPageBooks(t *testing.T) {
+	books := make([]Book, 5)
+	for i := range books {
+		books[i].ID = objectid.New()
+	}
+	sorting := &Sorting{Sort: "_id", Direction: 1}
+
+	page, err := PageBooks(books, sorting, &Pagination{Limit: 2, Offset: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, books[1:3], page.Books)
+	assert.Equal(t, int64(5), page.Total)
+	assert.NotEmpty(t, page.NextCursor)
+	assert.NotEmpty(t, page.PrevCursor)
+
+	next, _ := DecodeCursor(page.NextCursor, sorting)
+	page, err = PageBooks(books, sorting, &Pagination{Limit: 2, Cursor: next})
+	assert.NoError(t, err)
+	assert.Equal(t, books[3:5], page.Books, "It should page after the cursor")
+	assert.Empty(t, page.NextCursor)
+
+	prev, _ := DecodeCursor(page.PrevCursor, sorting)
+	page, _ = PageBooks(books, sorting, &Pagination{Limit: 2, Cursor: prev})
+	assert.Equal(t, books[1:3], page.Books, "It should page before a backward cursor")
+
+	_, err = PageBooks(books[:2], sorting, &Pagination{Cursor: next})
+	assert.Equal(t, ErrInvalidCursor, err, "It should reject cursors of books not listed")
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// Revision actions
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+	ActionRevert  = "revert"
+)
+
+// ErrRevisionNotFound returned when a book has no revision with the requested number
+var ErrRevisionNotFound = &Error{Kind: KindNotFound, Code: CodeRevisionNotFound, Message: "revision not found"}
+
+// Revision immutable record of a single write on a book
+type Revision struct {
+	ID objectid.ObjectID `bson:"_id,omitempty" json:"-"`
+	// BookID id of the changed book
+	BookID objectid.ObjectID `bson:"book_id" json:"book_id"`
+	// Number revision number, the book version after the write
+	Number int64 `bson:"number" json:"number"`
+	// Action one of the Action* constants
+	Action string `bson:"action" json:"action"`
+	// Actor who made the write
+	Actor string `bson:"actor" json:"actor"`
+	// CreatedAt when the write was made
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	// Changes field level diff with the previous state
+	Changes []FieldChange `bson:"changes" json:"changes"`
+	// RevertedTo number of the revision a revert restored
+	RevertedTo int64 `bson:"reverted_to,omitempty" json:"reverted_to,omitempty"`
+	// Book state of the book after the write
+	Book *Book `bson:"book" json:"book,omitempty"`
+}
+
+// FieldChange value of a field before and after a write
+type FieldChange struct {
+	Field string      `bson:"field" json:"field"`
+	From  interface{} `bson:"from" json:"from"`
+	To    interface{} `bson:"to" json:"to"`
+}
+
+// Diff list changes of client fields between two states of a book
+func Diff(before *Book, after *Book) []FieldChange {
+	changes := []FieldChange{}
+	for _, field := range ChangedFields(before, after) {
+		changes = append(changes, FieldChange{
+			Field: field,
+			From:  before.FieldValue(field),
+			To:    after.FieldValue(field),
+		})
+	}
+	return changes
+}
@@ -0,0 +1,15 @@
+package model
+
+// SearchResult book matching a full text search
+type SearchResult struct {
+	Book       Book              `json:"book"`
+	Score      float64           `json:"score"`
+	Highlights map[string]string `json:"highlights"`
+}
+
+// SearchPage full text search results
+type SearchPage struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"data"`
+	Total   int64          `json:"total"`
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// ErrSeriesNotFound returned when a series doesn't exist
+var ErrSeriesNotFound = &Error{Kind: KindNotFound, Code: CodeSeriesNotFound, Message: "series not found"}
+
+// ErrSeriesInUse returned when deleting a series books are still part of
+var ErrSeriesInUse = &Error{Kind: KindConflict, Code: CodeSeriesInUse, Message: "series has books"}
+
+// ErrNoUnreadBook returned when every book of a series is read
+var ErrNoUnreadBook = &Error{Kind: KindNotFound, Code: CodeNoUnreadBook, Message: "every book of the series is read"}
+
+// Series series model structure, books are part of a series by its name, at a position
+type Series struct {
+	ID objectid.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	// Name name books of the series have as series
+	Name        string `bson:"name" json:"name"`
+	Description string `bson:"description" json:"description"`
+	// Volumes number of volumes of a finished series, 0 when unknown or still running
+	Volumes   int64     `bson:"volumes" json:"volumes"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// ReadingOrder sorting of the books of a series, by position then publication year
+var ReadingOrder = Sorting{Sort: "series_position", Direction: 1, Then: []SortKey{{Field: "publication_year", Direction: 1}}}
+
+// Validate check every field of a series, all the invalid fields are returned in a single error
+func (s *Series) Validate() error {
+	var fields []FieldError
+	check := func(field string, value interface{}, rules ...Rule) {
+		for _, rule := range rules {
+			if err := rule(value); err != nil {
+				err.Field = field
+				fields = append(fields, *err)
+				return
+			}
+		}
+	}
+
+	check("name", s.Name, Required, Length(1, 200))
+	check("description", s.Description, Length(1, 10000))
+	check("volumes", s.Volumes, Range(1, 10000))
+
+	if len(fields) > 0 {
+		return &Error{Kind: KindValidation, Code: CodeValidation, Message: "invalid series", Fields: fields}
+	}
+	return nil
+}
+
+// Normalize trim the name and description
+func (s *Series) Normalize() {
+	s.Name = strings.TrimSpace(s.Name)
+	s.Description = strings.TrimSpace(s.Description)
+}
+
+// SortSeries sort series by name
+func SortSeries(series []Series) {
+	sort.SliceStable(series, func(i, j int) bool {
+		return CompareStrings(series[i].Name, series[j].Name) < 0
+	})
+}
+
+// FindSeries series named name, case and accent insensitive, nil when there is none
+func FindSeries(series []Series, name string) *Series {
+	for i := range series {
+		if SameName(series[i].Name, name) {
+			return &series[i]
+		}
+	}
+	return nil
+}
+
+// SeriesGaps volumes missing from the books of a series
+type SeriesGaps struct {
+	// Volumes number of volumes expected, the volumes of the series or the last whole position of its books
+	Volumes int64 `json:"volumes"`
+	// Missing whole positions from 1 to Volumes no book is at
+	Missing []int64 `json:"missing"`
+	// Repeated positions several books are at, ex: two editions of a volume
+	Repeated []float64 `json:"repeated"`
+}
+
+// FindGaps find the missing and repeated volumes of a series from the positions of its books. Books without a
+// position and fractional positions, like a novella at 2.5, never fill a volume
+func FindGaps(series *Series, books []Book) *SeriesGaps {
+	gaps := &SeriesGaps{Volumes: series.Volumes, Missing: []int64{}, Repeated: []float64{}}
+
+	counts := map[float64]int{}
+	for _, book := range books {
+		if book.SeriesPosition <= 0 {
+			continue
+		}
+		counts[book.SeriesPosition]++
+		if counts[book.SeriesPosition] == 2 {
+			gaps.Repeated = append(gaps.Repeated, book.SeriesPosition)
+		}
+		if last := int64(math.Floor(book.SeriesPosition)); last > gaps.Volumes {
+			gaps.Volumes = last
+		}
+	}
+	sort.Float64s(gaps.Repeated)
+
+	for volume := int64(1); volume <= gaps.Volumes; volume++ {
+		if counts[float64(volume)] == 0 {
+			gaps.Missing = append(gaps.Missing, volume)
+		}
+	}
+	return gaps
+}
+
+// NextUnread first unread book of books in reading order, nil when every book is read
+func NextUnread(books []Book) *Book {
+	for i := range books {
+		if !books[i].Read {
+			return &books[i]
+		}
+	}
+	return nil
+}
+
+// SeriesRequest small hack to protect ID of being posted and update from body payload
+type SeriesRequest struct {
+	*Series
+
+	// Previous series state before the body was bound over it, nil on create
+	Previous *Series `json:"-"`
+
+	ProtectedID string `json:"_id"` // override '_id' json to have more control
+}
+
+// Bind bind body into series struct
+func (s *SeriesRequest) Bind(r *http.Request) error {
+	if s.Series == nil {
+		return errors.New("missing required Series fields.")
+	}
+
+	s.ProtectedID = ""
+
+	// timestamps are only changed by the repository
+	previous := s.Previous
+	if previous == nil {
+		previous = &Series{}
+	}
+	s.Series.ID = previous.ID
+	s.Series.CreatedAt = previous.CreatedAt
+	s.Series.UpdatedAt = previous.UpdatedAt
+
+	if err := s.Series.Validate(); err != nil {
+		return err
+	}
+	s.Series.Normalize()
+	return nil
+}
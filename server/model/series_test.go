@@ -0,0 +1,56 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindGaps(t *testing.T) {
+	books := []Book{
+		{Title: "Sardines", SeriesPosition: 1},
+		{Title: "Novella", SeriesPosition: 2.5},
+		{Title: "Trawler", SeriesPosition: 4},
+		{Title: "Trawler, pocket", SeriesPosition: 4},
+		{Title: "Prequel"},
+	}
+
+	gaps := FindGaps(&Series{}, books)
+	assert.Equal(t, int64(4), gaps.Volumes, "It should expect volumes up to the last position")
+	assert.Equal(t, []int64{2, 3}, gaps.Missing, "It should not fill a volume with a fractional position")
+	assert.Equal(t, []float64{4}, gaps.Repeated)
+
+	gaps = FindGaps(&Series{Volumes: 6}, books)
+	assert.Equal(t, []int64{2, 3, 5, 6}, gaps.Missing, "It should expect the volumes of the series")
+
+	gaps = FindGaps(&Series{}, nil)
+	assert.Equal(t, int64(0), gaps.Volumes)
+	assert.Empty(t, gaps.Missing)
+}
+
+func TestNextUnread(t *testing.T) {
+	books := []Book{
+		{Title: "Sardines", SeriesPosition: 1, Read: true},
+		{Title: "Novella", SeriesPosition: 1.5},
+		{Title: "Trawler", SeriesPosition: 2},
+	}
+
+	assert.Equal(t, "Novella", NextUnread(books).Title)
+
+	books[1].Read = true
+	books[2].Read = true
+	assert.Nil(t, NextUnread(books))
+}
+
+func TestSeriesValidate(t *testing.T) {
+	err := (&Book{Title: "Sardines", SeriesPosition: 2}).Validate()
+	if assert.IsType(t, &Error{}, err) {
+		assert.Equal(t, "series_position", err.(*Error).Fields[0].Field, "It should require a series for a position")
+	}
+	assert.NoError(t, (&Book{Title: "Sardines", Series: "Sea", SeriesPosition: 2.5}).Validate())
+
+	err = (&Series{Volumes: -1}).Validate()
+	if assert.IsType(t, &Error{}, err) {
+		assert.Len(t, err.(*Error).Fields, 2)
+	}
+}
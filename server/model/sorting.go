@@ -1,9 +1,178 @@
 package model
 
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SortKey one level of a sorting
+type SortKey struct {
+	// Field bson name of the field
+	Field string
+	// Direction 1 ascending, -1 descending
+	Direction int32
+}
+
 // Sorting sorting structure
 type Sorting struct {
 	// Sort field to sort
 	Sort string
 	// Direction one of asc, desc
 	Direction int32
+	// Then keys sorting books having the same values for the previous keys, `_id` breaks the last ties
+	Then []SortKey
+	// IgnoreArticles sort titles without their leading article, "The Trawler" sorts as "Trawler"
+	IgnoreArticles bool
+}
+
+// SortableFields fields books can be sorted by, mongo has a sort index for each of them
+var SortableFields = map[string]bool{
+	"_id":              true,
+	"title":            true,
+	"author":           true,
+	"genre":            true,
+	"series":           true,
+	"series_position":  true,
+	"rating":           true,
+	"publication_year": true,
+	"created_at":       true,
+	"updated_at":       true,
+	"deleted_at":       true,
+}
+
+// Articles leading articles ignored by IgnoreArticles, lower cased with their trailing separator
+var Articles = []string{"the ", "a ", "an ", "le ", "la ", "les ", "l'", "l’"}
+
+// ParseSorting parse a comma separated list of fields, ex: `-rating,author,title`.
+//
+// A `-` prefix sorts the field descending, a `+` prefix ascending, fields without prefix are sorted in direction.
+func ParseSorting(raw string, direction int32) (*Sorting, error) {
+	if direction == 0 {
+		direction = 1
+	}
+
+	var keys []SortKey
+	seen := map[string]bool{}
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		key := SortKey{Field: field, Direction: direction}
+		switch {
+		case strings.HasPrefix(field, "-"):
+			key = SortKey{Field: field[1:], Direction: -1}
+		case strings.HasPrefix(field, "+"):
+			key = SortKey{Field: field[1:], Direction: 1}
+		}
+
+		if !SortableFields[key.Field] {
+			return nil, fmt.Errorf("invalid sort: can't sort by %q", key.Field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("invalid sort: %q is listed twice", key.Field)
+		}
+		if seen["_id"] {
+			return nil, fmt.Errorf("invalid sort: %q must be the last field", "_id")
+		}
+		seen[key.Field] = true
+		keys = append(keys, key)
+	}
+
+	return &Sorting{Sort: keys[0].Field, Direction: keys[0].Direction, Then: keys[1:]}, nil
+}
+
+// Keys every sort key in order, starting with Sort
+func (s *Sorting) Keys() []SortKey {
+	return append([]SortKey{{Field: s.Sort, Direction: s.Direction}}, s.Then...)
+}
+
+// Reverse same sorting with every direction flipped, used to walk pages backward
+func (s *Sorting) Reverse() *Sorting {
+	reversed := *s
+	reversed.Direction = -s.Direction
+	reversed.Then = make([]SortKey, len(s.Then))
+	for i, key := range s.Then {
+		reversed.Then[i] = SortKey{Field: key.Field, Direction: -key.Direction}
+	}
+	return &reversed
+}
+
+// TieDirection direction `_id` breaks ties in, the one of the last key
+func (s *Sorting) TieDirection() int32 {
+	if len(s.Then) > 0 {
+		return s.Then[len(s.Then)-1].Direction
+	}
+	return s.Direction
+}
+
+// String keys as written in the `sort` query parameter
+func (s *Sorting) String() string {
+	return keysString(s.Keys())
+}
+
+// Value value book is sorted by for field, titles lose their article when IgnoreArticles
+func (s *Sorting) Value(book *Book, field string) interface{} {
+	if field == "title" && s.IgnoreArticles {
+		return SortTitle(book.Title)
+	}
+	return book.FieldValue(field)
+}
+
+// SortTitle title without its leading article
+func SortTitle(title string) string {
+	for _, article := range Articles {
+		if len(title) > len(article) && strings.EqualFold(title[:len(article)], article) {
+			return title[len(article):]
+		}
+	}
+	return title
+}
+
+// CompareStrings compare strings the way the ICU "en" collation does, -1, 0 or 1.
+//
+// Letters are compared first, then accents (unaccented first), then case (lower case first). Accents are
+// compared by code point, ICU orders some accents otherwise.
+func CompareStrings(a string, b string) int {
+	if c := strings.Compare(CollationKey(a), CollationKey(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(TieBreakKey(a), TieBreakKey(b))
+}
+
+// CollationKey lower case s and strip its accents, "Émile" sorts with "emile"
+func CollationKey(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// TieBreakKey order of strings having the same CollationKey: s lower cased then s with its case swapped, so
+// accents break ties before case and lower case sorts first
+func TieBreakKey(s string) string {
+	swapped := strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, s)
+	return strings.ToLower(s) + "\x00" + swapped
+}
+
+// keysString write keys as in the `sort` query parameter
+func keysString(keys []SortKey) string {
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key.Direction < 0 {
+			fields = append(fields, "-"+key.Field)
+		} else {
+			fields = append(fields, key.Field)
+		}
+	}
+	return strings.Join(fields, ",")
 }
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSorting(t *testing.T) {
+	sorting, err := ParseSorting("-rating, author,+title", -1)
+	assert.NoError(t, err)
+	assert.Equal(t, &Sorting{
+		Sort:      "rating",
+		Direction: -1,
+		Then:      []SortKey{{Field: "author", Direction: -1}, {Field: "title", Direction: 1}},
+	}, sorting)
+	assert.Equal(t, "-rating,-author,title", sorting.String())
+
+	sorting, err = ParseSorting("author", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, &Sorting{Sort: "author", Direction: 1, Then: []SortKey{}}, sorting, "It should sort ascending by default")
+
+	for _, raw := range []string{"ratting", "description", "publisher", "rating,-rating", "_id,title", ""} {
+		_, err := ParseSorting(raw, 1)
+		assert.Error(t, err, raw)
+	}
+}
+
+func TestSortingReverse(t *testing.T) {
+	sorting := &Sorting{Sort: "rating", Direction: -1, Then: []SortKey{{Field: "title", Direction: 1}}}
+
+	reversed := sorting.Reverse()
+	assert.Equal(t, &Sorting{Sort: "rating", Direction: 1, Then: []SortKey{{Field: "title", Direction: -1}}}, reversed)
+	assert.Equal(t, int32(-1), reversed.TieDirection())
+	assert.Equal(t, int32(1), sorting.Then[0].Direction, "It should not change the sorting")
+}
+
+func TestSortTitle(t *testing.T) {
+	assert.Equal(t, "Trawler", SortTitle("The Trawler"))
+	assert.Equal(t, "Mer", SortTitle("la Mer"))
+	assert.Equal(t, "Étranger", SortTitle("L'Étranger"))
+	assert.Equal(t, "Theory", SortTitle("Theory"), "It should only remove whole articles")
+	assert.Equal(t, "The", SortTitle("The"))
+}
+
+func TestCompareStrings(t *testing.T) {
+	assert.Equal(t, -1, CompareStrings("Émile", "Eric"))
+	assert.Equal(t, -1, CompareStrings("emile", "Émile"), "It should break ties on accents")
+	assert.Equal(t, 1, CompareStrings("Zola", "émile"))
+	assert.Equal(t, 0, CompareStrings("Émile", "Émile"))
+	assert.Equal(t, -1, CompareStrings("bob", "Bob"), "It should sort lower case first like ICU en")
+	assert.Equal(t, -1, CompareStrings("Bob", "bób"), "It should break ties on accents before case")
+}
+
+func TestCursorThenKeys(t *testing.T) {
+	book := &Book{ID: objectid.New(), Title: "The Trawler", Rating: 4}
+	sorting := &Sorting{Sort: "rating", Direction: -1, Then: []SortKey{{Field: "title", Direction: 1}}, IgnoreArticles: true}
+
+	cursor, err := DecodeCursor(NewCursor(book, sorting, false).Encode(), sorting)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []interface{}{int64(4), "Trawler"}, cursor.Values())
+	}
+
+	_, err = DecodeCursor(NewCursor(book, sorting, false).Encode(), &Sorting{Sort: "rating", Direction: -1, Then: []SortKey{{Field: "title", Direction: 1}}})
+	assert.Equal(t, ErrInvalidCursor, err, "It should reject cursors built with articles ignored")
+
+	_, err = DecodeCursor(NewCursor(book, sorting, false).Encode(), &Sorting{Sort: "rating", Direction: -1, IgnoreArticles: true})
+	assert.Equal(t, ErrInvalidCursor, err, "It should reject cursors built for other keys")
+}
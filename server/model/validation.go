@@ -0,0 +1,295 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// Validation reasons, the machine readable cause of a FieldError
+const (
+	ReasonRequired        = "required"
+	ReasonTooShort        = "too_short"
+	ReasonTooLong         = "too_long"
+	ReasonOutOfRange      = "out_of_range"
+	ReasonInFuture        = "in_future"
+	ReasonNotAllowed      = "not_allowed"
+	ReasonInvalidISBN     = "invalid_isbn"
+	ReasonInvalidLanguage = "invalid_language"
+	ReasonMismatch        = "mismatch"
+	ReasonRepeated        = "repeated"
+	ReasonUnknown         = "unknown"
+)
+
+// FieldError invalid field of a book
+type FieldError struct {
+	Field   string `json:"field"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// Rule check a field value, nil when valid. Except Required, rules accept empty values
+type Rule func(value interface{}) *FieldError
+
+// FieldRules rules of a field, by bson name
+type FieldRules struct {
+	Field string
+	Rules []Rule
+}
+
+// CrossRule check a book as a whole, nil when valid
+type CrossRule func(b *Book) *FieldError
+
+// BookRules rules of every client writable field, the first failing rule of a field is reported
+var BookRules = []FieldRules{
+	{"title", []Rule{Required, Length(1, 300)}},
+	{"subtitle", []Rule{Length(1, 300)}},
+	{"author", []Rule{Length(2, 200)}},
+	{"author_ids", []Rule{Distinct}},
+	{"contributors", []Rule{ValidContributors}},
+	{"genre", []Rule{Length(1, 100)}},
+	{"series", []Rule{Length(1, 200)}},
+	{"series_position", []Rule{Between(0, 10000)}},
+	{"isbn_10", []Rule{ISBN(10)}},
+	{"isbn_13", []Rule{ISBN(13)}},
+	{"publisher", []Rule{Length(1, 200)}},
+	{"language", []Rule{Language}},
+	{"description", []Rule{Length(1, 10000)}},
+	{"edition", []Rule{Range(1, 200)}},
+	{"number_of_pages", []Rule{Range(1, 100000)}},
+	{"publication_year", []Rule{Range(1000, 9999), NotInFuture}},
+	{"rating", []Rule{Range(1, 5)}},
+}
+
+// BookCrossRules rules checking several fields together
+var BookCrossRules = []CrossRule{
+	matchingISBN,
+	distinctSubtitle,
+	positionInSeries,
+}
+
+// Validate check every rule of a book, all the invalid fields are returned in a single error
+func (b *Book) Validate() error {
+	var fields []FieldError
+	invalid := map[string]bool{}
+	for _, field := range BookRules {
+		for _, rule := range field.Rules {
+			if err := rule(b.FieldValue(field.Field)); err != nil {
+				err.Field = field.Field
+				fields = append(fields, *err)
+				invalid[field.Field] = true
+				break
+			}
+		}
+	}
+	for _, rule := range BookCrossRules {
+		// a field already invalid on its own isn't checked against the others
+		if err := rule(b); err != nil && !invalid[err.Field] {
+			fields = append(fields, *err)
+		}
+	}
+
+	if len(fields) > 0 {
+		return Invalid(fields...)
+	}
+	return nil
+}
+
+// Required the value can't be empty
+func Required(value interface{}) *FieldError {
+	if isEmpty(value) {
+		return &FieldError{Reason: ReasonRequired, Message: "is required"}
+	}
+	return nil
+}
+
+// Length the string length, in characters, must be between min and max
+func Length(min int, max int) Rule {
+	return func(value interface{}) *FieldError {
+		if isEmpty(value) {
+			return nil
+		}
+		length := utf8.RuneCountInString(strings.TrimSpace(value.(string)))
+		if length < min {
+			return &FieldError{Reason: ReasonTooShort, Message: fmt.Sprintf("must be at least %d characters", min)}
+		}
+		if length > max {
+			return &FieldError{Reason: ReasonTooLong, Message: fmt.Sprintf("must be at most %d characters", max)}
+		}
+		return nil
+	}
+}
+
+// Range the number must be between min and max
+func Range(min int64, max int64) Rule {
+	return func(value interface{}) *FieldError {
+		if isEmpty(value) {
+			return nil
+		}
+		if number := value.(int64); number < min || number > max {
+			return &FieldError{Reason: ReasonOutOfRange, Message: fmt.Sprintf("must be between %d and %d", min, max)}
+		}
+		return nil
+	}
+}
+
+// Between the decimal number must be between min and max
+func Between(min float64, max float64) Rule {
+	return func(value interface{}) *FieldError {
+		if isEmpty(value) {
+			return nil
+		}
+		if number := value.(float64); number < min || number > max {
+			return &FieldError{Reason: ReasonOutOfRange, Message: fmt.Sprintf("must be between %g and %g", min, max)}
+		}
+		return nil
+	}
+}
+
+// NotInFuture the year can't be after the current one
+func NotInFuture(value interface{}) *FieldError {
+	if isEmpty(value) {
+		return nil
+	}
+	if value.(int64) > int64(time.Now().Year()) {
+		return &FieldError{Reason: ReasonInFuture, Message: "can't be in the future"}
+	}
+	return nil
+}
+
+// OneOf the string must be one of values, case insensitive
+func OneOf(values []string) Rule {
+	return func(value interface{}) *FieldError {
+		if isEmpty(value) {
+			return nil
+		}
+		for _, allowed := range values {
+			if strings.EqualFold(allowed, strings.TrimSpace(value.(string))) {
+				return nil
+			}
+		}
+		return &FieldError{Reason: ReasonNotAllowed, Message: "must be one of " + strings.Join(values, ", ")}
+	}
+}
+
+// Distinct the ids can't be listed twice
+func Distinct(value interface{}) *FieldError {
+	if isEmpty(value) {
+		return nil
+	}
+	seen := map[objectid.ObjectID]bool{}
+	for _, ID := range value.([]objectid.ObjectID) {
+		if seen[ID] {
+			return &FieldError{Reason: ReasonRepeated, Message: "can't list the same id twice"}
+		}
+		seen[ID] = true
+	}
+	return nil
+}
+
+// ValidContributors every contributor must have a known role and a name or an author id, and be listed once per role
+func ValidContributors(value interface{}) *FieldError {
+	if isEmpty(value) {
+		return nil
+	}
+	contributors := value.([]Contributor)
+	for i, contributor := range contributors {
+		prefix := fmt.Sprintf("contributor %d ", i+1)
+		if err := OneOf(ContributorRoles)(contributor.Role); isEmpty(contributor.Role) || err != nil {
+			return &FieldError{Reason: ReasonNotAllowed, Message: prefix + "role must be one of " + strings.Join(ContributorRoles, ", ")}
+		}
+		if !contributor.IsLinked() {
+			if err := Required(contributor.Name); err != nil {
+				return &FieldError{Reason: err.Reason, Message: prefix + "name is required without author_id"}
+			}
+		}
+		if err := Length(1, 200)(contributor.Name); err != nil {
+			return &FieldError{Reason: err.Reason, Message: prefix + "name " + err.Message}
+		}
+		for _, previous := range contributors[:i] {
+			if strings.EqualFold(previous.Role, contributor.Role) && previous.SamePerson(&contributor) {
+				return &FieldError{Reason: ReasonRepeated, Message: prefix + "is listed twice as " + strings.ToLower(contributor.Role)}
+			}
+		}
+	}
+	return nil
+}
+
+// ISBN the string must be an ISBN of length digits with a valid checksum
+func ISBN(length int) Rule {
+	return func(value interface{}) *FieldError {
+		if isEmpty(value) {
+			return nil
+		}
+		isbn := value.(string)
+		if _, err := NormalizeISBN(isbn); err != nil || len(cleanISBN(isbn)) != length {
+			return &FieldError{Reason: ReasonInvalidISBN, Message: fmt.Sprintf("must be an ISBN-%d with a valid check digit", length)}
+		}
+		return nil
+	}
+}
+
+// Language the string must be an ISO 639-1 code
+func Language(value interface{}) *FieldError {
+	if isEmpty(value) {
+		return nil
+	}
+	if _, err := NormalizeLanguage(value.(string)); err != nil {
+		return &FieldError{Reason: ReasonInvalidLanguage, Message: "must be an ISO 639-1 code"}
+	}
+	return nil
+}
+
+// matchingISBN ISBN-10 and ISBN-13 must be the same book
+func matchingISBN(b *Book) *FieldError {
+	if b.ISBN10 == "" || b.ISBN13 == "" {
+		return nil
+	}
+	isbn10, err10 := NormalizeISBN(b.ISBN10)
+	isbn13, err13 := NormalizeISBN(b.ISBN13)
+	if err10 == nil && err13 == nil && isbn10 != isbn13 {
+		return &FieldError{Field: "isbn_10", Reason: ReasonMismatch, Message: "doesn't match isbn_13"}
+	}
+	return nil
+}
+
+// distinctSubtitle the subtitle can't repeat the title
+func distinctSubtitle(b *Book) *FieldError {
+	if isEmpty(b.Subtitle) || isEmpty(b.Title) {
+		return nil
+	}
+	if strings.EqualFold(strings.TrimSpace(b.Subtitle), strings.TrimSpace(b.Title)) {
+		return &FieldError{Field: "subtitle", Reason: ReasonRepeated, Message: "can't repeat the title"}
+	}
+	return nil
+}
+
+// positionInSeries a position is only set on books of a series
+func positionInSeries(b *Book) *FieldError {
+	if b.SeriesPosition != 0 && isEmpty(b.Series) {
+		return &FieldError{Field: "series_position", Reason: ReasonNotAllowed, Message: "requires a series"}
+	}
+	return nil
+}
+
+// isEmpty check if a field value is its zero value, blank strings are empty
+func isEmpty(value interface{}) bool {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v) == ""
+	case int64:
+		return v == 0
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case []objectid.ObjectID:
+		return len(v) == 0
+	case []Contributor:
+		return len(v) == 0
+	}
+	return value == nil
+}
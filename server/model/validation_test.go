@@ -0,0 +1,64 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookValidate(t *testing.T) {
+	valid := &Book{
+		Title:             "Sardines",
+		Author:            "Mathieu Doyon",
+		Genre:             "fiction",
+		ISBN10:            "0-306-40615-2",
+		ISBN13:            "978-0-306-40615-7",
+		Language:          "FR",
+		NumberOfPages:     345,
+		YearOfPublication: 2011,
+		Rating:            5,
+	}
+	assert.Nil(t, valid.Validate())
+
+	book := &Book{
+		Subtitle:          " ",
+		Genre:             "Fiction--",
+		ISBN13:            "9780306406158",
+		Language:          "french",
+		Edition:           -1,
+		NumberOfPages:     -12,
+		YearOfPublication: 3000,
+		Rating:            42,
+	}
+	err := book.Validate()
+	if !assert.IsType(t, &Error{}, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, KindValidation, err.(*Error).Kind)
+	assert.Equal(t, []FieldError{
+		{Field: "title", Reason: ReasonRequired, Message: "is required"},
+		{Field: "isbn_13", Reason: ReasonInvalidISBN, Message: "must be an ISBN-13 with a valid check digit"},
+		{Field: "language", Reason: ReasonInvalidLanguage, Message: "must be an ISO 639-1 code"},
+		{Field: "edition", Reason: ReasonOutOfRange, Message: "must be between 1 and 200"},
+		{Field: "number_of_pages", Reason: ReasonOutOfRange, Message: "must be between 1 and 100000"},
+		{Field: "publication_year", Reason: ReasonInFuture, Message: "can't be in the future"},
+		{Field: "rating", Reason: ReasonOutOfRange, Message: "must be between 1 and 5"},
+	}, err.(*Error).Fields, "It should report every invalid field at once")
+}
+
+func TestBookValidateCrossFields(t *testing.T) {
+	err := (&Book{Title: "Sardines", ISBN10: "0306406152", ISBN13: "9780804429573"}).Validate()
+	if assert.IsType(t, &Error{}, err) {
+		assert.Equal(t, []FieldError{{Field: "isbn_10", Reason: ReasonMismatch, Message: "doesn't match isbn_13"}}, err.(*Error).Fields)
+	}
+
+	err = (&Book{Title: "Sardines", Subtitle: "sardines"}).Validate()
+	if assert.IsType(t, &Error{}, err) {
+		assert.Equal(t, ReasonRepeated, err.(*Error).Fields[0].Reason)
+	}
+
+	err = (&Book{Title: "Sardines", ISBN10: "0306406153", ISBN13: "9780804429573"}).Validate()
+	if assert.IsType(t, &Error{}, err) {
+		assert.Len(t, err.(*Error).Fields, 1, "It should not report a mismatch of an invalid ISBN")
+	}
+}
@@ -0,0 +1,206 @@
+package model
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// ErrWorkNotFound returned when a work doesn't exist
+var ErrWorkNotFound = &Error{Kind: KindNotFound, Code: CodeWorkNotFound, Message: "work not found"}
+
+// ErrWorkInUse returned when deleting a work which still has editions
+var ErrWorkInUse = &Error{Kind: KindConflict, Code: CodeWorkInUse, Message: "work has editions"}
+
+// Work work model structure, books are editions of a work by its id. New books are grouped into the work having
+// their title and authors
+type Work struct {
+	ID objectid.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	// Title title editions are grouped by, the title of the first edition
+	Title string `bson:"title" json:"title"`
+	// Author free text authors editions are grouped by, "A & B" credits two authors
+	Author    string    `bson:"author" json:"author"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// Validate check every field of a work, all the invalid fields are returned in a single error
+func (w *Work) Validate() error {
+	var fields []FieldError
+	check := func(field string, value interface{}, rules ...Rule) {
+		for _, rule := range rules {
+			if err := rule(value); err != nil {
+				err.Field = field
+				fields = append(fields, *err)
+				return
+			}
+		}
+	}
+
+	check("title", w.Title, Required, Length(1, 300))
+	check("author", w.Author, Length(2, 200))
+
+	if len(fields) > 0 {
+		return &Error{Kind: KindValidation, Code: CodeValidation, Message: "invalid work", Fields: fields}
+	}
+	return nil
+}
+
+// Normalize trim the title and author
+func (w *Work) Normalize() {
+	w.Title = strings.TrimSpace(w.Title)
+	w.Author = strings.TrimSpace(w.Author)
+}
+
+// HasEdition check if a book has the title and authors of the work, case and accent insensitive.
+// Authors are compared whatever their order
+func (w *Work) HasEdition(book *Book) bool {
+	if !SameName(w.Title, book.Title) {
+		return false
+	}
+
+	names := SplitAuthorNames(w.Author)
+	others := SplitAuthorNames(book.Author)
+	if len(names) != len(others) {
+		return false
+	}
+	for _, name := range others {
+		if !containsName(names, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewWork work a book is the first edition of
+func NewWork(book *Book) *Work {
+	return &Work{Title: book.Title, Author: book.Author}
+}
+
+// SortWorks sort works by title then author
+func SortWorks(works []Work) {
+	sort.SliceStable(works, func(i, j int) bool {
+		if c := CompareStrings(works[i].Title, works[j].Title); c != 0 {
+			return c < 0
+		}
+		return CompareStrings(works[i].Author, works[j].Author) < 0
+	})
+}
+
+// FindWork oldest work book is an edition of, nil when there is none
+func FindWork(works []Work, book *Book) *Work {
+	var found *Work
+	for i := range works {
+		if works[i].HasEdition(book) && (found == nil || works[i].CreatedAt.Before(found.CreatedAt)) {
+			found = &works[i]
+		}
+	}
+	return found
+}
+
+// WorkSummary work with figures rolled up from its editions
+type WorkSummary struct {
+	Work
+	// Editions number of editions, trashed ones aren't counted
+	Editions int64 `json:"editions"`
+	// Rating average rating of the rated editions rounded to 2 decimals, 0 when none is rated
+	Rating float64 `json:"rating"`
+	// Ratings number of rated editions
+	Ratings int64 `json:"ratings"`
+
+	total int64
+}
+
+// Tally roll an edition up into the figures of its work
+func (s *WorkSummary) Tally(book *Book) {
+	s.Editions++
+	if book.Rating > 0 {
+		s.Ratings++
+		s.total += book.Rating
+		s.Rating = math.Round(float64(s.total)/float64(s.Ratings)*100) / 100
+	}
+}
+
+// CollapseEditions keep the first edition of each work in books order, with its number of editions in books.
+// Books without a work are kept as they are
+func CollapseEditions(books []Book) []Book {
+	collapsed := []Book{}
+	first := map[objectid.ObjectID]int{}
+	for _, book := range books {
+		if !book.WorkID.IsZero() {
+			if i, ok := first[book.WorkID]; ok {
+				collapsed[i].Editions++
+				continue
+			}
+			first[book.WorkID] = len(collapsed)
+		}
+		book.Editions = 1
+		collapsed = append(collapsed, book)
+	}
+	return collapsed
+}
+
+// WorkRequest small hack to protect ID of being posted and update from body payload
+type WorkRequest struct {
+	*Work
+
+	// Previous work state before the body was bound over it
+	Previous *Work `json:"-"`
+
+	ProtectedID string `json:"_id"` // override '_id' json to have more control
+}
+
+// Bind bind body into work struct
+func (w *WorkRequest) Bind(r *http.Request) error {
+	if w.Work == nil {
+		return errors.New("missing required Work fields.")
+	}
+
+	w.ProtectedID = ""
+
+	// timestamps are only changed by the repository
+	previous := w.Previous
+	if previous == nil {
+		previous = &Work{}
+	}
+	w.Work.ID = previous.ID
+	w.Work.CreatedAt = previous.CreatedAt
+	w.Work.UpdatedAt = previous.UpdatedAt
+
+	if err := w.Work.Validate(); err != nil {
+		return err
+	}
+	w.Work.Normalize()
+	return nil
+}
+
+// WorkMergeRequest works whose editions are moved into another work, the merged works are deleted
+type WorkMergeRequest struct {
+	Works []objectid.ObjectID `json:"works"`
+}
+
+// Bind check at least one work is merged
+func (w *WorkMergeRequest) Bind(r *http.Request) error {
+	if len(w.Works) == 0 {
+		return Invalid(FieldError{Field: "works", Reason: ReasonRequired, Message: "is required"})
+	}
+	return nil
+}
+
+// WorkSplitRequest editions moved out of their work into a new work
+type WorkSplitRequest struct {
+	Editions []objectid.ObjectID `json:"editions"`
+}
+
+// Bind check at least one edition is split
+func (w *WorkSplitRequest) Bind(r *http.Request) error {
+	if len(w.Editions) == 0 {
+		return Invalid(FieldError{Field: "editions", Reason: ReasonRequired, Message: "is required"})
+	}
+	return nil
+}
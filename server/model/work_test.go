@@ -0,0 +1,63 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkHasEdition(t *testing.T) {
+	work := &Work{Title: "Sardines", Author: "Mathieu Doyon & Eric Cantona"}
+
+	assert.True(t, work.HasEdition(&Book{Title: "sardines ", Author: "Eric Cantona & Mathieu Doyon"}), "It should match authors in any order")
+	assert.False(t, work.HasEdition(&Book{Title: "Sardines", Author: "Mathieu Doyon"}))
+	assert.False(t, work.HasEdition(&Book{Title: "Sardinas", Author: "Mathieu Doyon & Eric Cantona"}))
+	assert.True(t, (&Work{Title: "Boat"}).HasEdition(&Book{Title: "Boat"}))
+}
+
+func TestFindWork(t *testing.T) {
+	now := time.Now()
+	works := []Work{
+		{ID: objectid.New(), Title: "Sardines", CreatedAt: now},
+		{ID: objectid.New(), Title: "Sardines", CreatedAt: now.Add(-time.Hour)},
+		{ID: objectid.New(), Title: "Seagulls", CreatedAt: now.Add(-2 * time.Hour)},
+	}
+
+	assert.Equal(t, works[1].ID, FindWork(works, &Book{Title: "Sardines"}).ID, "It should find the oldest work")
+	assert.Nil(t, FindWork(works, &Book{Title: "Trawler"}))
+}
+
+func TestWorkSummaryTally(t *testing.T) {
+	summary := &WorkSummary{}
+	for _, rating := range []int64{4, 0, 5, 5} {
+		summary.Tally(&Book{Rating: rating})
+	}
+
+	assert.Equal(t, int64(4), summary.Editions)
+	assert.Equal(t, int64(3), summary.Ratings, "It should only average rated editions")
+	assert.Equal(t, 4.67, summary.Rating)
+}
+
+func TestCollapseEditions(t *testing.T) {
+	sardines, seagulls := objectid.New(), objectid.New()
+	books := []Book{
+		{Title: "Sardines", WorkID: sardines},
+		{Title: "Seagulls", WorkID: seagulls},
+		{Title: "Boat"},
+		{Title: "Sardines, pocket", WorkID: sardines},
+		{Title: "Boat"},
+	}
+
+	collapsed := CollapseEditions(books)
+	titles := []string{}
+	editions := []int64{}
+	for _, book := range collapsed {
+		titles = append(titles, book.Title)
+		editions = append(editions, book.Editions)
+	}
+	assert.Equal(t, []string{"Sardines", "Seagulls", "Boat", "Boat"}, titles, "It should keep the first edition of each work")
+	assert.Equal(t, []int64{2, 1, 1, 1}, editions)
+	assert.Equal(t, int64(0), books[0].Editions)
+}
@@ -0,0 +1,345 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Patch content types
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// errTestFailed returned by apply when a `test` operation doesn't match
+var errTestFailed = errors.New("test failed")
+
+// TestFailedError returned when a JSON Patch `test` operation doesn't match
+type TestFailedError struct {
+	Index int
+	Path  string
+}
+
+func (e *TestFailedError) Error() string {
+	return fmt.Sprintf("patch test operation %d failed on %q", e.Index, e.Path)
+}
+
+// Operation single RFC 6902 JSON Patch operation
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"` // "null" when explicitly null, empty when missing
+}
+
+// Decode decode JSON keeping numbers as `json.Number`
+func Decode(data []byte) (interface{}, error) {
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// MergePatch apply a RFC 7396 JSON Merge Patch to target
+func MergePatch(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+		} else {
+			targetObject[key] = MergePatch(targetObject[key], value)
+		}
+	}
+
+	return targetObject
+}
+
+// JSONPatch apply RFC 6902 JSON Patch operations to doc, stopping at the first error
+func JSONPatch(doc interface{}, operations []Operation) (interface{}, error) {
+	for i, operation := range operations {
+		var err error
+		doc, err = apply(doc, operation)
+		if err != nil {
+			if err == errTestFailed {
+				return nil, &TestFailedError{Index: i, Path: operation.Path}
+			}
+			return nil, fmt.Errorf("invalid patch operation %d: %s", i, err)
+		}
+	}
+	return doc, nil
+}
+
+// apply apply a single operation
+func apply(doc interface{}, operation Operation) (interface{}, error) {
+	path, err := parsePointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch operation.Op {
+	case "add", "replace", "test":
+		if len(operation.Value) == 0 {
+			return nil, fmt.Errorf("%s requires a value", operation.Op)
+		}
+		if value, err = Decode(operation.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	switch operation.Op {
+	case "add":
+		return add(doc, path, value)
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "replace":
+		if doc, _, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "move", "copy":
+		from, err := parsePointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+		if operation.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, errors.New("can't move a value into one of its children")
+			}
+			doc, value, err = remove(doc, from)
+		} else {
+			value, err = get(doc, from)
+			value = deepCopy(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case "test":
+		current, err := get(doc, path)
+		if err != nil || !equal(current, value) {
+			return nil, errTestFailed
+		}
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("unknown operation %q", operation.Op)
+}
+
+// parsePointer split a RFC 6901 JSON pointer into unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid path %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// get return the value at path
+func get(node interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path %q not found", token)
+			}
+			node = child
+		case []interface{}:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("path %q not found", token)
+		}
+	}
+	return node, nil
+}
+
+// add insert value at path, returning the new node
+func add(node interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, last := path[0], len(path) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if last {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, fmt.Errorf("path %q not found", token)
+		}
+		child, err := add(child, path[1:], value)
+		n[token] = child
+		return n, err
+	case []interface{}:
+		if last {
+			i := len(n)
+			if token != "-" {
+				var err error
+				if i, err = arrayIndex(token, len(n)); err != nil {
+					return nil, err
+				}
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		i, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := add(n[i], path[1:], value)
+		n[i] = child
+		return n, err
+	}
+
+	return nil, fmt.Errorf("path %q not found", token)
+}
+
+// remove delete the value at path, returning the new node and the removed value
+func remove(node interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, node, nil
+	}
+
+	token, last := path[0], len(path) == 1
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, nil, fmt.Errorf("path %q not found", token)
+		}
+		if last {
+			delete(n, token)
+			return n, child, nil
+		}
+		child, removed, err := remove(child, path[1:])
+		n[token] = child
+		return n, removed, err
+	case []interface{}:
+		i, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if last {
+			removed := n[i]
+			return append(n[:i], n[i+1:]...), removed, nil
+		}
+		child, removed, err := remove(n[i], path[1:])
+		n[i] = child
+		return n, removed, err
+	}
+
+	return nil, nil, fmt.Errorf("path %q not found", token)
+}
+
+// arrayIndex parse an array index token, it must be between 0 and max
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	return i, nil
+}
+
+// isPrefix check if prefix is the beginning of path
+func isPrefix(prefix []string, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// equal compare JSON values, numbers are compared by value
+func equal(a interface{}, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := a.Float64()
+		y, errB := b.Float64()
+		return errA == nil && errB == nil && x == y
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// deepCopy copy maps and arrays so copied values can be changed independently
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			c[key] = deepCopy(child)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, child := range v {
+			c[i] = deepCopy(child)
+		}
+		return c
+	}
+	return value
+}
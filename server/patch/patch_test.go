@@ -0,0 +1,87 @@
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustDecode(t *testing.T, data string) interface{} {
+	doc, err := Decode([]byte(data))
+	assert.Nil(t, err)
+	return doc
+}
+
+func TestMergePatch(t *testing.T) {
+	// examples from RFC 7396 appendix A
+	cases := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+	}
+
+	for _, c := range cases {
+		result := MergePatch(mustDecode(t, c[0]), mustDecode(t, c[1]))
+
+		assert.Equal(t, mustDecode(t, c[2]), result, c[1])
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	doc := mustDecode(t, `{"title":"Sardines","tags":["a","b"],"rating":4}`)
+
+	var operations []Operation
+	json.Unmarshal([]byte(`[
+		{"op":"test","path":"/rating","value":4.0},
+		{"op":"replace","path":"/rating","value":5},
+		{"op":"add","path":"/tags/1","value":"x"},
+		{"op":"add","path":"/tags/-","value":"z"},
+		{"op":"remove","path":"/tags/0"},
+		{"op":"copy","from":"/title","path":"/subtitle"},
+		{"op":"move","from":"/subtitle","path":"/a~1b"},
+		{"op":"add","path":"/genre","value":null}
+	]`), &operations)
+
+	result, err := JSONPatch(doc, operations)
+
+	assert.Nil(t, err)
+	assert.Equal(t, mustDecode(t, `{"title":"Sardines","tags":["x","b","z"],"rating":5,"a/b":"Sardines","genre":null}`), result)
+}
+
+func TestJSONPatchErrors(t *testing.T) {
+	cases := []string{
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"add","path":"/tags/5","value":1}]`,
+		`[{"op":"add","path":"title","value":1}]`,
+		`[{"op":"add","path":"/title"}]`,
+		`[{"op":"move","from":"/tags","path":"/tags/0"}]`,
+		`[{"op":"jump","path":"/title"}]`,
+	}
+
+	for _, c := range cases {
+		var operations []Operation
+		json.Unmarshal([]byte(c), &operations)
+
+		_, err := JSONPatch(mustDecode(t, `{"title":"Sardines","tags":["a"]}`), operations)
+
+		assert.NotNil(t, err, c)
+		_, isTest := err.(*TestFailedError)
+		assert.False(t, isTest, c)
+	}
+}
+
+func TestJSONPatchTestFailed(t *testing.T) {
+	var operations []Operation
+	json.Unmarshal([]byte(`[{"op":"replace","path":"/rating","value":1},{"op":"test","path":"/rating","value":4}]`), &operations)
+
+	_, err := JSONPatch(mustDecode(t, `{"rating":4}`), operations)
+
+	assert.Equal(t, &TestFailedError{Index: 1, Path: "/rating"}, err)
+}
@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/db"
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/findopt"
+	"github.com/mongodb/mongo-go-driver/mongo/mongoopt"
+	"github.com/mongodb/mongo-go-driver/mongo/replaceopt"
+)
+
+// AuthorRepo authors repository
+type AuthorRepo struct {
+	interfaces.IAuthorRepository
+
+	// Collation locale authors are sorted and matched by name with
+	Collation string
+}
+
+// List get every author sorted by sort name, only the ones named name (or aliased) when it isn't empty
+func (repo *AuthorRepo) List(ctx context.Context, name string) ([]model.Author, error) {
+	collection := db.Database.Collection("authors")
+
+	filterDoc := bson.NewDocument()
+	if name != "" {
+		filterDoc.Append(bson.EC.ArrayFromElements("$or",
+			bson.VC.DocumentFromElements(bson.EC.String("name", name)),
+			bson.VC.DocumentFromElements(bson.EC.String("aliases", name)),
+		))
+	}
+
+	opts := []findopt.Find{findopt.Sort(bson.NewDocument(
+		bson.EC.Int32("sort_name", 1),
+		bson.EC.Int32("name", 1),
+	))}
+	if repo.Collation != "" {
+		// strength 1 compares base letters only, names match whatever their case and accents
+		opts = append(opts, findopt.Collation(&mongoopt.Collation{Locale: repo.Collation, Strength: 1}))
+	}
+
+	cur, err := collection.Find(ctx, filterDoc, opts...)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return decodeAuthors(ctx, cur)
+}
+
+// Get get an author by ID
+func (repo *AuthorRepo) Get(ctx context.Context, ID string) (*model.Author, error) {
+	collection := db.Database.Collection("authors")
+
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	var author *model.Author
+	err = collection.FindOne(ctx, bson.NewDocument(bson.EC.ObjectID("_id", objectID))).Decode(&author)
+	if err == mongo.ErrNoDocuments {
+		return nil, model.ErrAuthorNotFound
+	}
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return author, nil
+}
+
+// GetMany get authors by ID in the order of IDs, missing authors are left out
+func (repo *AuthorRepo) GetMany(ctx context.Context, IDs []objectid.ObjectID) ([]model.Author, error) {
+	collection := db.Database.Collection("authors")
+
+	if len(IDs) == 0 {
+		return []model.Author{}, nil
+	}
+
+	values := make([]*bson.Value, len(IDs))
+	for i, ID := range IDs {
+		values[i] = bson.VC.ObjectID(ID)
+	}
+	cur, err := collection.Find(ctx, bson.NewDocument(
+		bson.EC.SubDocumentFromElements("_id", bson.EC.ArrayFromElements("$in", values...)),
+	))
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	authors, err := decodeAuthors(ctx, cur)
+	if err != nil {
+		return nil, err
+	}
+	return orderAuthors(authors, IDs), nil
+}
+
+// Create add a new author
+func (repo *AuthorRepo) Create(ctx context.Context, author *model.Author) (*model.Author, error) {
+	collection := db.Database.Collection("authors")
+
+	author.ID = objectid.New()
+	author.CreatedAt = now()
+	author.UpdatedAt = author.CreatedAt
+	if _, err := collection.InsertOne(ctx, author); err != nil {
+		return nil, mongoError(err)
+	}
+
+	return author, nil
+}
+
+// Update replace an author by ID
+func (repo *AuthorRepo) Update(ctx context.Context, author *model.Author) (*model.Author, error) {
+	collection := db.Database.Collection("authors")
+
+	updatedAt := now()
+	aliases := bson.NewArray()
+	for _, alias := range author.Aliases {
+		aliases.Append(bson.VC.String(alias))
+	}
+
+	res, err := collection.UpdateOne(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("_id", author.ID)),
+		bson.NewDocument(bson.EC.SubDocumentFromElements("$set",
+			bson.EC.String("name", author.Name),
+			bson.EC.String("sort_name", author.SortName),
+			bson.EC.Int64("birth_year", author.BirthYear),
+			bson.EC.Int64("death_year", author.DeathYear),
+			bson.EC.Array("aliases", aliases),
+			bson.EC.String("biography", author.Biography),
+			bson.EC.Time("updated_at", updatedAt),
+		)),
+	)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, model.ErrAuthorNotFound
+	}
+
+	author.UpdatedAt = updatedAt
+	return author, nil
+}
+
+// Delete remove an author by ID
+func (repo *AuthorRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	collection := db.Database.Collection("authors")
+
+	res, err := collection.DeleteOne(ctx, bson.NewDocument(bson.EC.ObjectID("_id", ID)))
+	if err != nil {
+		return 0, mongoError(err)
+	}
+
+	return res.DeletedCount, nil
+}
+
+// decodeAuthors read every author of a cursor
+func decodeAuthors(ctx context.Context, cur mongo.Cursor) ([]model.Author, error) {
+	defer cur.Close(context.Background())
+
+	authors := []model.Author{}
+	for cur.Next(ctx) {
+		author := model.Author{}
+		if err := cur.Decode(&author); err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, mongoError(err)
+	}
+
+	return authors, nil
+}
+
+// orderAuthors sort authors in the order of IDs, authors not listed are left out
+func orderAuthors(authors []model.Author, IDs []objectid.ObjectID) []model.Author {
+	byID := map[objectid.ObjectID]model.Author{}
+	for _, author := range authors {
+		byID[author.ID] = author
+	}
+
+	ordered := []model.Author{}
+	for _, ID := range IDs {
+		if author, ok := byID[ID]; ok {
+			ordered = append(ordered, author)
+		}
+	}
+	return ordered
+}
+
+// Put replace the author document having the id of author, inserting it when missing. Dates are written as is
+func (repo *AuthorRepo) Put(ctx context.Context, author *model.Author) error {
+	collection := db.Database.Collection("authors")
+
+	_, err := collection.ReplaceOne(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("_id", author.ID)),
+		author,
+		replaceopt.Upsert(true),
+	)
+
+	return mongoError(err)
+}
@@ -0,0 +1,885 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/search"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// booksBucket books by id
+	booksBucket = []byte("books")
+	// isbnBucket book ids by ISBN-13, keeps ISBN unique
+	isbnBucket = []byte("books_isbn_13")
+	// trashBucket ids of the trashed books, trash is checked without reading books
+	trashBucket = []byte("books_trash")
+	// metaBucket settings of the file, the version of its indexes
+	metaBucket = []byte("meta")
+
+	// indexVersionKey key of the index version in metaBucket
+	indexVersionKey = []byte("index_version")
+	// errStopWalk ends a walk of a bucket early
+	errStopWalk = errors.New("stop walk")
+)
+
+// indexVersion version of the index buckets encoding, the indexes are rebuilt when a file has another version.
+// 2: string values start with their collation key, trashed books are listed into trashBucket
+// 3: string values break collation ties with their tie break key, lower case first
+const indexVersion = "3"
+
+// boltIndexes fields having a secondary index bucket, keys are the encoded value followed by the book id
+var boltIndexes = []string{"author", "genre", "rating", "publication_year"}
+
+// streamBatchSize number of books read by each transaction of a stream
+const streamBatchSize = 500
+
+// idSorting creation order, used when every book is read
+var idSorting = &model.Sorting{Sort: "_id", Direction: 1}
+
+// BoltRepo book repository storing books into an embedded bbolt file. Lists read the index bucket of the first
+// filtered or sorted field having one, strings are ordered by their `model.CollationKey` then `model.TieBreakKey`
+// which approximate the en collation
+type BoltRepo struct {
+	interfaces.IBookRepository
+
+	DB *bolt.DB
+	// Strict fail reads on books which can't be decoded, they are skipped with a warning otherwise
+	Strict bool
+}
+
+// OpenBolt open or create the bbolt file at path, with every bucket used by the bolt repositories
+func OpenBolt(path string) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		buckets := [][]byte{booksBucket, isbnBucket, trashBucket, metaBucket, revisionsBucket, authorsBucket, genresBucket, seriesBucket, worksBucket}
+		for _, field := range boltIndexes {
+			buckets = append(buckets, indexBucket(field))
+		}
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		if version := tx.Bucket(metaBucket).Get(indexVersionKey); string(version) != indexVersion {
+			return reindex(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// List return a page of books. Books are counted from the indexes and only the page is read when filters are on
+// trash and a single indexed field and books are sorted by `_id` or a single indexed field, every matching book
+// is read otherwise
+func (repo *BoltRepo) List(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, pagination *model.Pagination) (*model.BookPage, error) {
+	activeSorting, walk, limit := listSettings(sorting, pagination)
+
+	var books []model.Book
+	var total int64
+	var warnings []string
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		r := &bookReader{tx: tx, strict: repo.Strict}
+		defer func() { warnings = r.warnings }()
+
+		var indexed bool
+		var err error
+		if books, total, indexed, err = listIndexed(r, filters, walk, pagination, limit); indexed || err != nil {
+			return err
+		}
+
+		books = []model.Book{}
+		err = scanBooks(r, filters, walk, func(book *model.Book) error {
+			books = append(books, *book)
+			return nil
+		})
+		total = int64(len(books))
+		books = windowBooks(books, walk, pagination, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	page := newBookPage(books, total, limit, pagination, activeSorting)
+	page.Warnings = warnings
+	return page, nil
+}
+
+// Stream call fn for each book matching filters, stopping at the first error.
+//
+// The ids of the matching books are read first, then books are read streamBatchSize at a time and fn is called
+// between transactions, so a slow reader doesn't hold a read transaction. Books changed in between are read as
+// they are now and skipped when they don't match anymore.
+func (repo *BoltRepo) Stream(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, fn func(book *model.Book) error) error {
+	var ids []objectid.ObjectID
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		return scanBooks(&bookReader{tx: tx, strict: repo.Strict}, filters, sorting, func(book *model.Book) error {
+			ids = append(ids, book.ID)
+			return ctx.Err()
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > streamBatchSize {
+			batch = batch[:streamBatchSize]
+		}
+		ids = ids[len(batch):]
+
+		books := make([]model.Book, 0, len(batch))
+		err := repo.DB.View(func(tx *bolt.Tx) error {
+			r := &bookReader{tx: tx, strict: repo.Strict}
+			for _, ID := range batch {
+				if isTrashed(tx, ID) != filters.Trashed {
+					continue
+				}
+				book, err := r.get(ID)
+				if err != nil {
+					return err
+				}
+				if book != nil && matchFilters(book, filters) {
+					books = append(books, *book)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range books {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fn(&books[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Search full text search books, ranked by `search.Search`.
+//
+// There is no text index, every book is a candidate.
+func (repo *BoltRepo) Search(ctx context.Context, query string, limit int64) (*model.SearchPage, error) {
+	if len(search.Terms(query)) == 0 {
+		return search.Search(nil, query, limit), nil
+	}
+
+	books := []model.Book{}
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		return scanBooks(&bookReader{tx: tx, strict: repo.Strict}, &model.BookFilter{}, idSorting, func(book *model.Book) error {
+			books = append(books, *book)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return search.Search(books, query, limit), nil
+}
+
+// Create add a new book
+func (repo *BoltRepo) Create(ctx context.Context, book *model.Book) (*model.Book, error) {
+	created := cloneBook(book)
+	created.ID = objectid.New()
+	created.Version = 1
+	created.CreatedAt = now()
+	created.UpdatedAt = created.CreatedAt
+
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		return putBook(tx, created, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	book.ID = created.ID
+	book.Version = created.Version
+	book.CreatedAt = created.CreatedAt
+	book.UpdatedAt = created.UpdatedAt
+	return book, nil
+}
+
+// Get get a book by ID, trashed books are not returned
+func (repo *BoltRepo) Get(ctx context.Context, ID string) (*model.Book, error) {
+	return repo.get(ID, false)
+}
+
+// GetTrashed get a trashed book by ID
+func (repo *BoltRepo) GetTrashed(ctx context.Context, ID string) (*model.Book, error) {
+	return repo.get(ID, true)
+}
+
+// get get a book by ID either from trash or not
+func (repo *BoltRepo) get(ID string, trashed bool) (*model.Book, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	var book *model.Book
+	err = repo.DB.View(func(tx *bolt.Tx) error {
+		book, err = getBook(tx, objectID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if book == nil || (book.DeletedAt != nil) != trashed {
+		return nil, model.ErrNotFound
+	}
+
+	return book, nil
+}
+
+// Update update a book by ID if it is still at its version
+func (repo *BoltRepo) Update(ctx context.Context, book *model.Book) (*model.Book, error) {
+	fields := []string{}
+	for _, field := range model.FieldNames() {
+		if !model.IsManaged(field) {
+			fields = append(fields, field)
+		}
+	}
+
+	return repo.update(book, fields)
+}
+
+// Patch update only the given fields (bson names) of a book
+func (repo *BoltRepo) Patch(ctx context.Context, book *model.Book, fields []string) (*model.Book, error) {
+	if len(fields) == 0 {
+		return book, nil
+	}
+
+	return repo.update(book, fields)
+}
+
+// update copy fields of book into the stored one if it is still at the same version
+func (repo *BoltRepo) update(book *model.Book, fields []string) (*model.Book, error) {
+	var updated *model.Book
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		stored, err := getBook(tx, book.ID)
+		if err != nil {
+			return err
+		}
+		if stored == nil || stored.Version != book.Version {
+			return model.ErrVersionConflict
+		}
+
+		updated = cloneBook(stored)
+		for _, field := range fields {
+			updated.CopyField(book, field)
+		}
+		updated.Version++
+		updated.UpdatedAt = now()
+
+		return putBook(tx, updated, stored)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	book.Version = updated.Version
+	book.UpdatedAt = updated.UpdatedAt
+	return book, nil
+}
+
+// Delete move a book to trash if it is still at version
+func (repo *BoltRepo) Delete(ctx context.Context, ID objectid.ObjectID, version int64) (int64, error) {
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		stored, err := getBook(tx, ID)
+		if err != nil {
+			return err
+		}
+		if stored == nil || stored.Version != version || stored.DeletedAt != nil {
+			return model.ErrVersionConflict
+		}
+
+		deleted := cloneBook(stored)
+		deletedAt := now()
+		deleted.DeletedAt = &deletedAt
+		deleted.UpdatedAt = deletedAt
+		deleted.Version++
+
+		return putBook(tx, deleted, stored)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+// Restore move a book out of trash
+func (repo *BoltRepo) Restore(ctx context.Context, ID objectid.ObjectID) (*model.Book, error) {
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		stored, err := getBook(tx, ID)
+		if err != nil || stored == nil || stored.DeletedAt == nil {
+			return err
+		}
+
+		restored := cloneBook(stored)
+		restored.DeletedAt = nil
+		restored.UpdatedAt = now()
+		restored.Version++
+
+		return putBook(tx, restored, stored)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.Get(ctx, ID.Hex())
+}
+
+// Purge permanently remove a book, trashed or not
+func (repo *BoltRepo) Purge(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	var purged int64
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		stored, err := getBook(tx, ID)
+		if err != nil || stored == nil {
+			return err
+		}
+		purged = 1
+		return deleteBook(tx, stored)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// PurgeTrash permanently remove books trashed before a date
+func (repo *BoltRepo) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		var trashed []model.Book
+		err := scanBooks(&bookReader{tx: tx, strict: repo.Strict}, &model.BookFilter{Trashed: true}, idSorting, func(book *model.Book) error {
+			if book.DeletedAt.Before(before) {
+				trashed = append(trashed, *book)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for i := range trashed {
+			if err := deleteBook(tx, &trashed[i]); err != nil {
+				return err
+			}
+			purged++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// Put write book under its id as is, replacing the stored book and moving its index entries and trash mark
+func (repo *BoltRepo) Put(ctx context.Context, book *model.Book) error {
+	return repo.DB.Update(func(tx *bolt.Tx) error {
+		stored, err := getBook(tx, book.ID)
+		if err != nil {
+			return err
+		}
+		return putBook(tx, cloneBook(book), stored)
+	})
+}
+
+// scanBooks call fn for each book matching filters, sorted by every sort key then `_id`.
+//
+// Candidates are read from an index when an indexed field is filtered, books are walked in index order
+// when sorted by `_id` or a single indexed field, every book is read otherwise. Trash is checked before books
+// are read.
+func scanBooks(r *bookReader, filters *model.BookFilter, sorting *model.Sorting, fn func(book *model.Book) error) error {
+	active := *sorting
+	if active.Sort == "" {
+		active.Sort = "_id"
+	}
+	if active.Direction == 0 {
+		active.Direction = 1
+	}
+	sorting = &active
+
+	ids, _, indexed := indexCandidates(r.tx, filters)
+	if bucket := sortBucket(sorting); !indexed && bucket != nil {
+		// the index order (or the books bucket order for _id) is the sort order
+		return walkBucket(r.tx.Bucket(bucket), sorting.Direction, nil, func(key []byte) error {
+			ID := keyID(key)
+			if isTrashed(r.tx, ID) != filters.Trashed {
+				return nil
+			}
+			book, err := r.get(ID)
+			if err != nil || book == nil || !matchFilters(book, filters) {
+				return err
+			}
+			return fn(book)
+		})
+	}
+
+	if !indexed {
+		ids = nil
+		r.tx.Bucket(booksBucket).ForEach(func(key []byte, _ []byte) error {
+			ids = append(ids, keyID(key))
+			return nil
+		})
+	}
+
+	books := []model.Book{}
+	for _, ID := range ids {
+		if isTrashed(r.tx, ID) != filters.Trashed {
+			continue
+		}
+		book, err := r.get(ID)
+		if err != nil {
+			return err
+		}
+		if book != nil && matchFilters(book, filters) {
+			books = append(books, *book)
+		}
+	}
+
+	sortBooks(books, sorting)
+	for i := range books {
+		if err := fn(&books[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listIndexed page of books matching filters and the number of matching books, read from the indexes and the trash
+// bucket so only the page is decoded. false when filters need books to be decoded or books aren't sorted by `_id`
+// or a single indexed field
+func listIndexed(r *bookReader, filters *model.BookFilter, walk *model.Sorting, pagination *model.Pagination, limit int64) ([]model.Book, int64, bool, error) {
+	bucket := sortBucket(walk)
+	ids, rest, indexed := indexCandidates(r.tx, filters)
+	if bucket == nil || !reflect.DeepEqual(*rest, model.BookFilter{Trashed: rest.Trashed}) {
+		return nil, 0, false, nil
+	}
+
+	var candidates map[objectid.ObjectID]bool
+	var total int64
+	if indexed {
+		candidates = map[objectid.ObjectID]bool{}
+		for _, ID := range ids {
+			if isTrashed(r.tx, ID) == filters.Trashed {
+				candidates[ID] = true
+			}
+		}
+		total = int64(len(candidates))
+	} else {
+		total = int64(r.tx.Bucket(trashBucket).Stats().KeyN)
+		if !filters.Trashed {
+			total = int64(r.tx.Bucket(booksBucket).Stats().KeyN) - total
+		}
+	}
+
+	var after []byte
+	if cursor := pagination.Cursor; cursor != nil {
+		after = cursor.ID[:]
+		if walk.Sort != "_id" {
+			after = append(indexValue(cursor.Value), cursor.ID[:]...)
+		}
+	}
+
+	// one extra book tells if there is another page
+	books := []model.Book{}
+	var skipped int64
+	err := walkBucket(r.tx.Bucket(bucket), walk.Direction, after, func(key []byte) error {
+		ID := keyID(key)
+		if (indexed && !candidates[ID]) || (!indexed && isTrashed(r.tx, ID) != filters.Trashed) {
+			return nil
+		}
+		if after == nil && skipped < pagination.Offset {
+			skipped++
+			return nil
+		}
+		book, err := r.get(ID)
+		if err != nil || book == nil {
+			return err
+		}
+		books = append(books, *book)
+		if int64(len(books)) > limit {
+			return errStopWalk
+		}
+		return nil
+	})
+	if err != nil && err != errStopWalk {
+		return nil, 0, true, err
+	}
+	return books, total, true, nil
+}
+
+// sortBucket bucket walked in sorting order, nil when books are sorted by several keys or a field without index
+func sortBucket(sorting *model.Sorting) []byte {
+	switch {
+	case len(sorting.Then) > 0:
+		return nil
+	case sorting.Sort == "_id":
+		return booksBucket
+	case isIndexed(sorting.Sort):
+		return indexBucket(sorting.Sort)
+	}
+	return nil
+}
+
+// indexCandidates ids of the books that can match filters according to the first usable index, with the filters
+// left to check on the books. false when no filter is on an indexed field
+func indexCandidates(tx *bolt.Tx, filters *model.BookFilter) ([]objectid.ObjectID, *model.BookFilter, bool) {
+	rest := *filters
+	equal := func(field string, values ...interface{}) []objectid.ObjectID {
+		var keys [][]byte
+		for _, value := range values {
+			keys = append(keys, indexValue(value))
+		}
+		return unionIndex(tx.Bucket(indexBucket(field)), keys)
+	}
+
+	// the author filter also matches author contributors, which the author index doesn't hold
+	switch {
+	case filters.Genre != "":
+		rest.Genre = ""
+		return equal("genre", filters.Genre), &rest, true
+	case filters.Rating != 0:
+		rest.Rating = 0
+		return equal("rating", filters.Rating), &rest, true
+	case filters.YearOfPublication != 0:
+		rest.YearOfPublication = 0
+		return equal("publication_year", filters.YearOfPublication), &rest, true
+	case len(filters.Genres) > 0:
+		rest.Genres = nil
+		var values []interface{}
+		for _, genre := range filters.Genres {
+			values = append(values, genre)
+		}
+		return equal("genre", values...), &rest, true
+	}
+
+	for i, condition := range filters.Conditions {
+		if !isIndexed(condition.Field) {
+			continue
+		}
+
+		bucket := tx.Bucket(indexBucket(condition.Field))
+		values, _ := condition.Value.([]interface{})
+		var ids []objectid.ObjectID
+		switch condition.Operator {
+		case model.OpEq:
+			ids = equal(condition.Field, condition.Value)
+		case model.OpIn:
+			ids = equal(condition.Field, values...)
+		case model.OpGt, model.OpGte:
+			ids = scanIndex(bucket, indexValue(condition.Value), condition.Operator == model.OpGte, nil, false)
+		case model.OpLt, model.OpLte:
+			ids = scanIndex(bucket, nil, false, indexValue(condition.Value), condition.Operator == model.OpLte)
+		case model.OpBetween:
+			ids = scanIndex(bucket, indexValue(values[0]), true, indexValue(values[1]), true)
+		default:
+			continue
+		}
+
+		rest.Conditions = append(append([]model.FilterCondition(nil), filters.Conditions[:i]...), filters.Conditions[i+1:]...)
+		if len(rest.Conditions) == 0 {
+			rest.Conditions = nil
+		}
+		return ids, &rest, true
+	}
+
+	return nil, &rest, false
+}
+
+// scanIndex ids of the index entries between two encoded values, nil bounds are open
+func scanIndex(bucket *bolt.Bucket, low []byte, lowInclusive bool, high []byte, highInclusive bool) []objectid.ObjectID {
+	ids := []objectid.ObjectID{}
+
+	c := bucket.Cursor()
+	var key []byte
+	if low != nil {
+		key, _ = c.Seek(low)
+	} else {
+		key, _ = c.First()
+	}
+
+	for ; key != nil; key, _ = c.Next() {
+		value := key[:len(key)-len(objectid.ObjectID{})]
+		if !lowInclusive && bytes.Equal(value, low) {
+			continue
+		}
+		if high != nil {
+			if c := bytes.Compare(value, high); c > 0 || (c == 0 && !highInclusive) {
+				break
+			}
+		}
+		ids = append(ids, keyID(key))
+	}
+
+	return ids
+}
+
+// unionIndex ids of the index entries equal to any of the encoded values, each id once even when values repeat
+func unionIndex(bucket *bolt.Bucket, keys [][]byte) []objectid.ObjectID {
+	ids := []objectid.ObjectID{}
+	seen := map[objectid.ObjectID]bool{}
+	for _, key := range keys {
+		for _, ID := range scanIndex(bucket, key, true, key, true) {
+			if !seen[ID] {
+				seen[ID] = true
+				ids = append(ids, ID)
+			}
+		}
+	}
+	return ids
+}
+
+// walkBucket call fn with every key of bucket strictly after the key after, in reverse order when direction is
+// negative. A nil after walks every key, errStopWalk returned by fn ends the walk
+func walkBucket(bucket *bolt.Bucket, direction int32, after []byte, fn func(key []byte) error) error {
+	c := bucket.Cursor()
+	next := c.Next
+	if direction < 0 {
+		next = c.Prev
+	}
+
+	var key []byte
+	switch {
+	case after == nil && direction < 0:
+		key, _ = c.Last()
+	case after == nil:
+		key, _ = c.First()
+	case direction < 0:
+		// the last key before after
+		if key, _ = c.Seek(after); key == nil {
+			key, _ = c.Last()
+		} else {
+			key, _ = c.Prev()
+		}
+	default:
+		if key, _ = c.Seek(after); key != nil && bytes.Equal(key, after) {
+			key, _ = c.Next()
+		}
+	}
+
+	for ; key != nil; key, _ = next() {
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bookReader read books of a transaction, books which can't be decoded are skipped with a warning unless strict
+type bookReader struct {
+	tx       *bolt.Tx
+	strict   bool
+	warnings []string
+}
+
+// get read a book by id, nil when it doesn't exist or is skipped
+func (r *bookReader) get(ID objectid.ObjectID) (*model.Book, error) {
+	book, err := getBook(r.tx, ID)
+	if err != nil {
+		if r.strict {
+			return nil, err
+		}
+		log.Printf("Skipping book: %s", err)
+		r.warnings = append(r.warnings, err.Error())
+		return nil, nil
+	}
+	return book, nil
+}
+
+// isTrashed check if a stored book is in trash without reading it
+func isTrashed(tx *bolt.Tx, ID objectid.ObjectID) bool {
+	return tx.Bucket(trashBucket).Get(ID[:]) != nil
+}
+
+// getBook read a book by id, nil when it doesn't exist
+func getBook(tx *bolt.Tx, ID objectid.ObjectID) (*model.Book, error) {
+	data := tx.Bucket(booksBucket).Get(ID[:])
+	if data == nil {
+		return nil, nil
+	}
+
+	book := &model.Book{}
+	if err := json.Unmarshal(data, book); err != nil {
+		return nil, model.Undecodable(ID.Hex(), err)
+	}
+	return book, nil
+}
+
+// putBook write a book and its index entries, previous is the stored book it replaces
+func putBook(tx *bolt.Tx, book *model.Book, previous *model.Book) error {
+	isbn := tx.Bucket(isbnBucket)
+	if book.ISBN13 != "" {
+		if owner := isbn.Get([]byte(book.ISBN13)); owner != nil && !bytes.Equal(owner, book.ID[:]) {
+			return model.ErrDuplicateISBN
+		}
+	}
+
+	if previous != nil {
+		if err := deleteIndexes(tx, previous); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(book)
+	if err != nil {
+		return err
+	}
+	if err := tx.Bucket(booksBucket).Put(book.ID[:], data); err != nil {
+		return err
+	}
+
+	if book.ISBN13 != "" {
+		if err := isbn.Put([]byte(book.ISBN13), book.ID[:]); err != nil {
+			return err
+		}
+	}
+	return putIndexes(tx, book)
+}
+
+// putIndexes write the index entries of a book and its trash entry
+func putIndexes(tx *bolt.Tx, book *model.Book) error {
+	for _, field := range boltIndexes {
+		if err := tx.Bucket(indexBucket(field)).Put(indexKey(book, field), []byte{}); err != nil {
+			return err
+		}
+	}
+	if book.DeletedAt != nil {
+		return tx.Bucket(trashBucket).Put(book.ID[:], []byte{})
+	}
+	return nil
+}
+
+// reindex rebuild the index and trash buckets from the stored books, books which can't be decoded are left out
+func reindex(tx *bolt.Tx) error {
+	buckets := [][]byte{trashBucket}
+	for _, field := range boltIndexes {
+		buckets = append(buckets, indexBucket(field))
+	}
+	for _, name := range buckets {
+		if err := tx.DeleteBucket(name); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		if _, err := tx.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+
+	// buckets aren't written while they are walked
+	var ids []objectid.ObjectID
+	tx.Bucket(booksBucket).ForEach(func(key []byte, _ []byte) error {
+		ids = append(ids, keyID(key))
+		return nil
+	})
+	for _, ID := range ids {
+		book, err := getBook(tx, ID)
+		if err != nil {
+			log.Printf("Not indexing book: %s", err)
+			continue
+		}
+		if err := putIndexes(tx, book); err != nil {
+			return err
+		}
+	}
+
+	return tx.Bucket(metaBucket).Put(indexVersionKey, []byte(indexVersion))
+}
+
+// deleteBook remove a book and its index entries
+func deleteBook(tx *bolt.Tx, book *model.Book) error {
+	if err := deleteIndexes(tx, book); err != nil {
+		return err
+	}
+	return tx.Bucket(booksBucket).Delete(book.ID[:])
+}
+
+// deleteIndexes remove the index entries of a stored book
+func deleteIndexes(tx *bolt.Tx, book *model.Book) error {
+	if book.ISBN13 != "" {
+		if err := tx.Bucket(isbnBucket).Delete([]byte(book.ISBN13)); err != nil {
+			return err
+		}
+	}
+	for _, field := range boltIndexes {
+		if err := tx.Bucket(indexBucket(field)).Delete(indexKey(book, field)); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(trashBucket).Delete(book.ID[:])
+}
+
+// indexBucket name of the index bucket of a field
+func indexBucket(field string) []byte {
+	return []byte("books_index_" + field)
+}
+
+// isIndexed check if field has an index bucket
+func isIndexed(field string) bool {
+	for _, indexed := range boltIndexes {
+		if field == indexed {
+			return true
+		}
+	}
+	return false
+}
+
+// indexKey index entry of a book for field, sorted by value then id
+func indexKey(book *model.Book, field string) []byte {
+	return append(indexValue(book.FieldValue(field)), book.ID[:]...)
+}
+
+// indexValue encode a value so encoded values sort like the values.
+//
+// Strings are their collation key then their tie break key so they sort like `model.CompareStrings`, each ends
+// with a 0 byte so a prefix sorts first. Integers are big endian with the sign bit flipped.
+func indexValue(value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		b := append([]byte(model.CollationKey(v)), 0)
+		return append(append(b, model.TieBreakKey(v)...), 0)
+	case int64:
+		b := make([]byte, 8)
+		binary.BigEndian.PutUint64(b, uint64(v)^(1<<63))
+		return b
+	}
+	return nil
+}
+
+// keyID book id at the end of a books bucket or index bucket key
+func keyID(key []byte) objectid.ObjectID {
+	var ID objectid.ObjectID
+	copy(ID[:], key[len(key)-len(ID):])
+	return ID
+}
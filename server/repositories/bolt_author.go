@@ -0,0 +1,177 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// authorsBucket authors by id
+var authorsBucket = []byte("authors")
+
+// BoltAuthorRepo authors repository storing authors into an embedded bbolt file
+type BoltAuthorRepo struct {
+	interfaces.IAuthorRepository
+
+	DB *bolt.DB
+}
+
+// List get every author sorted by sort name, only the ones named name (or aliased) when it isn't empty
+func (repo *BoltAuthorRepo) List(ctx context.Context, name string) ([]model.Author, error) {
+	authors := []model.Author{}
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(authorsBucket).ForEach(func(_ []byte, data []byte) error {
+			author := model.Author{}
+			if err := json.Unmarshal(data, &author); err != nil {
+				return err
+			}
+			if name == "" || author.HasName(name) {
+				authors = append(authors, author)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	model.SortAuthors(authors)
+	return authors, nil
+}
+
+// Get get an author by ID
+func (repo *BoltAuthorRepo) Get(ctx context.Context, ID string) (*model.Author, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	var author *model.Author
+	err = repo.DB.View(func(tx *bolt.Tx) error {
+		author, err = getAuthor(tx, objectID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if author == nil {
+		return nil, model.ErrAuthorNotFound
+	}
+
+	return author, nil
+}
+
+// GetMany get authors by ID in the order of IDs, missing authors are left out
+func (repo *BoltAuthorRepo) GetMany(ctx context.Context, IDs []objectid.ObjectID) ([]model.Author, error) {
+	authors := []model.Author{}
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		for _, ID := range IDs {
+			author, err := getAuthor(tx, ID)
+			if err != nil {
+				return err
+			}
+			if author != nil {
+				authors = append(authors, *author)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return authors, nil
+}
+
+// Create add a new author
+func (repo *BoltAuthorRepo) Create(ctx context.Context, author *model.Author) (*model.Author, error) {
+	author.ID = objectid.New()
+	author.CreatedAt = now()
+	author.UpdatedAt = author.CreatedAt
+
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		return putAuthor(tx, author)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return author, nil
+}
+
+// Update replace an author by ID
+func (repo *BoltAuthorRepo) Update(ctx context.Context, author *model.Author) (*model.Author, error) {
+	updated := *author
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		stored, err := getAuthor(tx, author.ID)
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return model.ErrAuthorNotFound
+		}
+
+		updated.CreatedAt = stored.CreatedAt
+		updated.UpdatedAt = now()
+		return putAuthor(tx, &updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	author.CreatedAt = updated.CreatedAt
+	author.UpdatedAt = updated.UpdatedAt
+	return author, nil
+}
+
+// Delete remove an author by ID
+func (repo *BoltAuthorRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	var deleted int64
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(authorsBucket)
+		if bucket.Get(ID[:]) == nil {
+			return nil
+		}
+		deleted = 1
+		return bucket.Delete(ID[:])
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// Put write author as is under its id into the authors bucket, replacing the stored one
+func (repo *BoltAuthorRepo) Put(ctx context.Context, author *model.Author) error {
+	return repo.DB.Update(func(tx *bolt.Tx) error {
+		return putAuthor(tx, author)
+	})
+}
+
+// getAuthor read an author by id, nil when it doesn't exist
+func getAuthor(tx *bolt.Tx, ID objectid.ObjectID) (*model.Author, error) {
+	data := tx.Bucket(authorsBucket).Get(ID[:])
+	if data == nil {
+		return nil, nil
+	}
+
+	author := &model.Author{}
+	if err := json.Unmarshal(data, author); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// putAuthor write an author
+func putAuthor(tx *bolt.Tx, author *model.Author) error {
+	data, err := json.Marshal(author)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(authorsBucket).Put(author.ID[:], data)
+}
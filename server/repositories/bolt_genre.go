@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// genresBucket genres by id
+var genresBucket = []byte("genres")
+
+// BoltGenreRepo genres repository storing genres into an embedded bbolt file
+type BoltGenreRepo struct {
+	interfaces.IGenreRepository
+
+	DB *bolt.DB
+}
+
+// List get every genre sorted by name
+func (repo *BoltGenreRepo) List(ctx context.Context) ([]model.Genre, error) {
+	genres := []model.Genre{}
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(genresBucket).ForEach(func(_ []byte, data []byte) error {
+			genre := model.Genre{}
+			if err := json.Unmarshal(data, &genre); err != nil {
+				return err
+			}
+			genres = append(genres, genre)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	model.SortGenres(genres)
+	return genres, nil
+}
+
+// Get get a genre by ID
+func (repo *BoltGenreRepo) Get(ctx context.Context, ID string) (*model.Genre, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	var genre *model.Genre
+	err = repo.DB.View(func(tx *bolt.Tx) error {
+		genre, err = getGenre(tx, objectID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if genre == nil {
+		return nil, model.ErrGenreNotFound
+	}
+
+	return genre, nil
+}
+
+// Create add a new genre
+func (repo *BoltGenreRepo) Create(ctx context.Context, genre *model.Genre) (*model.Genre, error) {
+	genre.ID = objectid.New()
+	genre.CreatedAt = now()
+	genre.UpdatedAt = genre.CreatedAt
+
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		return putGenre(tx, genre)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return genre, nil
+}
+
+// Update replace a genre by ID
+func (repo *BoltGenreRepo) Update(ctx context.Context, genre *model.Genre) (*model.Genre, error) {
+	updated := *genre
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		stored, err := getGenre(tx, genre.ID)
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return model.ErrGenreNotFound
+		}
+
+		updated.CreatedAt = stored.CreatedAt
+		updated.UpdatedAt = now()
+		return putGenre(tx, &updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	genre.CreatedAt = updated.CreatedAt
+	genre.UpdatedAt = updated.UpdatedAt
+	return genre, nil
+}
+
+// Delete remove a genre by ID
+func (repo *BoltGenreRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	var deleted int64
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(genresBucket)
+		if bucket.Get(ID[:]) == nil {
+			return nil
+		}
+		deleted = 1
+		return bucket.Delete(ID[:])
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// Put write genre as is under its id into the genres bucket, replacing the stored one
+func (repo *BoltGenreRepo) Put(ctx context.Context, genre *model.Genre) error {
+	return repo.DB.Update(func(tx *bolt.Tx) error {
+		return putGenre(tx, genre)
+	})
+}
+
+// getGenre read a genre by id, nil when it doesn't exist
+func getGenre(tx *bolt.Tx, ID objectid.ObjectID) (*model.Genre, error) {
+	data := tx.Bucket(genresBucket).Get(ID[:])
+	if data == nil {
+		return nil, nil
+	}
+
+	genre := &model.Genre{}
+	if err := json.Unmarshal(data, genre); err != nil {
+		return nil, err
+	}
+	return genre, nil
+}
+
+// putGenre write a genre
+func putGenre(tx *bolt.Tx, genre *model.Genre) error {
+	data, err := json.Marshal(genre)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(genresBucket).Put(genre.ID[:], data)
+}
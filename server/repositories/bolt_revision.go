@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// revisionsBucket revisions by book id followed by revision number
+var revisionsBucket = []byte("revisions")
+
+// BoltRevisionRepo book revisions repository storing revisions into an embedded bbolt file
+type BoltRevisionRepo struct {
+	interfaces.IRevisionRepository
+
+	DB *bolt.DB
+}
+
+// Append store a new revision, a revision number can't be written twice
+func (repo *BoltRevisionRepo) Append(ctx context.Context, revision *model.Revision) error {
+	return repo.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(revisionsBucket)
+		key := revisionKey(revision.BookID, revision.Number)
+		if bucket.Get(key) != nil {
+			return model.ErrVersionConflict
+		}
+
+		revision.ID = objectid.New()
+		data, err := json.Marshal(revision)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, data)
+	})
+}
+
+// List get every revision of a book, oldest first
+func (repo *BoltRevisionRepo) List(ctx context.Context, bookID objectid.ObjectID) ([]model.Revision, error) {
+	revisions := []model.Revision{}
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(revisionsBucket).Cursor()
+		for key, data := c.Seek(bookID[:]); key != nil && bytes.HasPrefix(key, bookID[:]); key, data = c.Next() {
+			revision := model.Revision{}
+			if err := json.Unmarshal(data, &revision); err != nil {
+				return err
+			}
+			revisions = append(revisions, revision)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Get get a single revision of a book by number
+func (repo *BoltRevisionRepo) Get(ctx context.Context, bookID objectid.ObjectID, number int64) (*model.Revision, error) {
+	var revision *model.Revision
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(revisionsBucket).Get(revisionKey(bookID, number))
+		if data == nil {
+			return model.ErrRevisionNotFound
+		}
+		revision = &model.Revision{}
+		return json.Unmarshal(data, revision)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// revisionKey key of a revision, revisions of a book are sorted by number
+func revisionKey(bookID objectid.ObjectID, number int64) []byte {
+	return append(append([]byte{}, bookID[:]...), indexValue(number)...)
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// seriesBucket series by id
+var seriesBucket = []byte("series")
+
+// BoltSeriesRepo series repository storing series into an embedded bbolt file
+type BoltSeriesRepo struct {
+	interfaces.ISeriesRepository
+
+	DB *bolt.DB
+}
+
+// List get every series sorted by name
+func (repo *BoltSeriesRepo) List(ctx context.Context) ([]model.Series, error) {
+	list := []model.Series{}
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(seriesBucket).ForEach(func(_ []byte, data []byte) error {
+			series := model.Series{}
+			if err := json.Unmarshal(data, &series); err != nil {
+				return err
+			}
+			list = append(list, series)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	model.SortSeries(list)
+	return list, nil
+}
+
+// Get get a series by ID
+func (repo *BoltSeriesRepo) Get(ctx context.Context, ID string) (*model.Series, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	var series *model.Series
+	err = repo.DB.View(func(tx *bolt.Tx) error {
+		series, err = getSeries(tx, objectID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if series == nil {
+		return nil, model.ErrSeriesNotFound
+	}
+
+	return series, nil
+}
+
+// Create add a new series
+func (repo *BoltSeriesRepo) Create(ctx context.Context, series *model.Series) (*model.Series, error) {
+	series.ID = objectid.New()
+	series.CreatedAt = now()
+	series.UpdatedAt = series.CreatedAt
+
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		return putSeries(tx, series)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// Update replace a series by ID
+func (repo *BoltSeriesRepo) Update(ctx context.Context, series *model.Series) (*model.Series, error) {
+	updated := *series
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		stored, err := getSeries(tx, series.ID)
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return model.ErrSeriesNotFound
+		}
+
+		updated.CreatedAt = stored.CreatedAt
+		updated.UpdatedAt = now()
+		return putSeries(tx, &updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	series.CreatedAt = updated.CreatedAt
+	series.UpdatedAt = updated.UpdatedAt
+	return series, nil
+}
+
+// Delete remove a series by ID
+func (repo *BoltSeriesRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	var deleted int64
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(seriesBucket)
+		if bucket.Get(ID[:]) == nil {
+			return nil
+		}
+		deleted = 1
+		return bucket.Delete(ID[:])
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// Put write series as is under its id into the series bucket, replacing the stored one
+func (repo *BoltSeriesRepo) Put(ctx context.Context, series *model.Series) error {
+	return repo.DB.Update(func(tx *bolt.Tx) error {
+		return putSeries(tx, series)
+	})
+}
+
+// getSeries read a series by id, nil when it doesn't exist
+func getSeries(tx *bolt.Tx, ID objectid.ObjectID) (*model.Series, error) {
+	data := tx.Bucket(seriesBucket).Get(ID[:])
+	if data == nil {
+		return nil, nil
+	}
+
+	series := &model.Series{}
+	if err := json.Unmarshal(data, series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// putSeries write a series
+func putSeries(tx *bolt.Tx, series *model.Series) error {
+	data, err := json.Marshal(series)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(seriesBucket).Put(series.ID[:], data)
+}
@@ -0,0 +1,391 @@
+package repositories
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTestBolt(t *testing.T) (*Storage, func()) {
+	dir, err := ioutil.TempDir("", "bookshelf")
+	assert.NoError(t, err)
+
+	storage, err := OpenStorage(StorageBolt, Options{BoltPath: filepath.Join(dir, "bookshelf.db")})
+	assert.NoError(t, err)
+
+	return storage, func() {
+		storage.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestBoltRepoList(t *testing.T) {
+	ctx := context.Background()
+	storage, cleanup := openTestBolt(t)
+	defer cleanup()
+	repo := storage.Books
+
+	for _, book := range []*model.Book{
+		{Title: "Sardines", Author: "Doyon", Rating: 5, YearOfPublication: 2001},
+		{Title: "Trawler", Author: "Doyon", Rating: 4, YearOfPublication: 1999},
+		{Title: "Seagulls", Author: "Cantona", Rating: 4, YearOfPublication: 2005},
+		{Title: "Boat", Author: "Do", Rating: 2, YearOfPublication: 1999},
+	} {
+		_, err := repo.Create(ctx, book)
+		assert.NoError(t, err)
+	}
+
+	page, err := repo.List(ctx, &model.BookFilter{Author: "Doyon"}, &model.Sorting{Sort: "title", Direction: 1}, &model.Pagination{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Sardines", "Trawler"}, titles(page), "It should not match a prefix of the author")
+
+	between, _ := model.NewFilterCondition("publication_year", model.OpBetween, "1999,2001")
+	gt, _ := model.NewFilterCondition("rating", model.OpGt, "2")
+	page, _ = repo.List(ctx, &model.BookFilter{Conditions: []model.FilterCondition{*between, *gt}}, &model.Sorting{Sort: "rating", Direction: -1}, &model.Pagination{})
+	assert.Equal(t, []string{"Sardines", "Trawler"}, titles(page))
+
+	sorting := &model.Sorting{Sort: "publication_year", Direction: -1}
+	page, _ = repo.List(ctx, &model.BookFilter{}, sorting, &model.Pagination{Limit: 3})
+	assert.Equal(t, int64(4), page.Total)
+	assert.Equal(t, []string{"Seagulls", "Sardines", "Boat"}, titles(page), "It should break ties with _id")
+
+	cursor, _ := model.DecodeCursor(page.NextCursor, sorting)
+	page, _ = repo.List(ctx, &model.BookFilter{}, sorting, &model.Pagination{Limit: 3, Cursor: cursor})
+	assert.Equal(t, []string{"Trawler"}, titles(page))
+}
+
+func TestBoltRepoListAuthorOrder(t *testing.T) {
+	ctx := context.Background()
+	storage, cleanup := openTestBolt(t)
+	defer cleanup()
+	repo := storage.Books
+
+	for _, author := range []string{"alice", "Zed", "Émile", "bob", "Bob"} {
+		repo.Create(ctx, &model.Book{Title: author, Author: author})
+	}
+
+	sorting := &model.Sorting{Sort: "author", Direction: 1}
+	page, _ := repo.List(ctx, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2})
+	assert.Equal(t, []string{"alice", "bob"}, titles(page), "It should walk the author index in collation order")
+	assert.Equal(t, int64(5), page.Total)
+
+	cursor, _ := model.DecodeCursor(page.NextCursor, sorting)
+	page, _ = repo.List(ctx, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Cursor: cursor})
+	assert.Equal(t, []string{"Bob", "Émile"}, titles(page))
+
+	cursor, _ = model.DecodeCursor(page.PrevCursor, sorting)
+	page, _ = repo.List(ctx, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Cursor: cursor})
+	assert.Equal(t, []string{"alice", "bob"}, titles(page))
+
+	page, _ = repo.List(ctx, &model.BookFilter{}, &model.Sorting{Sort: "author", Direction: -1}, &model.Pagination{Limit: 2, Offset: 1})
+	assert.Equal(t, []string{"Émile", "Bob"}, titles(page))
+}
+
+func TestBoltRepoUndecodable(t *testing.T) {
+	ctx := context.Background()
+	storage, cleanup := openTestBolt(t)
+	defer cleanup()
+	repo := storage.Books.(*BoltRepo)
+
+	for _, title := range []string{"Sardines", "Trawler", "Anchor"} {
+		repo.Create(ctx, &model.Book{Title: title})
+	}
+	// a title stored as a number can't be decoded into a book
+	ID := objectid.New()
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(booksBucket).Put(ID[:], []byte(`{"title":42}`))
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	page, err := repo.List(ctx, &model.BookFilter{}, &model.Sorting{Sort: "_id", Direction: 1}, &model.Pagination{Limit: 1})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"Sardines"}, titles(page))
+		assert.Equal(t, int64(4), page.Total, "It should count books from the keys")
+		assert.Empty(t, page.Warnings, "It should only read the page")
+	}
+
+	page, err = repo.List(ctx, &model.BookFilter{}, &model.Sorting{Sort: "title", Direction: 1}, &model.Pagination{})
+	if assert.NoError(t, err, "It should skip undecodable books") {
+		assert.Equal(t, []string{"Anchor", "Sardines", "Trawler"}, titles(page))
+		if assert.Len(t, page.Warnings, 1) {
+			assert.Contains(t, page.Warnings[0], ID.Hex())
+		}
+	}
+
+	var streamed int
+	err = repo.Stream(ctx, &model.BookFilter{}, &model.Sorting{}, func(book *model.Book) error {
+		streamed++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, streamed)
+
+	repo.Strict = true
+	_, err = repo.List(ctx, &model.BookFilter{}, &model.Sorting{Sort: "title", Direction: 1}, &model.Pagination{})
+	if assert.IsType(t, &model.Error{}, err, "It should fail on undecodable books when strict") {
+		assert.Equal(t, model.CodeUndecodable, err.(*model.Error).Code)
+	}
+}
+
+func TestBoltReindex(t *testing.T) {
+	ctx := context.Background()
+	dir, _ := ioutil.TempDir("", "bookshelf")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bookshelf.db")
+
+	storage, err := OpenStorage(StorageBolt, Options{BoltPath: path})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	storage.Books.Create(ctx, &model.Book{Title: "Sardines", Author: "Émile"})
+	trawler, _ := storage.Books.Create(ctx, &model.Book{Title: "Trawler", Author: "Zed"})
+	storage.Books.Delete(ctx, trawler.ID, 1)
+	storage.Close()
+
+	// files written before the index version have neither the version nor the trash bucket
+	db, _ := OpenBolt(path)
+	db.Update(func(tx *bolt.Tx) error {
+		tx.Bucket(metaBucket).Delete(indexVersionKey)
+		return tx.DeleteBucket(trashBucket)
+	})
+	db.Close()
+
+	storage, err = OpenStorage(StorageBolt, Options{BoltPath: path})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer storage.Close()
+
+	page, _ := storage.Books.List(ctx, &model.BookFilter{}, &model.Sorting{}, &model.Pagination{})
+	assert.Equal(t, []string{"Sardines"}, titles(page), "It should rebuild the trash index")
+	assert.Equal(t, int64(1), page.Total)
+	page, _ = storage.Books.List(ctx, &model.BookFilter{Trashed: true, Author: "Zed"}, &model.Sorting{}, &model.Pagination{})
+	assert.Equal(t, []string{"Trawler"}, titles(page))
+}
+
+func TestBoltRepoWrites(t *testing.T) {
+	ctx := context.Background()
+	storage, cleanup := openTestBolt(t)
+	defer cleanup()
+	repo := storage.Books
+
+	created, _ := repo.Create(ctx, &model.Book{Title: "Sardines", Author: "Doyon", ISBN13: "9780306406157"})
+	_, err := repo.Create(ctx, &model.Book{Title: "Copy", ISBN13: "9780306406157"})
+	assert.Equal(t, model.ErrDuplicateISBN, err)
+
+	_, err = repo.Get(ctx, objectid.New().Hex())
+	assert.Equal(t, model.ErrNotFound, err)
+
+	book, _ := repo.Get(ctx, created.ID.Hex())
+	book.Author = "Cantona"
+	book.ISBN13 = ""
+	_, err = repo.Update(ctx, book)
+	assert.NoError(t, err)
+
+	page, _ := repo.List(ctx, &model.BookFilter{Author: "Doyon"}, &model.Sorting{}, &model.Pagination{})
+	assert.Empty(t, page.Books, "It should remove stale index entries")
+	_, err = repo.Create(ctx, &model.Book{Title: "Copy", ISBN13: "9780306406157"})
+	assert.NoError(t, err, "It should free the previous ISBN")
+
+	_, err = repo.Patch(ctx, &model.Book{ID: created.ID, Version: 1, Title: "Stale"}, []string{"title"})
+	assert.Equal(t, model.ErrVersionConflict, err)
+
+	repo.Delete(ctx, created.ID, 2)
+	page, _ = repo.List(ctx, &model.BookFilter{Author: "Cantona"}, &model.Sorting{}, &model.Pagination{})
+	assert.Empty(t, page.Books, "It should hide trashed books")
+
+	purged, _ := repo.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	assert.Equal(t, int64(1), purged)
+	_, err = repo.GetTrashed(ctx, created.ID.Hex())
+	assert.Equal(t, model.ErrNotFound, err)
+}
+
+func TestBoltRepoStream(t *testing.T) {
+	ctx := context.Background()
+	storage, cleanup := openTestBolt(t)
+	defer cleanup()
+	repo := storage.Books.(*BoltRepo)
+	repo.DB.NoSync = true
+
+	var last *model.Book
+	for i := 0; i <= streamBatchSize; i++ {
+		last, _ = repo.Create(ctx, &model.Book{Title: "Sardines", Author: "Doyon"})
+	}
+
+	streamed := 0
+	err := repo.Stream(ctx, &model.BookFilter{Author: "Doyon"}, idSorting, func(book *model.Book) error {
+		if streamed == 0 {
+			// no read transaction is open while books are handed out
+			_, err := repo.Delete(ctx, last.ID, last.Version)
+			assert.NoError(t, err)
+		}
+		streamed++
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, streamBatchSize, streamed, "It should skip books trashed by a later batch")
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	storage, cleanup := openTestBolt(t)
+	defer cleanup()
+
+	source, _ := OpenStorage(StorageMemory, Options{})
+	history := NewHistoryRepo(source.Books, source.Revisions)
+	author, _ := source.Authors.Create(ctx, &model.Author{Name: "Mathieu Doyon"})
+	genre, _ := source.Genres.Create(ctx, &model.Genre{Name: "Fiction", Synonyms: []string{"Novel"}})
+	series, _ := source.Series.Create(ctx, &model.Series{Name: "Sea", Volumes: 3})
+	work, _ := source.Works.Create(ctx, &model.Work{Title: "Sardines"})
+	live, _ := history.Create(ctx, &model.Book{Title: "Sardines", Rating: 4, AuthorIDs: []objectid.ObjectID{author.ID}, WorkID: work.ID})
+	trashed, _ := history.Create(ctx, &model.Book{Title: "Trawler"})
+	history.Delete(ctx, trashed.ID, 1)
+
+	for i := 0; i < 2; i++ {
+		copied, err := Migrate(ctx, source, storage)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), copied)
+	}
+
+	book, err := storage.Books.Get(ctx, live.ID.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, live.CreatedAt.Unix(), book.CreatedAt.Unix())
+	assert.Equal(t, work.ID, book.WorkID)
+	_, err = storage.Books.GetTrashed(ctx, trashed.ID.Hex())
+	assert.NoError(t, err)
+
+	revisions, _ := storage.Revisions.List(ctx, trashed.ID)
+	assert.Len(t, revisions, 2)
+
+	_, err = storage.Authors.Get(ctx, author.ID.Hex())
+	assert.NoError(t, err, "It should copy authors")
+	copiedGenre, err := storage.Genres.Get(ctx, genre.ID.Hex())
+	if assert.NoError(t, err, "It should copy genres") {
+		assert.Equal(t, []string{"Novel"}, copiedGenre.Synonyms)
+	}
+	copiedSeries, err := storage.Series.Get(ctx, series.ID.Hex())
+	if assert.NoError(t, err, "It should copy series") {
+		assert.Equal(t, int64(3), copiedSeries.Volumes)
+	}
+	_, err = storage.Works.Get(ctx, work.ID.Hex())
+	assert.NoError(t, err, "It should copy works")
+}
+
+func TestSeedGenres(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := OpenStorage(StorageMemory, Options{})
+
+	for i, expected := range []int64{int64(len(model.DefaultGenres)), 0} {
+		seeded, err := SeedGenres(ctx, storage.Genres)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, seeded, "run %d", i)
+	}
+	genres, _ := storage.Genres.List(ctx)
+	assert.Len(t, genres, len(model.DefaultGenres), "It should only seed an empty store")
+}
+
+func TestMigrateAuthors(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := OpenStorage(StorageMemory, Options{})
+	history := NewHistoryRepo(storage.Books, storage.Revisions).WithActor("migrate")
+
+	doyon := &model.Author{Name: "Mathieu Doyon", Aliases: []string{"M. Doyon"}}
+	doyon.Normalize()
+	storage.Authors.Create(ctx, doyon)
+	sardines, _ := history.Create(ctx, &model.Book{Title: "Sardines", Author: "M. Doyon"})
+	seagulls, _ := history.Create(ctx, &model.Book{Title: "Seagulls", Author: "Eric Cantona & mathieu doyon"})
+	trawler, _ := history.Create(ctx, &model.Book{Title: "Trawler", Author: "Eric Cantona"})
+	history.Delete(ctx, trawler.ID, 1)
+	boat, _ := history.Create(ctx, &model.Book{Title: "Boat"})
+	translated, _ := history.Create(ctx, &model.Book{Title: "Sardinas", Author: "Eric Cantona", Contributors: []model.Contributor{
+		{Name: "Eric Cantona", Role: model.RoleAuthor},
+		{Name: "M. Doyon", Role: model.RoleTranslator},
+	}})
+
+	for i, expected := range []int64{4, 0} {
+		linked, err := MigrateAuthors(ctx, history, storage.Authors)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, linked, "run %d", i)
+	}
+
+	authors, _ := storage.Authors.List(ctx, "")
+	if !assert.Len(t, authors, 2, "It should create missing authors once") {
+		t.FailNow()
+	}
+	cantona := authors[0]
+	assert.Equal(t, "Eric Cantona", cantona.Name)
+
+	book, _ := storage.Books.Get(ctx, sardines.ID.Hex())
+	assert.Equal(t, []objectid.ObjectID{doyon.ID}, book.AuthorIDs, "It should match aliases")
+	assert.Equal(t, "Mathieu Doyon", book.Author)
+
+	book, _ = storage.Books.Get(ctx, seagulls.ID.Hex())
+	assert.Equal(t, []objectid.ObjectID{cantona.ID, doyon.ID}, book.AuthorIDs)
+	assert.Equal(t, "Eric Cantona & Mathieu Doyon", book.Author)
+
+	book, _ = storage.Books.GetTrashed(ctx, trawler.ID.Hex())
+	assert.Equal(t, []objectid.ObjectID{cantona.ID}, book.AuthorIDs, "It should link trashed books")
+
+	book, _ = storage.Books.Get(ctx, boat.ID.Hex())
+	assert.Empty(t, book.AuthorIDs)
+
+	book, _ = storage.Books.Get(ctx, translated.ID.Hex())
+	assert.Equal(t, []objectid.ObjectID{cantona.ID}, book.AuthorIDs)
+	if assert.Len(t, book.Contributors, 2) {
+		assert.Equal(t, doyon.ID, book.Contributors[1].AuthorID, "It should link contributors in every role")
+		assert.Equal(t, "Mathieu Doyon", book.Contributors[1].Name)
+	}
+
+	revisions, _ := storage.Revisions.List(ctx, sardines.ID)
+	if assert.Len(t, revisions, 2) {
+		assert.Equal(t, "migrate", revisions[1].Actor)
+	}
+}
+
+func TestMigrateWorks(t *testing.T) {
+	ctx := context.Background()
+	storage, _ := OpenStorage(StorageMemory, Options{})
+	history := NewHistoryRepo(storage.Books, storage.Revisions).WithActor("migrate")
+
+	sardines, _ := storage.Works.Create(ctx, &model.Work{Title: "Sardines", Author: "Mathieu Doyon & Eric Cantona"})
+	paperback, _ := history.Create(ctx, &model.Book{Title: "sardines", Author: "Eric Cantona & Mathieu Doyon"})
+	audiobook, _ := history.Create(ctx, &model.Book{Title: "Seagulls", Author: "Eric Cantona", Edition: 2})
+	hardcover, _ := history.Create(ctx, &model.Book{Title: "Seagulls", Author: "Eric Cantona"})
+	history.Delete(ctx, hardcover.ID, 1)
+	other, _ := history.Create(ctx, &model.Book{Title: "Seagulls", Author: "Mathieu Doyon"})
+
+	for i, expected := range []int64{4, 0} {
+		grouped, err := MigrateWorks(ctx, history, storage.Works)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, grouped, "run %d", i)
+	}
+
+	works, _ := storage.Works.List(ctx)
+	assert.Len(t, works, 3, "It should create missing works once")
+
+	book, _ := storage.Books.Get(ctx, paperback.ID.Hex())
+	assert.Equal(t, sardines.ID, book.WorkID, "It should match authors in any order")
+
+	seagulls, _ := storage.Books.Get(ctx, audiobook.ID.Hex())
+	assert.False(t, seagulls.WorkID.IsZero())
+	book, _ = storage.Books.GetTrashed(ctx, hardcover.ID.Hex())
+	assert.Equal(t, seagulls.WorkID, book.WorkID, "It should group trashed books")
+
+	book, _ = storage.Books.Get(ctx, other.ID.Hex())
+	assert.NotEqual(t, seagulls.WorkID, book.WorkID, "It should keep works of other authors apart")
+
+	revisions, _ := storage.Revisions.List(ctx, paperback.ID)
+	if assert.Len(t, revisions, 2) {
+		assert.Equal(t, "migrate", revisions[1].Actor)
+	}
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	bolt "go.etcd.io/bbolt"
+)
+
+// worksBucket works by id
+var worksBucket = []byte("works")
+
+// BoltWorkRepo work repository storing works into an embedded bbolt file
+type BoltWorkRepo struct {
+	interfaces.IWorkRepository
+
+	DB *bolt.DB
+}
+
+// List get every work sorted by title
+func (repo *BoltWorkRepo) List(ctx context.Context) ([]model.Work, error) {
+	works := []model.Work{}
+	err := repo.DB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(worksBucket).ForEach(func(_ []byte, data []byte) error {
+			work := model.Work{}
+			if err := json.Unmarshal(data, &work); err != nil {
+				return err
+			}
+			works = append(works, work)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	model.SortWorks(works)
+	return works, nil
+}
+
+// Get get a work by ID
+func (repo *BoltWorkRepo) Get(ctx context.Context, ID string) (*model.Work, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	var work *model.Work
+	err = repo.DB.View(func(tx *bolt.Tx) error {
+		work, err = getWork(tx, objectID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if work == nil {
+		return nil, model.ErrWorkNotFound
+	}
+
+	return work, nil
+}
+
+// Create add a new work
+func (repo *BoltWorkRepo) Create(ctx context.Context, work *model.Work) (*model.Work, error) {
+	work.ID = objectid.New()
+	work.CreatedAt = now()
+	work.UpdatedAt = work.CreatedAt
+
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		return putWork(tx, work)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return work, nil
+}
+
+// Update replace a work by ID
+func (repo *BoltWorkRepo) Update(ctx context.Context, work *model.Work) (*model.Work, error) {
+	updated := *work
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		stored, err := getWork(tx, work.ID)
+		if err != nil {
+			return err
+		}
+		if stored == nil {
+			return model.ErrWorkNotFound
+		}
+
+		updated.CreatedAt = stored.CreatedAt
+		updated.UpdatedAt = now()
+		return putWork(tx, &updated)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	work.CreatedAt = updated.CreatedAt
+	work.UpdatedAt = updated.UpdatedAt
+	return work, nil
+}
+
+// Delete remove a work by ID
+func (repo *BoltWorkRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	var deleted int64
+	err := repo.DB.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(worksBucket)
+		if bucket.Get(ID[:]) == nil {
+			return nil
+		}
+		deleted = 1
+		return bucket.Delete(ID[:])
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+// Put write work as is under its id into the works bucket, replacing the stored one
+func (repo *BoltWorkRepo) Put(ctx context.Context, work *model.Work) error {
+	return repo.DB.Update(func(tx *bolt.Tx) error {
+		return putWork(tx, work)
+	})
+}
+
+// getWork read a work by id, nil when it doesn't exist
+func getWork(tx *bolt.Tx, ID objectid.ObjectID) (*model.Work, error) {
+	data := tx.Bucket(worksBucket).Get(ID[:])
+	if data == nil {
+		return nil, nil
+	}
+
+	work := &model.Work{}
+	if err := json.Unmarshal(data, work); err != nil {
+		return nil, err
+	}
+	return work, nil
+}
+
+// putWork write a work
+func putWork(tx *bolt.Tx, work *model.Work) error {
+	data, err := json.Marshal(work)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(worksBucket).Put(work.ID[:], data)
+}
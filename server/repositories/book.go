@@ -3,32 +3,365 @@ package repositories
 // func Book{}
 import (
 	"context"
+	"encoding/binary"
 	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/MathieuDoyon/bookshelf/server/db"
 	"github.com/MathieuDoyon/bookshelf/server/interfaces"
 	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/search"
 	"github.com/mongodb/mongo-go-driver/bson"
 	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/aggregateopt"
 	"github.com/mongodb/mongo-go-driver/mongo/findopt"
+	"github.com/mongodb/mongo-go-driver/mongo/mongoopt"
+	"github.com/mongodb/mongo-go-driver/mongo/replaceopt"
 )
 
 // BookRepo book repository
 type BookRepo struct {
 	interfaces.IBookRepository
+	// Strict fail reads on books which can't be decoded, they are skipped with a warning otherwise
+	Strict bool
+	// Collation locale strings are sorted and compared with, ex: `fr`, binary order when empty
+	Collation string
 }
 
-// List query database to return list of books
-func (repo *BookRepo) List(filters *model.BookFilter, sorting *model.Sorting) ([]model.Book, error) {
+// List query database to return a page of books
+func (repo *BookRepo) List(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, pagination *model.Pagination) (*model.BookPage, error) {
 	collection := db.Database.Collection("books")
 
-	filterDoc := bson.NewDocument()
+	filterDoc := filterDocument(filters)
+
+	total, err := collection.CountDocuments(ctx, filterDoc)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	// walk the collection in reverse order when paginating backward, newBookPage flips the results afterward
+	activeSorting, walk, limit := listSettings(sorting, pagination)
+
+	cursor := pagination.Cursor
+	var keyset *bson.Element
+	if cursor != nil {
+		keyset = keysetElement(walk, cursor)
+	}
+
+	var skip int64
+	if cursor == nil && pagination.Offset > 0 {
+		skip = pagination.Offset
+	}
+
+	// one extra book tells if there is another page
+	cur, err := repo.find(ctx, collection, filterDoc, keyset, walk, skip, limit+1)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	defer cur.Close(context.Background())
+
+	books := []model.Book{}
+	var warnings []string
+
+	for cur.Next(ctx) {
+		book, err := decodeBook(cur)
+		if err != nil {
+			if repo.Strict {
+				return nil, err
+			}
+			log.Printf("Skipping book: %s", err)
+			warnings = append(warnings, err.Error())
+			continue
+		}
+		books = append(books, *book)
+	}
+
+	// a deadline or a cancellation ends the cursor early
+	if err := cur.Err(); err != nil {
+		return nil, mongoError(err)
+	}
+
+	page := newBookPage(books, total, limit, pagination, activeSorting)
+	page.Warnings = warnings
+	return page, nil
+}
+
+// Stream query database and call fn for each book as it is read from the cursor, stopping at the first error
+func (repo *BookRepo) Stream(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, fn func(book *model.Book) error) error {
+	collection := db.Database.Collection("books")
+
+	cur, err := repo.find(ctx, collection, filterDocument(filters), nil, sorting, 0, 0)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	defer cur.Close(context.Background())
+
+	for cur.Next(ctx) {
+		book, err := decodeBook(cur)
+		if err != nil {
+			if repo.Strict {
+				return err
+			}
+			log.Printf("Skipping book: %s", err)
+			continue
+		}
+		if err := fn(book); err != nil {
+			return err
+		}
+	}
+
+	return mongoError(cur.Err())
+}
+
+// find query books matching filterDoc and keyset in sorting order, skip and limit are ignored when 0.
+//
+// Titles without their article aren't stored, they are computed by an aggregation when sorted by.
+func (repo *BookRepo) find(ctx context.Context, collection *mongo.Collection, filterDoc *bson.Document, keyset *bson.Element, sorting *model.Sorting, skip int64, limit int64) (mongo.Cursor, error) {
+	var collation *mongoopt.Collation
+	if repo.Collation != "" {
+		collation = &mongoopt.Collation{Locale: repo.Collation}
+	}
+
+	if !sortsTitleWithoutArticle(sorting) {
+		queryDoc := filterDoc
+		if keyset != nil {
+			queryDoc = filterDoc.Copy()
+			queryDoc.Append(keyset)
+		}
+
+		opts := []findopt.Find{findopt.Sort(sortDocument(sorting))}
+		if skip > 0 {
+			opts = append(opts, findopt.Skip(skip))
+		}
+		if limit > 0 {
+			opts = append(opts, findopt.Limit(limit))
+		}
+		if collation != nil {
+			opts = append(opts, findopt.Collation(collation))
+		}
+		return collection.Find(ctx, queryDoc, opts...)
+	}
+
+	pipeline := bson.NewArray(
+		bson.VC.DocumentFromElements(bson.EC.SubDocument("$match", filterDoc)),
+		bson.VC.DocumentFromElements(bson.EC.SubDocumentFromElements("$addFields", sortTitleElement())),
+	)
+	if keyset != nil {
+		pipeline.Append(bson.VC.DocumentFromElements(bson.EC.SubDocumentFromElements("$match", keyset)))
+	}
+	pipeline.Append(bson.VC.DocumentFromElements(bson.EC.SubDocument("$sort", sortDocument(sorting))))
+	if skip > 0 {
+		pipeline.Append(bson.VC.DocumentFromElements(bson.EC.Int64("$skip", skip)))
+	}
+	if limit > 0 {
+		pipeline.Append(bson.VC.DocumentFromElements(bson.EC.Int64("$limit", limit)))
+	}
+	pipeline.Append(bson.VC.DocumentFromElements(bson.EC.SubDocumentFromElements("$project", bson.EC.Int32(sortTitleField, 0))))
+
+	var opts []aggregateopt.Aggregate
+	if collation != nil {
+		opts = append(opts, aggregateopt.Collation(collation))
+	}
+	return collection.Aggregate(ctx, pipeline, opts...)
+}
+
+// sortTitleField field holding the title without its article while aggregating
+const sortTitleField = "sort_title"
+
+// sortsTitleWithoutArticle check if books are sorted by their title without its article
+func sortsTitleWithoutArticle(sorting *model.Sorting) bool {
+	if !sorting.IgnoreArticles {
+		return false
+	}
+	for _, key := range sorting.Keys() {
+		if key.Field == "title" {
+			return true
+		}
+	}
+	return false
+}
+
+// sortField mongo field a sort key is sorted by
+func sortField(field string, sorting *model.Sorting) string {
+	if field == "title" && sorting.IgnoreArticles {
+		return sortTitleField
+	}
+	return field
+}
+
+// sortDocument sort document of every sort key, `_id` is the tie breaker keeping keyset pages stable
+func sortDocument(sorting *model.Sorting) *bson.Document {
+	sortDoc := bson.NewDocument()
+	last := ""
+	for _, key := range sorting.Keys() {
+		sortDoc.Append(bson.EC.Int32(sortField(key.Field, sorting), key.Direction))
+		last = key.Field
+	}
+	if last != "_id" {
+		sortDoc.Append(bson.EC.Int32("_id", sorting.TieDirection()))
+	}
+	return sortDoc
+}
+
+// sortTitleElement compute the title without its leading article, like `model.SortTitle`
+func sortTitleElement() *bson.Element {
+	branches := bson.NewArray()
+	for _, article := range model.Articles {
+		length := int32(utf8.RuneCountInString(article))
+		branches.Append(bson.VC.DocumentFromElements(
+			bson.EC.SubDocumentFromElements("case",
+				bson.EC.ArrayFromElements("$and",
+					bson.VC.DocumentFromElements(bson.EC.ArrayFromElements("$gt",
+						bson.VC.DocumentFromElements(bson.EC.String("$strLenCP", "$title")),
+						bson.VC.Int32(length),
+					)),
+					bson.VC.DocumentFromElements(bson.EC.ArrayFromElements("$eq",
+						bson.VC.DocumentFromElements(bson.EC.ArrayFromElements("$toLower",
+							bson.VC.DocumentFromElements(bson.EC.ArrayFromElements("$substrCP",
+								bson.VC.String("$title"), bson.VC.Int32(0), bson.VC.Int32(length),
+							)),
+						)),
+						bson.VC.String(article),
+					)),
+				),
+			),
+			bson.EC.SubDocumentFromElements("then",
+				bson.EC.ArrayFromElements("$substrCP",
+					bson.VC.String("$title"),
+					bson.VC.Int32(length),
+					bson.VC.DocumentFromElements(bson.EC.String("$strLenCP", "$title")),
+				),
+			),
+		))
+	}
+
+	return bson.EC.SubDocumentFromElements(sortTitleField,
+		bson.EC.SubDocumentFromElements("$switch",
+			bson.EC.Array("branches", branches),
+			bson.EC.String("default", "$title"),
+		),
+	)
+}
+
+// CountUndecodable count stored books, trashed or not, which can't be decoded
+func (repo *BookRepo) CountUndecodable(ctx context.Context) (int64, error) {
+	collection := db.Database.Collection("books")
+
+	cur, err := collection.Find(ctx, bson.NewDocument())
+	if err != nil {
+		return 0, mongoError(err)
+	}
+
+	defer cur.Close(context.Background())
+
+	var count int64
+	for cur.Next(ctx) {
+		if _, err := decodeBook(cur); err != nil {
+			count++
+		}
+	}
+
+	return count, mongoError(cur.Err())
+}
+
+// decodeBook decode the current document of a cursor, the error tells which book couldn't be decoded
+func decodeBook(cur mongo.Cursor) (*model.Book, error) {
+	book := &model.Book{}
+	err := cur.Decode(book)
+	if err == nil {
+		return book, nil
+	}
+
+	ID := ""
+	if raw, rawErr := cur.DecodeBytes(); rawErr == nil {
+		if element, lookupErr := raw.Lookup("_id"); lookupErr == nil {
+			if objectID, ok := element.Value().ObjectIDOK(); ok {
+				ID = objectID.Hex()
+			}
+		}
+	}
+	return nil, model.Undecodable(ID, err)
+}
+
+// filterDocument build the query document of list filters
+func filterDocument(filters *model.BookFilter) *bson.Document {
+	filterDoc := bson.NewDocument(trashedElement(filters.Trashed))
+	if !filters.ID.IsZero() {
+		filterDoc.Append(bson.EC.ObjectID("_id", filters.ID))
+	}
+	if !filters.WorkID.IsZero() {
+		filterDoc.Append(bson.EC.ObjectID("work_id", filters.WorkID))
+	}
+	if filters.Title != "" {
+		filterDoc.Append(bson.EC.String("title", filters.Title))
+	}
+	if filters.Subtitle != "" {
+		filterDoc.Append(bson.EC.String("subtitle", filters.Subtitle))
+	}
+	// the keyset filter is the top level $or, other alternatives are nested into $and
+	and := bson.NewArray()
 	if filters.Author != "" {
-		filterDoc.Append(bson.EC.String("author", filters.Author))
+		// matches the author text, or one of the authors when there are several
+		and.Append(bson.VC.DocumentFromElements(bson.EC.ArrayFromElements("$or",
+			bson.VC.DocumentFromElements(bson.EC.String("author", filters.Author)),
+			bson.VC.DocumentFromElements(bson.EC.SubDocumentFromElements("contributors",
+				bson.EC.SubDocumentFromElements("$elemMatch",
+					bson.EC.String("role", model.RoleAuthor),
+					bson.EC.String("name", filters.Author),
+				),
+			)),
+		)))
+	}
+	if !filters.AuthorID.IsZero() {
+		// matches the arrays having the id, as author or contributor
+		and.Append(bson.VC.DocumentFromElements(bson.EC.ArrayFromElements("$or",
+			bson.VC.DocumentFromElements(bson.EC.ObjectID("author_ids", filters.AuthorID)),
+			bson.VC.DocumentFromElements(bson.EC.ObjectID("contributors.author_id", filters.AuthorID)),
+		)))
+	}
+	if len(filters.Contributors) > 0 {
+		// every contributor must be matched, each by a different element
+		contributors := bson.NewArray()
+		for _, contributor := range filters.Contributors {
+			contributors.Append(bson.VC.DocumentFromElements(
+				bson.EC.SubDocumentFromElements("$elemMatch",
+					bson.EC.String("role", contributor.Role),
+					bson.EC.String("name", contributor.Name),
+				),
+			))
+		}
+		filterDoc.Append(bson.EC.SubDocumentFromElements("contributors", bson.EC.Array("$all", contributors)))
 	}
 	if filters.Genre != "" {
 		filterDoc.Append(bson.EC.String("genre", filters.Genre))
 	}
+	if filters.Series != "" {
+		filterDoc.Append(bson.EC.String("series", filters.Series))
+	}
+	if len(filters.Genres) > 0 {
+		genres := bson.NewArray()
+		for _, genre := range filters.Genres {
+			genres.Append(bson.VC.String(genre))
+		}
+		filterDoc.Append(bson.EC.SubDocumentFromElements("genre", bson.EC.Array("$in", genres)))
+	}
+	if filters.ISBN13 != "" {
+		filterDoc.Append(bson.EC.String("isbn_13", filters.ISBN13))
+	}
+	if filters.Publisher != "" {
+		filterDoc.Append(bson.EC.String("publisher", filters.Publisher))
+	}
+	if filters.Language != "" {
+		filterDoc.Append(bson.EC.String("language", filters.Language))
+	}
+	if filters.Edition != 0 {
+		filterDoc.Append(bson.EC.Int64("edition", filters.Edition))
+	}
 	if filters.NumberOfPages != 0 {
 		filterDoc.Append(bson.EC.Int64("number_of_pages", filters.NumberOfPages))
 	}
@@ -38,123 +371,576 @@ func (repo *BookRepo) List(filters *model.BookFilter, sorting *model.Sorting) ([
 	if filters.Rating != 0 {
 		filterDoc.Append(bson.EC.Int64("rating", filters.Rating))
 	}
+	for _, condition := range filters.Conditions {
+		and.Append(bson.VC.DocumentFromElements(conditionElement(condition)))
+	}
+	if and.Len() > 0 {
+		filterDoc.Append(bson.EC.Array("$and", and))
+	}
+
+	return filterDoc
+}
+
+// newBookPage build the page of books read after the pagination position, books has one extra book when
+// there is another page and is in reverse order when paginating backward
+func newBookPage(books []model.Book, total int64, limit int64, pagination *model.Pagination, activeSorting *model.Sorting) *model.BookPage {
+	cursor := pagination.Cursor
+	backward := cursor != nil && cursor.Backward
+
+	hasMore := int64(len(books)) > limit
+	if hasMore {
+		books = books[:limit]
+	}
+	if backward {
+		for i, j := 0, len(books)-1; i < j; i, j = i+1, j-1 {
+			books[i], books[j] = books[j], books[i]
+		}
+	}
+
+	page := &model.BookPage{
+		Books:  books,
+		Total:  total,
+		Limit:  limit,
+		Offset: pagination.Offset,
+	}
+	if cursor != nil {
+		page.Offset = 0
+	}
+
+	if len(books) > 0 {
+		first, last := &books[0], &books[len(books)-1]
+		if (!backward && hasMore) || (backward && cursor != nil) {
+			page.NextCursor = model.NewCursor(last, activeSorting, false).Encode()
+		}
+		if (backward && hasMore) || (!backward && (cursor != nil || pagination.Offset > 0)) {
+			page.PrevCursor = model.NewCursor(first, activeSorting, true).Encode()
+		}
+	}
+
+	return page
+}
+
+// conditionElement build the filter element of an operator condition
+func conditionElement(condition model.FilterCondition) *bson.Element {
+	if condition.Operator == model.OpBetween {
+		bounds := condition.Value.([]interface{})
+		return bson.EC.SubDocumentFromElements(condition.Field,
+			element("$gte", bounds[0]),
+			element("$lte", bounds[1]),
+		)
+	}
+
+	if values, ok := condition.Value.([]interface{}); ok {
+		array := bson.NewArray()
+		for _, value := range values {
+			array.Append(element("", value).Value())
+		}
+		return bson.EC.SubDocumentFromElements(condition.Field, bson.EC.Array("$"+condition.Operator, array))
+	}
+
+	return bson.EC.SubDocumentFromElements(condition.Field, element("$"+condition.Operator, condition.Value))
+}
+
+// keysetElement build the filter selecting books after cursor in the sorting order: a book is after the cursor
+// when its first differing key is after the cursor value, `_id` breaks ties
+func keysetElement(sorting *model.Sorting, cursor *model.Cursor) *bson.Element {
+	var keys []model.SortKey
+	for _, key := range sorting.Keys() {
+		if key.Field == "_id" {
+			break
+		}
+		keys = append(keys, key)
+	}
+	values := cursor.Values()
+
+	// equal the cursor values of the first n keys
+	equal := func(n int) []*bson.Element {
+		elements := make([]*bson.Element, 0, n+1)
+		for i := 0; i < n; i++ {
+			elements = append(elements, element(sortField(keys[i].Field, sorting), values[i]))
+		}
+		return elements
+	}
 
-	var opts []findopt.Find
+	branches := make([]*bson.Value, 0, len(keys)+1)
+	for i, key := range keys {
+		after := append(equal(i), bson.EC.SubDocumentFromElements(sortField(key.Field, sorting), element(keysetOperator(key.Direction), values[i])))
+		branches = append(branches, bson.VC.DocumentFromElements(after...))
+	}
+	tie := bson.EC.SubDocumentFromElements("_id", bson.EC.ObjectID(keysetOperator(sorting.TieDirection()), cursor.ID))
+	if len(keys) == 0 {
+		return tie
+	}
+	branches = append(branches, bson.VC.DocumentFromElements(append(equal(len(keys)), tie)...))
 
-	sort := "_id"
-	if sorting.Sort != "" {
-		sort = sorting.Sort
+	return bson.EC.ArrayFromElements("$or", branches...)
+}
+
+// keysetOperator comparison selecting values after the cursor in direction
+func keysetOperator(direction int32) string {
+	if direction < 0 {
+		return "$lt"
 	}
+	return "$gt"
+}
+
+// searchCandidatesPerResult candidates mongo selects by text score for each result asked
+const searchCandidatesPerResult = 10
+
+// Search full text search books using mongo text index, ranked by `search.Search`.
+//
+// Only the `limit * searchCandidatesPerResult` best candidates by mongo text score are ranked, the total of
+// larger searches is counted by mongo.
+func (repo *BookRepo) Search(ctx context.Context, query string, limit int64) (*model.SearchPage, error) {
+	collection := db.Database.Collection("books")
 
-	var direction int32 = -1
-	if sorting.Direction != 0 {
-		direction = sorting.Direction
+	terms := search.Terms(query)
+	if len(terms) == 0 {
+		return search.Search(nil, query, limit), nil
 	}
 
-	opts = append(opts, findopt.Sort(bson.NewDocument(bson.EC.Int32(sort, direction))))
-	opts = append(opts, findopt.Limit(10))
+	// mongo only selects candidates, ranking is done the same way for every backend
+	filterDoc := bson.NewDocument(
+		trashedElement(false),
+		bson.EC.SubDocumentFromElements("$text",
+			bson.EC.String("$search", strings.Join(terms, " ")),
+			bson.EC.Boolean("$caseSensitive", false),
+			bson.EC.Boolean("$diacriticSensitive", false),
+		),
+	)
 
-	cur, err := collection.Find(
-		nil,
-		filterDoc,
-		opts...,
+	if limit <= 0 || limit > model.MaxLimit {
+		limit = model.MaxLimit
+	}
+	// the score is projected to be sorted on, decoding skips it
+	scoreDoc := bson.NewDocument(bson.EC.SubDocumentFromElements("score", bson.EC.String("$meta", "textScore")))
+	candidates := limit * searchCandidatesPerResult
+	cur, err := collection.Find(ctx, filterDoc.Copy(),
+		findopt.Projection(scoreDoc),
+		findopt.Sort(scoreDoc.Copy()),
+		findopt.Limit(candidates),
 	)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 
 	defer cur.Close(context.Background())
 
-	var books []model.Book
+	books := []model.Book{}
+	read := int64(0)
 
-	for cur.Next(nil) {
-		book := model.Book{}
-		err := cur.Decode(&book)
+	for cur.Next(ctx) {
+		read++
+		book, err := decodeBook(cur)
 		if err != nil {
-			log.Fatal("Decode error ", err)
+			if repo.Strict {
+				return nil, err
+			}
+			log.Printf("Skipping book: %s", err)
+			continue
 		}
-		books = append(books, book)
+		books = append(books, *book)
 	}
 
 	if err := cur.Err(); err != nil {
-		log.Fatal("Cursor error ", err)
+		return nil, mongoError(err)
 	}
 
-	return books, nil
+	page := search.Search(books, query, limit)
+	if read == candidates {
+		// more books may match than were ranked
+		if page.Total, err = collection.CountDocuments(ctx, filterDoc); err != nil {
+			return nil, mongoError(err)
+		}
+	}
+	return page, nil
 }
 
 // Create add a new book into database
-func (repo *BookRepo) Create(book *model.Book) (*model.Book, error) {
+func (repo *BookRepo) Create(ctx context.Context, book *model.Book) (*model.Book, error) {
 	collection := db.Database.Collection("books")
 
 	book.ID = objectid.New()
-	_, err := collection.InsertOne(nil, book)
+	book.Version = 1
+	book.CreatedAt = now()
+	book.UpdatedAt = book.CreatedAt
+	_, err := collection.InsertOne(ctx, book)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 
 	return book, nil
 }
 
-// Get get a book by ID into database
-func (repo *BookRepo) Get(ID string) (*model.Book, error) {
+// Get get a book by ID into database, trashed books are not returned
+func (repo *BookRepo) Get(ctx context.Context, ID string) (*model.Book, error) {
+	return repo.get(ctx, ID, false)
+}
+
+// GetTrashed get a trashed book by ID into database
+func (repo *BookRepo) GetTrashed(ctx context.Context, ID string) (*model.Book, error) {
+	return repo.get(ctx, ID, true)
+}
+
+// get get a book by ID either from trash or not
+func (repo *BookRepo) get(ctx context.Context, ID string, trashed bool) (*model.Book, error) {
 	collection := db.Database.Collection("books")
 
 	objectID, err := objectid.FromHex(ID)
 	if err != nil {
-		return nil, err
+		return nil, model.ErrInvalidID
 	}
 	var book *model.Book
 
-	idDoc := bson.NewDocument(bson.EC.ObjectID("_id", objectID))
+	idDoc := bson.NewDocument(
+		bson.EC.ObjectID("_id", objectID),
+		trashedElement(trashed),
+	)
 
-	err = collection.FindOne(nil, idDoc).Decode(&book)
+	err = collection.FindOne(ctx, idDoc).Decode(&book)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 
 	return book, nil
 }
 
 // Update update a book by ID
-func (repo *BookRepo) Update(book *model.Book) (*model.Book, error) {
+func (repo *BookRepo) Update(ctx context.Context, book *model.Book) (*model.Book, error) {
 	collection := db.Database.Collection("books")
 
-	idDoc := bson.NewDocument(bson.EC.ObjectID("_id", book.ID))
+	idDoc := versionFilter(book.ID, book.Version)
+
+	updatedAt := now()
+	setDoc := bson.NewDocument(
+		bson.EC.String("title", book.Title),
+		bson.EC.String("subtitle", book.Subtitle),
+		bson.EC.String("author", book.Author),
+		objectIDsElement("author_ids", book.AuthorIDs),
+		contributorsElement("contributors", book.Contributors),
+		bson.EC.String("genre", book.Genre),
+		bson.EC.String("series", book.Series),
+		bson.EC.Double("series_position", book.SeriesPosition),
+		bson.EC.String("publisher", book.Publisher),
+		bson.EC.String("language", book.Language),
+		bson.EC.String("description", book.Description),
+		bson.EC.Int64("edition", book.Edition),
+		bson.EC.Int64("number_of_pages", book.NumberOfPages),
+		bson.EC.Int64("publication_year", book.YearOfPublication),
+		bson.EC.Int64("rating", book.Rating),
+		bson.EC.Boolean("read", book.Read),
+		bson.EC.Time("updated_at", updatedAt),
+	)
+	// books keep the work they are an edition of, they only join one
+	if !book.WorkID.IsZero() {
+		setDoc.Append(bson.EC.ObjectID("work_id", book.WorkID))
+	}
+	// empty ISBN are unset instead of stored, they would break the unique index
+	unsetDoc := bson.NewDocument()
+	for _, isbn := range []*bson.Element{bson.EC.String("isbn_10", book.ISBN10), bson.EC.String("isbn_13", book.ISBN13)} {
+		if isbn.Value().StringValue() != "" {
+			setDoc.Append(isbn)
+		} else {
+			unsetDoc.Append(bson.EC.String(isbn.Key(), ""))
+		}
+	}
+
+	updateDoc := bson.NewDocument(
+		bson.EC.SubDocument("$set", setDoc),
+		bson.EC.SubDocumentFromElements("$inc",
+			bson.EC.Int64("version", 1),
+		),
+	)
+	if unsetDoc.Len() > 0 {
+		updateDoc.Append(bson.EC.SubDocument("$unset", unsetDoc))
+	}
+
+	res, err := collection.UpdateOne(
+		ctx,
+		idDoc,
+		updateDoc,
+	)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, model.ErrVersionConflict
+	}
+
+	book.Version++
+	book.UpdatedAt = updatedAt
+	return book, nil
+}
+
+// Patch update only the given fields (bson names) of a book
+func (repo *BookRepo) Patch(ctx context.Context, book *model.Book, fields []string) (*model.Book, error) {
+	collection := db.Database.Collection("books")
+
+	if len(fields) == 0 {
+		return book, nil
+	}
+
+	idDoc := versionFilter(book.ID, book.Version)
+
+	updatedAt := now()
+	setDoc := bson.NewDocument(bson.EC.Time("updated_at", updatedAt))
+	unsetDoc := bson.NewDocument()
+	for _, field := range fields {
+		value := book.FieldValue(field)
+		// empty ISBN are unset instead of stored, they would break the unique index
+		if (field == "isbn_10" || field == "isbn_13") && value == "" {
+			unsetDoc.Append(bson.EC.String(field, ""))
+		} else {
+			setDoc.Append(element(field, value))
+		}
+	}
+
+	updateDoc := bson.NewDocument(
+		bson.EC.SubDocument("$set", setDoc),
+		bson.EC.SubDocumentFromElements("$inc",
+			bson.EC.Int64("version", 1),
+		),
+	)
+	if unsetDoc.Len() > 0 {
+		updateDoc.Append(bson.EC.SubDocument("$unset", unsetDoc))
+	}
+
+	res, err := collection.UpdateOne(
+		ctx,
+		idDoc,
+		updateDoc,
+	)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, model.ErrVersionConflict
+	}
+
+	book.Version++
+	book.UpdatedAt = updatedAt
+	return book, nil
+}
+
+// Delete move a book to trash if it is still at version
+func (repo *BookRepo) Delete(ctx context.Context, ID objectid.ObjectID, version int64) (int64, error) {
+	collection := db.Database.Collection("books")
+
+	idDoc := versionFilter(ID, version)
+	idDoc.Append(trashedElement(false))
+
+	deletedAt := now()
+	res, err := collection.UpdateOne(
+		ctx,
+		idDoc,
+		bson.NewDocument(
+			bson.EC.SubDocumentFromElements("$set",
+				bson.EC.Time("deleted_at", deletedAt),
+				bson.EC.Time("updated_at", deletedAt),
+			),
+			bson.EC.SubDocumentFromElements("$inc",
+				bson.EC.Int64("version", 1),
+			),
+		),
+	)
+	if err != nil {
+		return 0, mongoError(err)
+	}
+	if res.MatchedCount == 0 {
+		return 0, model.ErrVersionConflict
+	}
+
+	return res.ModifiedCount, nil
+}
+
+// Restore move a book out of trash
+func (repo *BookRepo) Restore(ctx context.Context, ID objectid.ObjectID) (*model.Book, error) {
+	collection := db.Database.Collection("books")
+
+	idDoc := bson.NewDocument(
+		bson.EC.ObjectID("_id", ID),
+		trashedElement(true),
+	)
 
 	_, err := collection.UpdateOne(
-		nil,
+		ctx,
 		idDoc,
 		bson.NewDocument(
 			bson.EC.SubDocumentFromElements("$set",
-				bson.EC.String("author", book.Author),
-				bson.EC.String("genre", book.Genre),
-				bson.EC.Int64("number_of_pages", book.NumberOfPages),
-				bson.EC.Int64("publication_year", book.YearOfPublication),
-				bson.EC.Int64("rating", book.Rating),
+				bson.EC.Time("updated_at", now()),
+			),
+			bson.EC.SubDocumentFromElements("$unset",
+				bson.EC.String("deleted_at", ""),
 			),
-			bson.EC.SubDocumentFromElements("$currentDate",
-				bson.EC.Boolean("modifiedAt", true),
+			bson.EC.SubDocumentFromElements("$inc",
+				bson.EC.Int64("version", 1),
 			),
 		),
 	)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 
-	return book, nil
+	return repo.Get(ctx, ID.Hex())
 }
 
-// Delete remove a book from database
-func (repo *BookRepo) Delete(ID objectid.ObjectID) (int64, error) {
+// Purge permanently remove a book from database, trashed or not
+func (repo *BookRepo) Purge(ctx context.Context, ID objectid.ObjectID) (int64, error) {
 	collection := db.Database.Collection("books")
 
 	idDoc := bson.NewDocument(bson.EC.ObjectID("_id", ID))
 
-	res, err := collection.DeleteOne(nil, idDoc)
+	res, err := collection.DeleteOne(ctx, idDoc)
+	if err != nil {
+		return 0, mongoError(err)
+	}
+
+	return res.DeletedCount, nil
+}
+
+// PurgeTrash permanently remove books trashed before a date
+func (repo *BookRepo) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	collection := db.Database.Collection("books")
+
+	filterDoc := bson.NewDocument(
+		bson.EC.SubDocumentFromElements("deleted_at", bson.EC.Time("$lt", before)),
+	)
+
+	res, err := collection.DeleteMany(ctx, filterDoc)
 	if err != nil {
-		return 0, err
+		return 0, mongoError(err)
 	}
 
 	return res.DeletedCount, nil
 }
+
+// Put replace the book document having the id of book, inserting it when missing. Version and dates are written as is
+func (repo *BookRepo) Put(ctx context.Context, book *model.Book) error {
+	collection := db.Database.Collection("books")
+
+	_, err := collection.ReplaceOne(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("_id", book.ID)),
+		book,
+		replaceopt.Upsert(true),
+	)
+
+	return mongoError(err)
+}
+
+// backfillTimestamps date books stored before created_at and updated_at existed, it returns how many were dated.
+//
+// updated_at comes from the legacy modifiedAt, dropped once copied, and created_at from the object id.
+// Dated books don't match again so it runs on every start.
+func backfillTimestamps(ctx context.Context) (int64, error) {
+	collection := db.Database.Collection("books")
+
+	filterDoc := bson.NewDocument(bson.EC.ArrayFromElements("$or",
+		bson.VC.DocumentFromElements(bson.EC.SubDocumentFromElements("modifiedAt", bson.EC.Boolean("$exists", true))),
+		bson.VC.DocumentFromElements(bson.EC.SubDocumentFromElements("created_at", bson.EC.Boolean("$exists", false))),
+		bson.VC.DocumentFromElements(bson.EC.SubDocumentFromElements("updated_at", bson.EC.Boolean("$exists", false))),
+	))
+
+	cur, err := collection.Find(ctx, filterDoc)
+	if err != nil {
+		return 0, mongoError(err)
+	}
+	defer cur.Close(ctx)
+
+	count := int64(0)
+	for cur.Next(ctx) {
+		raw, err := cur.DecodeBytes()
+		if err != nil {
+			return count, mongoError(err)
+		}
+		idElement, err := raw.Lookup("_id")
+		if err != nil {
+			continue
+		}
+		ID, ok := idElement.Value().ObjectIDOK()
+		if !ok {
+			continue
+		}
+
+		createdAt, hasCreatedAt := rawTime(raw, "created_at")
+		if !hasCreatedAt {
+			createdAt = objectIDTime(ID)
+		}
+		updatedAt, hasUpdatedAt := rawTime(raw, "updated_at")
+		if !hasUpdatedAt {
+			updatedAt = createdAt
+			if modifiedAt, ok := rawTime(raw, "modifiedAt"); ok {
+				updatedAt = modifiedAt
+			}
+		}
+
+		updateDoc := bson.NewDocument(
+			bson.EC.SubDocumentFromElements("$set",
+				bson.EC.Time("created_at", createdAt),
+				bson.EC.Time("updated_at", updatedAt),
+			),
+			bson.EC.SubDocumentFromElements("$unset", bson.EC.String("modifiedAt", "")),
+		)
+		if _, err := collection.UpdateOne(ctx, bson.NewDocument(bson.EC.ObjectID("_id", ID)), updateDoc); err != nil {
+			return count, mongoError(err)
+		}
+		count++
+	}
+
+	return count, mongoError(cur.Err())
+}
+
+// rawTime get a date field of a raw document, false when it is missing or isn't a date
+func rawTime(raw bson.Reader, key string) (time.Time, bool) {
+	element, err := raw.Lookup(key)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t, ok := element.Value().TimeOK()
+	if !ok || t.IsZero() {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
+
+// objectIDTime creation time of an object id, its first 4 bytes are seconds since epoch
+func objectIDTime(ID objectid.ObjectID) time.Time {
+	return time.Unix(int64(binary.BigEndian.Uint32(ID[0:4])), 0).UTC()
+}
+
+// mongoError map a driver error to a typed error, context errors are kept as is
+func mongoError(err error) error {
+	switch {
+	case err == nil, err == context.Canceled, err == context.DeadlineExceeded:
+		return err
+	case err == mongo.ErrNoDocuments:
+		return model.ErrNotFound
+	case isDuplicateKey(err):
+		return model.ErrDuplicateISBN
+	}
+	return model.Unavailable(err)
+}
+
+// trashedElement filter element matching either trashed books or the others
+func trashedElement(trashed bool) *bson.Element {
+	return bson.EC.SubDocumentFromElements("deleted_at", bson.EC.Boolean("$exists", trashed))
+}
+
+// versionFilter match a book by ID only if it is still at version.
+//
+// Books stored before versioning have no version and match version 0.
+func versionFilter(ID objectid.ObjectID, version int64) *bson.Document {
+	if version == 0 {
+		return bson.NewDocument(
+			bson.EC.ObjectID("_id", ID),
+			bson.EC.SubDocumentFromElements("version",
+				bson.EC.ArrayFromElements("$in", bson.VC.Int64(0), bson.VC.Null()),
+			),
+		)
+	}
+
+	return bson.NewDocument(
+		bson.EC.ObjectID("_id", ID),
+		bson.EC.Int64("version", version),
+	)
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// element build a bson element from a book field value.
+//
+// `bson.EC.Interface` turns object ids and times into null, they are built explicitly.
+func element(key string, value interface{}) *bson.Element {
+	switch v := value.(type) {
+	case objectid.ObjectID:
+		return bson.EC.ObjectID(key, v)
+	case time.Time:
+		return bson.EC.Time(key, v)
+	case []objectid.ObjectID:
+		return objectIDsElement(key, v)
+	case []model.Contributor:
+		return contributorsElement(key, v)
+	}
+	return bson.EC.Interface(key, value)
+}
+
+// objectIDsElement build a bson array of object ids, nil ids are an empty array
+func objectIDsElement(key string, IDs []objectid.ObjectID) *bson.Element {
+	array := bson.NewArray()
+	for _, ID := range IDs {
+		array.Append(bson.VC.ObjectID(ID))
+	}
+	return bson.EC.Array(key, array)
+}
+
+// contributorsElement build a bson array of contributor documents, nil contributors are an empty array
+func contributorsElement(key string, contributors []model.Contributor) *bson.Element {
+	array := bson.NewArray()
+	for _, contributor := range contributors {
+		doc := bson.NewDocument()
+		if contributor.IsLinked() {
+			doc.Append(bson.EC.ObjectID("author_id", contributor.AuthorID))
+		}
+		doc.Append(
+			bson.EC.String("name", contributor.Name),
+			bson.EC.String("role", contributor.Role),
+		)
+		array.Append(bson.VC.Document(doc))
+	}
+	return bson.EC.Array(key, array)
+}
+
+// now current time at the millisecond precision stored by mongo
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Millisecond)
+}
@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/db"
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/repositories/repotest"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/stretchr/testify/assert"
+)
+
+// testDBHostEnv mongo URI used by the contract suite, mongo is skipped when unset
+const testDBHostEnv = "BOOKSHELF_TEST_DB_HOST"
+
+var testMongoOnce sync.Once
+
+func TestMemoryRepoContract(t *testing.T) {
+	repotest.RunBookRepository(t, func(t *testing.T) (interfaces.IBookRepository, func()) {
+		return NewMemoryRepo(), func() {}
+	})
+}
+
+func TestBoltRepoContract(t *testing.T) {
+	repotest.RunBookRepository(t, func(t *testing.T) (interfaces.IBookRepository, func()) {
+		storage, cleanup := openTestBolt(t)
+		return storage.Books, cleanup
+	})
+}
+
+func TestBookRepoContract(t *testing.T) {
+	connectTestMongo(t)
+
+	repotest.RunBookRepository(t, func(t *testing.T) (interfaces.IBookRepository, func()) {
+		emptyTestMongo(t)
+		return &BookRepo{Collation: "en"}, func() {}
+	})
+}
+
+func TestMemoryAuthorRepoContract(t *testing.T) {
+	repotest.RunAuthorRepository(t, func(t *testing.T) (interfaces.IAuthorRepository, func()) {
+		return NewMemoryAuthorRepo(), func() {}
+	})
+}
+
+func TestBoltAuthorRepoContract(t *testing.T) {
+	repotest.RunAuthorRepository(t, func(t *testing.T) (interfaces.IAuthorRepository, func()) {
+		storage, cleanup := openTestBolt(t)
+		return storage.Authors, cleanup
+	})
+}
+
+func TestAuthorRepoContract(t *testing.T) {
+	connectTestMongo(t)
+
+	repotest.RunAuthorRepository(t, func(t *testing.T) (interfaces.IAuthorRepository, func()) {
+		emptyTestMongo(t)
+		return &AuthorRepo{Collation: "en"}, func() {}
+	})
+}
+
+func TestMemoryGenreRepoContract(t *testing.T) {
+	repotest.RunGenreRepository(t, func(t *testing.T) (interfaces.IGenreRepository, func()) {
+		return NewMemoryGenreRepo(), func() {}
+	})
+}
+
+func TestBoltGenreRepoContract(t *testing.T) {
+	repotest.RunGenreRepository(t, func(t *testing.T) (interfaces.IGenreRepository, func()) {
+		storage, cleanup := openTestBolt(t)
+		return storage.Genres, cleanup
+	})
+}
+
+func TestGenreRepoContract(t *testing.T) {
+	connectTestMongo(t)
+
+	repotest.RunGenreRepository(t, func(t *testing.T) (interfaces.IGenreRepository, func()) {
+		emptyTestMongo(t)
+		return &GenreRepo{}, func() {}
+	})
+}
+
+func TestMemorySeriesRepoContract(t *testing.T) {
+	repotest.RunSeriesRepository(t, func(t *testing.T) (interfaces.ISeriesRepository, func()) {
+		return NewMemorySeriesRepo(), func() {}
+	})
+}
+
+func TestBoltSeriesRepoContract(t *testing.T) {
+	repotest.RunSeriesRepository(t, func(t *testing.T) (interfaces.ISeriesRepository, func()) {
+		storage, cleanup := openTestBolt(t)
+		return storage.Series, cleanup
+	})
+}
+
+func TestSeriesRepoContract(t *testing.T) {
+	connectTestMongo(t)
+
+	repotest.RunSeriesRepository(t, func(t *testing.T) (interfaces.ISeriesRepository, func()) {
+		emptyTestMongo(t)
+		return &SeriesRepo{}, func() {}
+	})
+}
+
+func TestMemoryWorkRepoContract(t *testing.T) {
+	repotest.RunWorkRepository(t, func(t *testing.T) (interfaces.IWorkRepository, func()) {
+		return NewMemoryWorkRepo(), func() {}
+	})
+}
+
+func TestBoltWorkRepoContract(t *testing.T) {
+	repotest.RunWorkRepository(t, func(t *testing.T) (interfaces.IWorkRepository, func()) {
+		storage, cleanup := openTestBolt(t)
+		return storage.Works, cleanup
+	})
+}
+
+func TestWorkRepoContract(t *testing.T) {
+	connectTestMongo(t)
+
+	repotest.RunWorkRepository(t, func(t *testing.T) (interfaces.IWorkRepository, func()) {
+		emptyTestMongo(t)
+		return &WorkRepo{}, func() {}
+	})
+}
+
+func TestBookRepoUndecodable(t *testing.T) {
+	connectTestMongo(t)
+	emptyTestMongo(t)
+	ctx := context.Background()
+
+	repo := &BookRepo{}
+	_, err := repo.Create(ctx, &model.Book{Title: "Sardines"})
+	assert.NoError(t, err)
+	// a title stored as a number can't be decoded into a book
+	ID := objectid.New()
+	_, err = db.Database.Collection("books").InsertOne(ctx, bson.NewDocument(
+		bson.EC.ObjectID("_id", ID),
+		bson.EC.Int32("title", 42),
+	))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	page, err := repo.List(ctx, &model.BookFilter{}, &model.Sorting{}, &model.Pagination{})
+	if assert.NoError(t, err, "It should skip undecodable books") {
+		assert.Len(t, page.Books, 1)
+		if assert.Len(t, page.Warnings, 1) {
+			assert.Contains(t, page.Warnings[0], ID.Hex())
+		}
+	}
+
+	count, err := repo.CountUndecodable(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	repo.Strict = true
+	_, err = repo.List(ctx, &model.BookFilter{}, &model.Sorting{}, &model.Pagination{})
+	if assert.IsType(t, &model.Error{}, err, "It should fail on undecodable books when strict") {
+		assert.Equal(t, model.CodeUndecodable, err.(*model.Error).Code)
+	}
+}
+
+func TestBookRepoBackfillTimestamps(t *testing.T) {
+	connectTestMongo(t)
+	emptyTestMongo(t)
+	ctx := context.Background()
+
+	// books stored before created_at and updated_at only had modifiedAt
+	modifiedAt := time.Date(2018, 9, 1, 12, 0, 0, 0, time.UTC)
+	ID := objectid.New()
+	_, err := db.Database.Collection("books").InsertOne(ctx, bson.NewDocument(
+		bson.EC.ObjectID("_id", ID),
+		bson.EC.String("title", "Sardines"),
+		bson.EC.Time("modifiedAt", modifiedAt),
+	))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	count, err := backfillTimestamps(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	book, err := (&BookRepo{}).Get(ctx, ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, modifiedAt, book.UpdatedAt.UTC(), "It should keep modifiedAt as updated_at")
+		assert.Equal(t, objectIDTime(ID), book.CreatedAt.UTC())
+	}
+
+	count, err = backfillTimestamps(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count, "It should date books only once")
+}
+
+// connectTestMongo connect once to the test database, skipping the test when no instance is given
+func connectTestMongo(t *testing.T) {
+	host := os.Getenv(testDBHostEnv)
+	if host == "" {
+		t.Skip(testDBHostEnv + " is not set")
+	}
+
+	testMongoOnce.Do(func() {
+		client, err := mongo.Connect(context.Background(), host, nil)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		db.Client = client
+		db.Database = client.Database("bookshelf_test")
+		if !assert.NoError(t, ensureISBNIndex(context.Background())) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, ensureTextIndex(context.Background())) {
+			t.FailNow()
+		}
+		if !assert.NoError(t, ensureSortIndexes(context.Background(), "en")) {
+			t.FailNow()
+		}
+	})
+}
+
+// emptyTestMongo remove every book and author of the test database, documents are removed rather than the
+// collections dropped to keep the indexes
+func emptyTestMongo(t *testing.T) {
+	for _, name := range []string{"books", "authors", "genres", "series", "works"} {
+		_, err := db.Database.Collection(name).DeleteMany(context.Background(), bson.NewDocument())
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+}
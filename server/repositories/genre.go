@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/db"
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/replaceopt"
+)
+
+// GenreRepo genres repository
+type GenreRepo struct {
+	interfaces.IGenreRepository
+}
+
+// List get every genre sorted by name
+func (repo *GenreRepo) List(ctx context.Context) ([]model.Genre, error) {
+	collection := db.Database.Collection("genres")
+
+	cur, err := collection.Find(ctx, bson.NewDocument())
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cur.Close(context.Background())
+
+	genres := []model.Genre{}
+	for cur.Next(ctx) {
+		genre := model.Genre{}
+		if err := cur.Decode(&genre); err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, mongoError(err)
+	}
+
+	// sorted like the other backends, a collection sort doesn't follow CompareStrings
+	model.SortGenres(genres)
+	return genres, nil
+}
+
+// Get get a genre by ID
+func (repo *GenreRepo) Get(ctx context.Context, ID string) (*model.Genre, error) {
+	collection := db.Database.Collection("genres")
+
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	var genre *model.Genre
+	err = collection.FindOne(ctx, bson.NewDocument(bson.EC.ObjectID("_id", objectID))).Decode(&genre)
+	if err == mongo.ErrNoDocuments {
+		return nil, model.ErrGenreNotFound
+	}
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return genre, nil
+}
+
+// Create add a new genre
+func (repo *GenreRepo) Create(ctx context.Context, genre *model.Genre) (*model.Genre, error) {
+	collection := db.Database.Collection("genres")
+
+	genre.ID = objectid.New()
+	genre.CreatedAt = now()
+	genre.UpdatedAt = genre.CreatedAt
+	if _, err := collection.InsertOne(ctx, genre); err != nil {
+		return nil, mongoError(err)
+	}
+
+	return genre, nil
+}
+
+// Update replace a genre by ID
+func (repo *GenreRepo) Update(ctx context.Context, genre *model.Genre) (*model.Genre, error) {
+	collection := db.Database.Collection("genres")
+
+	updatedAt := now()
+	synonyms := bson.NewArray()
+	for _, synonym := range genre.Synonyms {
+		synonyms.Append(bson.VC.String(synonym))
+	}
+
+	res, err := collection.UpdateOne(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("_id", genre.ID)),
+		bson.NewDocument(bson.EC.SubDocumentFromElements("$set",
+			bson.EC.String("name", genre.Name),
+			bson.EC.String("parent", genre.Parent),
+			bson.EC.Array("synonyms", synonyms),
+			bson.EC.Time("updated_at", updatedAt),
+		)),
+	)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, model.ErrGenreNotFound
+	}
+
+	genre.UpdatedAt = updatedAt
+	return genre, nil
+}
+
+// Delete remove a genre by ID
+func (repo *GenreRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	collection := db.Database.Collection("genres")
+
+	res, err := collection.DeleteOne(ctx, bson.NewDocument(bson.EC.ObjectID("_id", ID)))
+	if err != nil {
+		return 0, mongoError(err)
+	}
+
+	return res.DeletedCount, nil
+}
+
+// Put replace the genre document having the id of genre, inserting it when missing. Dates are written as is
+func (repo *GenreRepo) Put(ctx context.Context, genre *model.Genre) error {
+	collection := db.Database.Collection("genres")
+
+	_, err := collection.ReplaceOne(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("_id", genre.ID)),
+		genre,
+		replaceopt.Upsert(true),
+	)
+
+	return mongoError(err)
+}
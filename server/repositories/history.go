@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// HistoryRepo book repository decorator appending a revision for every write of the wrapped repository
+type HistoryRepo struct {
+	interfaces.IBookRepository
+	// Revisions store revisions are appended to
+	Revisions interfaces.IRevisionRepository
+	// Actor who writes through this repository
+	Actor string
+}
+
+// NewHistoryRepo wrap repo so its writes are recorded into revisions
+func NewHistoryRepo(repo interfaces.IBookRepository, revisions interfaces.IRevisionRepository) *HistoryRepo {
+	return &HistoryRepo{
+		IBookRepository: repo,
+		Revisions:       revisions,
+	}
+}
+
+// WithActor copy of the repository recording writes in the name of actor
+func (repo *HistoryRepo) WithActor(actor string) interfaces.IHistoryRepository {
+	copy := *repo
+	copy.Actor = actor
+	return &copy
+}
+
+// History get every revision of a book, oldest first
+func (repo *HistoryRepo) History(ctx context.Context, bookID objectid.ObjectID) ([]model.Revision, error) {
+	return repo.Revisions.List(ctx, bookID)
+}
+
+// Revision get a single revision of a book
+func (repo *HistoryRepo) Revision(ctx context.Context, bookID objectid.ObjectID, number int64) (*model.Revision, error) {
+	return repo.Revisions.Get(ctx, bookID, number)
+}
+
+// Create add a new book and record its first revision
+func (repo *HistoryRepo) Create(ctx context.Context, book *model.Book) (*model.Book, error) {
+	created, err := repo.IBookRepository.Create(ctx, book)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.record(ctx, model.ActionCreate, &model.Book{}, created, 0)
+	return created, nil
+}
+
+// Update update a book and record the changed fields
+func (repo *HistoryRepo) Update(ctx context.Context, book *model.Book) (*model.Book, error) {
+	before, err := repo.stored(ctx, book.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := repo.IBookRepository.Update(ctx, book)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.record(ctx, model.ActionUpdate, before, updated, 0)
+	return updated, nil
+}
+
+// Patch update some fields of a book and record the changed fields
+func (repo *HistoryRepo) Patch(ctx context.Context, book *model.Book, fields []string) (*model.Book, error) {
+	if len(fields) == 0 {
+		return repo.IBookRepository.Patch(ctx, book, fields)
+	}
+
+	before, err := repo.stored(ctx, book.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := repo.IBookRepository.Patch(ctx, book, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.record(ctx, model.ActionUpdate, before, patched, 0)
+	return patched, nil
+}
+
+// Delete move a book to trash and record it
+func (repo *HistoryRepo) Delete(ctx context.Context, ID objectid.ObjectID, version int64) (int64, error) {
+	deleted, err := repo.IBookRepository.Delete(ctx, ID, version)
+	if err != nil || deleted == 0 {
+		return deleted, err
+	}
+
+	trashed, err := repo.IBookRepository.GetTrashed(ctx, ID.Hex())
+	if err != nil {
+		// the book is trashed already, only its revision is missing
+		log.Printf("Error recording deletion of book %s: %s", ID.Hex(), err)
+		return deleted, nil
+	}
+
+	repo.record(ctx, model.ActionDelete, trashed, trashed, 0)
+	return deleted, nil
+}
+
+// Restore move a book out of trash and record it
+func (repo *HistoryRepo) Restore(ctx context.Context, ID objectid.ObjectID) (*model.Book, error) {
+	restored, err := repo.IBookRepository.Restore(ctx, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.record(ctx, model.ActionRestore, restored, restored, 0)
+	return restored, nil
+}
+
+// Purge permanently remove a book and record it, its history is kept
+func (repo *HistoryRepo) Purge(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	before, err := repo.IBookRepository.Get(ctx, ID.Hex())
+	if err != nil {
+		if before, err = repo.IBookRepository.GetTrashed(ctx, ID.Hex()); err != nil {
+			return 0, err
+		}
+	}
+
+	purged, err := repo.IBookRepository.Purge(ctx, ID)
+	if err != nil || purged == 0 {
+		return purged, err
+	}
+
+	repo.record(ctx, model.ActionPurge, before, purgedState(before), 0)
+	return purged, nil
+}
+
+// PurgeTrash permanently remove books trashed before a date and record each of them
+func (repo *HistoryRepo) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	condition, err := model.NewFilterCondition("deleted_at", model.OpLt, before.UTC().Format(time.RFC3339Nano))
+	if err != nil {
+		return 0, err
+	}
+	filters := &model.BookFilter{
+		Trashed:    true,
+		Conditions: []model.FilterCondition{*condition},
+	}
+	sorting := &model.Sorting{Sort: "_id", Direction: 1}
+
+	// keyset pages, offsets would skip books trashed or restored while paging
+	var books []model.Book
+	pagination := &model.Pagination{Limit: model.MaxLimit}
+	for {
+		page, err := repo.IBookRepository.List(ctx, filters, sorting, pagination)
+		if err != nil {
+			return 0, err
+		}
+		books = append(books, page.Books...)
+		if int64(len(page.Books)) < pagination.Limit {
+			break
+		}
+		pagination.Cursor = model.NewCursor(&page.Books[len(page.Books)-1], sorting, false)
+	}
+
+	purged, err := repo.IBookRepository.PurgeTrash(ctx, before)
+	if err != nil {
+		return purged, err
+	}
+
+	for i := range books {
+		repo.record(ctx, model.ActionPurge, &books[i], purgedState(&books[i]), 0)
+	}
+
+	return purged, nil
+}
+
+// Revert restore a book to the state of one of its revisions
+func (repo *HistoryRepo) Revert(ctx context.Context, book *model.Book, number int64) (*model.Book, error) {
+	revision, err := repo.Revisions.Get(ctx, book.ID, number)
+	if err != nil {
+		return nil, err
+	}
+	if revision.Book == nil {
+		return nil, model.ErrRevisionNotFound
+	}
+
+	state := *revision.Book
+	state.KeepManagedFields(book)
+
+	reverted, err := repo.IBookRepository.Update(ctx, &state)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.record(ctx, model.ActionRevert, book, reverted, number)
+	return reverted, nil
+}
+
+// stored get a book by ID, trashed or not, trashed books are written when their authors are linked or renamed
+func (repo *HistoryRepo) stored(ctx context.Context, ID objectid.ObjectID) (*model.Book, error) {
+	book, err := repo.IBookRepository.Get(ctx, ID.Hex())
+	if err == model.ErrNotFound {
+		return repo.IBookRepository.GetTrashed(ctx, ID.Hex())
+	}
+	return book, err
+}
+
+// record append the revision of a write, numbered after the version of the written book.
+//
+// The write is already committed, so a revision which can't be appended is logged instead of failing it.
+func (repo *HistoryRepo) record(ctx context.Context, action string, before *model.Book, after *model.Book, revertedTo int64) {
+	snapshot := *after
+
+	err := repo.Revisions.Append(ctx, &model.Revision{
+		BookID:     after.ID,
+		Number:     after.Version,
+		Action:     action,
+		Actor:      repo.Actor,
+		CreatedAt:  time.Now().UTC(),
+		Changes:    model.Diff(before, after),
+		RevertedTo: revertedTo,
+		Book:       &snapshot,
+	})
+	if err != nil {
+		log.Printf("Error recording revision %d of book %s: %s", after.Version, after.ID.Hex(), err)
+	}
+}
+
+// purgedState state of a book once purged, its version is bumped so the revision gets a new number
+func purgedState(book *model.Book) *model.Book {
+	state := *book
+	state.Version++
+	return &state
+}
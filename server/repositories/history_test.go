@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces/mocks"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHistoryRepoUpdate(t *testing.T) {
+	ctx := context.Background()
+	ID := objectid.New()
+	before := &model.Book{ID: ID, Title: "Sardines", Rating: 3, Genre: "Fiction", Version: 2}
+	book := &model.Book{ID: ID, Title: "Sardines", Rating: 5, Genre: "Fiction", Version: 2}
+
+	bookMock := &mocks.IBookRepository{}
+	bookMock.On("Get", mock.Anything, ID.Hex()).Return(before, nil)
+	bookMock.On("Update", mock.Anything, book).Return(func(_ context.Context, b *model.Book) *model.Book {
+		updated := *b
+		updated.Version++
+		return &updated
+	}, nil)
+
+	revisionMock := &mocks.IRevisionRepository{}
+	revisionMock.On("Append", mock.Anything, mock.Anything).Return(nil)
+
+	repo := NewHistoryRepo(bookMock, revisionMock).WithActor("mathieu")
+	updated, err := repo.Update(ctx, book)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), updated.Version)
+	revisionMock.AssertCalled(t, "Append", mock.Anything, mock.MatchedBy(func(revision *model.Revision) bool {
+		return revision.BookID == ID &&
+			revision.Number == 3 &&
+			revision.Action == model.ActionUpdate &&
+			revision.Actor == "mathieu" &&
+			assert.Equal(t, []model.FieldChange{{Field: "rating", From: int64(3), To: int64(5)}}, revision.Changes) &&
+			revision.Book.Rating == 5
+	}))
+}
+
+func TestHistoryRepoAppendFailure(t *testing.T) {
+	ctx := context.Background()
+	ID := objectid.New()
+	book := &model.Book{ID: ID, Title: "Sardines", Version: 1}
+
+	bookMock := &mocks.IBookRepository{}
+	bookMock.On("Get", mock.Anything, ID.Hex()).Return(book, nil)
+	bookMock.On("Update", mock.Anything, book).Return(func(_ context.Context, b *model.Book) *model.Book {
+		updated := *b
+		updated.Version++
+		return &updated
+	}, nil)
+
+	revisionMock := &mocks.IRevisionRepository{}
+	revisionMock.On("Append", mock.Anything, mock.Anything).Return(model.Unavailable(errors.New("no reachable servers")))
+
+	updated, err := NewHistoryRepo(bookMock, revisionMock).Update(ctx, book)
+
+	assert.NoError(t, err, "It should not fail a committed write when its revision can't be appended")
+	assert.Equal(t, int64(2), updated.Version)
+}
+
+func TestHistoryRepoRevert(t *testing.T) {
+	ctx := context.Background()
+	ID := objectid.New()
+	current := &model.Book{ID: ID, Title: "Sardines", Rating: 5, Version: 4}
+	past := &model.Revision{BookID: ID, Number: 2, Book: &model.Book{ID: ID, Title: "Trawler", Rating: 3, Version: 2}}
+
+	bookMock := &mocks.IBookRepository{}
+	bookMock.On("Update", mock.Anything, mock.Anything).Return(func(_ context.Context, b *model.Book) *model.Book {
+		updated := *b
+		updated.Version++
+		return &updated
+	}, nil)
+
+	revisionMock := &mocks.IRevisionRepository{}
+	revisionMock.On("Get", mock.Anything, ID, int64(2)).Return(past, nil)
+	revisionMock.On("Get", mock.Anything, ID, int64(9)).Return(nil, model.ErrRevisionNotFound)
+	revisionMock.On("Append", mock.Anything, mock.Anything).Return(nil)
+
+	repo := NewHistoryRepo(bookMock, revisionMock)
+
+	reverted, err := repo.Revert(ctx, current, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Trawler", reverted.Title)
+	assert.Equal(t, int64(5), reverted.Version, "It should keep the current version")
+	revisionMock.AssertCalled(t, "Append", mock.Anything, mock.MatchedBy(func(revision *model.Revision) bool {
+		return revision.Action == model.ActionRevert && revision.RevertedTo == 2 && len(revision.Changes) == 2
+	}))
+
+	_, err = repo.Revert(ctx, current, 9)
+	assert.Equal(t, model.ErrRevisionNotFound, err)
+	bookMock.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestHistoryRepoPurgeTrash(t *testing.T) {
+	ctx := context.Background()
+	books := NewMemoryRepo()
+	revisions := NewMemoryRevisionRepo()
+	repo := NewHistoryRepo(books, revisions)
+
+	var IDs []objectid.ObjectID
+	for i := int64(0); i <= model.MaxLimit; i++ {
+		created, _ := books.Create(ctx, &model.Book{Title: "Sardines"})
+		books.Delete(ctx, created.ID, created.Version)
+		IDs = append(IDs, created.ID)
+	}
+
+	purged, err := repo.PurgeTrash(ctx, time.Now().Add(time.Hour))
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.MaxLimit+1, purged)
+	for _, ID := range IDs {
+		recorded, _ := revisions.List(ctx, ID)
+		if assert.Len(t, recorded, 1, "It should record every purged book, past the first page") {
+			assert.Equal(t, model.ActionPurge, recorded[0].Action)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/MathieuDoyon/bookshelf/server/db"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/search"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/core/command"
+	"github.com/mongodb/mongo-go-driver/mongo"
+)
+
+// textIndexName name of the books full text index, mongo allows only one per collection
+const textIndexName = "books_text"
+
+// isbnIndexName name of the unique ISBN index
+const isbnIndexName = "books_isbn_13"
+
+// ensureTextIndex create the text index over every searchable field, when the storage is opened.
+//
+// The index is dropped and created again only when its fields or weights changed.
+func ensureTextIndex(ctx context.Context) error {
+	collection := db.Database.Collection("books")
+
+	keys := bson.NewDocument()
+	weights := bson.NewDocument()
+	for _, field := range search.Fields() {
+		keys.Append(bson.EC.String(field, "text"))
+		weights.Append(bson.EC.Int32(field, search.Weight(field)))
+	}
+
+	index := mongo.IndexModel{
+		Keys: keys,
+		Options: mongo.NewIndexOptionsBuilder().
+			Name(textIndexName).
+			Weights(weights).
+			// no stemming nor stop words so mongo matches the same terms as `search.Terms`
+			DefaultLanguage("none").
+			Build(),
+	}
+
+	_, err := collection.Indexes().CreateOne(ctx, index)
+	if isIndexConflict(err) {
+		log.Printf("Recreating text index: %s", err)
+		if _, err = collection.Indexes().DropOne(ctx, textIndexName); err == nil {
+			_, err = collection.Indexes().CreateOne(ctx, index)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("creating text index: %s", err)
+	}
+	return nil
+}
+
+// ensureISBNIndex create the unique ISBN-13 index, sparse since ISBN are optional. It is created when the storage
+// is opened, which fails without it rather than accepting duplicate ISBN
+func ensureISBNIndex(ctx context.Context) error {
+	collection := db.Database.Collection("books")
+
+	index := mongo.IndexModel{
+		Keys: bson.NewDocument(bson.EC.Int32("isbn_13", 1)),
+		Options: mongo.NewIndexOptionsBuilder().
+			Name(isbnIndexName).
+			Unique(true).
+			Sparse(true).
+			Build(),
+	}
+
+	if _, err := collection.Indexes().CreateOne(ctx, index); err != nil {
+		return fmt.Errorf("creating ISBN index: %s", err)
+	}
+	return nil
+}
+
+// ensureSortIndexes create an index for each of `model.SortableFields`, followed by `_id` which breaks ties. The
+// indexes use the collation of the lists so string sorts can walk them, they are created again when it changes
+func ensureSortIndexes(ctx context.Context, collation string) error {
+	collection := db.Database.Collection("books")
+
+	for field := range model.SortableFields {
+		if field == "_id" {
+			continue
+		}
+		name := "books_sort_" + field
+		options := mongo.NewIndexOptionsBuilder().Name(name)
+		if collation != "" {
+			options = options.Collation(bson.NewDocument(bson.EC.String("locale", collation)))
+		}
+		index := mongo.IndexModel{
+			Keys:    bson.NewDocument(bson.EC.Int32(field, 1), bson.EC.Int32("_id", 1)),
+			Options: options.Build(),
+		}
+
+		_, err := collection.Indexes().CreateOne(ctx, index)
+		if isIndexConflict(err) {
+			log.Printf("Recreating %s index: %s", name, err)
+			if _, err = collection.Indexes().DropOne(ctx, name); err == nil {
+				_, err = collection.Indexes().CreateOne(ctx, index)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("creating %s index: %s", name, err)
+		}
+	}
+	return nil
+}
+
+// isIndexConflict check if err is the mongo error of an index created again with other options or keys
+func isIndexConflict(err error) bool {
+	commandErr, ok := err.(command.Error)
+	// IndexOptionsConflict and IndexKeySpecsConflict
+	return ok && (commandErr.Code == 85 || commandErr.Code == 86)
+}
+
+// isDuplicateKey check if err is a mongo duplicate key write error
+func isDuplicateKey(err error) bool {
+	writeErrors, ok := err.(mongo.WriteErrors)
+	if !ok {
+		return false
+	}
+	for _, writeError := range writeErrors {
+		if writeError.Code == 11000 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,530 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/MathieuDoyon/bookshelf/server/search"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// MemoryRepo book repository keeping books in a map, they are lost on restart. Every list reads every book,
+// strings are ordered by `model.CompareStrings` which approximates the en collation
+type MemoryRepo struct {
+	interfaces.IBookRepository
+
+	mu    sync.RWMutex
+	books map[objectid.ObjectID]*model.Book
+}
+
+// NewMemoryRepo create an empty in memory book repository
+func NewMemoryRepo() *MemoryRepo {
+	return &MemoryRepo{books: map[objectid.ObjectID]*model.Book{}}
+}
+
+// List return a page of books
+func (repo *MemoryRepo) List(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, pagination *model.Pagination) (*model.BookPage, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	books := repo.find(filters)
+	total := int64(len(books))
+
+	activeSorting, walk, limit := listSettings(sorting, pagination)
+	sortBooks(books, walk)
+	books = windowBooks(books, walk, pagination, limit)
+
+	return newBookPage(books, total, limit, pagination, activeSorting), nil
+}
+
+// Stream call fn for each book matching filters, stopping at the first error
+func (repo *MemoryRepo) Stream(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, fn func(book *model.Book) error) error {
+	repo.mu.RLock()
+	books := repo.find(filters)
+	repo.mu.RUnlock()
+
+	sortBooks(books, sorting)
+
+	for i := range books {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(&books[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Search full text search books, ranked by `search.Search`
+func (repo *MemoryRepo) Search(ctx context.Context, query string, limit int64) (*model.SearchPage, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	if len(search.Terms(query)) == 0 {
+		return search.Search(nil, query, limit), nil
+	}
+
+	return search.Search(repo.find(&model.BookFilter{}), query, limit), nil
+}
+
+// Create add a new book
+func (repo *MemoryRepo) Create(ctx context.Context, book *model.Book) (*model.Book, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.duplicateISBN(book) {
+		return nil, model.ErrDuplicateISBN
+	}
+
+	book.ID = objectid.New()
+	book.Version = 1
+	book.CreatedAt = now()
+	book.UpdatedAt = book.CreatedAt
+	repo.books[book.ID] = cloneBook(book)
+
+	return book, nil
+}
+
+// Get get a book by ID, trashed books are not returned
+func (repo *MemoryRepo) Get(ctx context.Context, ID string) (*model.Book, error) {
+	return repo.get(ID, false)
+}
+
+// GetTrashed get a trashed book by ID
+func (repo *MemoryRepo) GetTrashed(ctx context.Context, ID string) (*model.Book, error) {
+	return repo.get(ID, true)
+}
+
+// get get a book by ID either from trash or not
+func (repo *MemoryRepo) get(ID string, trashed bool) (*model.Book, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	book, ok := repo.books[objectID]
+	if !ok || (book.DeletedAt != nil) != trashed {
+		return nil, model.ErrNotFound
+	}
+
+	return cloneBook(book), nil
+}
+
+// Update update a book by ID if it is still at its version
+func (repo *MemoryRepo) Update(ctx context.Context, book *model.Book) (*model.Book, error) {
+	fields := []string{}
+	for _, field := range model.FieldNames() {
+		if !model.IsManaged(field) {
+			fields = append(fields, field)
+		}
+	}
+
+	return repo.update(book, fields)
+}
+
+// Patch update only the given fields (bson names) of a book
+func (repo *MemoryRepo) Patch(ctx context.Context, book *model.Book, fields []string) (*model.Book, error) {
+	if len(fields) == 0 {
+		return book, nil
+	}
+
+	return repo.update(book, fields)
+}
+
+// update copy fields of book into the stored one if it is still at the same version
+func (repo *MemoryRepo) update(book *model.Book, fields []string) (*model.Book, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.books[book.ID]
+	if !ok || stored.Version != book.Version {
+		return nil, model.ErrVersionConflict
+	}
+
+	updated := cloneBook(stored)
+	for _, field := range fields {
+		updated.CopyField(book, field)
+	}
+	if repo.duplicateISBN(updated) {
+		return nil, model.ErrDuplicateISBN
+	}
+
+	updated.Version++
+	updated.UpdatedAt = now()
+	repo.books[book.ID] = updated
+
+	book.Version = updated.Version
+	book.UpdatedAt = updated.UpdatedAt
+	return book, nil
+}
+
+// Delete move a book to trash if it is still at version
+func (repo *MemoryRepo) Delete(ctx context.Context, ID objectid.ObjectID, version int64) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.books[ID]
+	if !ok || stored.Version != version || stored.DeletedAt != nil {
+		return 0, model.ErrVersionConflict
+	}
+
+	deletedAt := now()
+	stored.DeletedAt = &deletedAt
+	stored.UpdatedAt = deletedAt
+	stored.Version++
+
+	return 1, nil
+}
+
+// Restore move a book out of trash
+func (repo *MemoryRepo) Restore(ctx context.Context, ID objectid.ObjectID) (*model.Book, error) {
+	repo.mu.Lock()
+	if stored, ok := repo.books[ID]; ok && stored.DeletedAt != nil {
+		stored.DeletedAt = nil
+		stored.UpdatedAt = now()
+		stored.Version++
+	}
+	repo.mu.Unlock()
+
+	return repo.Get(ctx, ID.Hex())
+}
+
+// Purge permanently remove a book, trashed or not
+func (repo *MemoryRepo) Purge(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.books[ID]; !ok {
+		return 0, nil
+	}
+	delete(repo.books, ID)
+
+	return 1, nil
+}
+
+// PurgeTrash permanently remove books trashed before a date
+func (repo *MemoryRepo) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	var purged int64
+	for ID, book := range repo.books {
+		if book.DeletedAt != nil && book.DeletedAt.Before(before) {
+			delete(repo.books, ID)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
+
+// Put keep a copy of book under its id as is, replacing the stored book. Its ISBN must still be unique
+func (repo *MemoryRepo) Put(ctx context.Context, book *model.Book) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if repo.duplicateISBN(book) {
+		return model.ErrDuplicateISBN
+	}
+	repo.books[book.ID] = cloneBook(book)
+
+	return nil
+}
+
+// find copy books matching filters, in no particular order
+func (repo *MemoryRepo) find(filters *model.BookFilter) []model.Book {
+	books := []model.Book{}
+	for _, book := range repo.books {
+		if matchFilters(book, filters) {
+			books = append(books, *cloneBook(book))
+		}
+	}
+	return books
+}
+
+// duplicateISBN check if another stored book has the ISBN-13 of book, like the unique index does
+func (repo *MemoryRepo) duplicateISBN(book *model.Book) bool {
+	if book.ISBN13 == "" {
+		return false
+	}
+	for ID, stored := range repo.books {
+		if ID != book.ID && stored.ISBN13 == book.ISBN13 {
+			return true
+		}
+	}
+	return false
+}
+
+// listSettings sorting and limit of a list with their defaults, and the sorting books are walked in
+func listSettings(sorting *model.Sorting, pagination *model.Pagination) (*model.Sorting, *model.Sorting, int64) {
+	activeSorting := activeSorting(sorting)
+
+	limit := model.DefaultLimit
+	if pagination.Limit > 0 {
+		limit = pagination.Limit
+	}
+
+	walk := activeSorting
+	if pagination.Cursor != nil && pagination.Cursor.Backward {
+		// walk the books in reverse order, newBookPage flips them back
+		walk = activeSorting.Reverse()
+	}
+
+	return activeSorting, walk, limit
+}
+
+// activeSorting sorting with its defaults, `_id` descending
+func activeSorting(sorting *model.Sorting) *model.Sorting {
+	active := *sorting
+	if active.Sort == "" {
+		active.Sort = "_id"
+	}
+	if active.Direction == 0 {
+		active.Direction = -1
+	}
+	return &active
+}
+
+// windowBooks keep sorted books after the pagination position, with one extra book telling if there is another page
+func windowBooks(books []model.Book, walk *model.Sorting, pagination *model.Pagination, limit int64) []model.Book {
+	if cursor := pagination.Cursor; cursor != nil {
+		after := books[:0]
+		for _, book := range books {
+			if afterCursor(&book, walk, cursor) {
+				after = append(after, book)
+			}
+		}
+		books = after
+	} else if pagination.Offset > 0 {
+		if pagination.Offset >= int64(len(books)) {
+			books = books[:0]
+		} else {
+			books = books[pagination.Offset:]
+		}
+	}
+
+	if int64(len(books)) > limit+1 {
+		books = books[:limit+1]
+	}
+	return books
+}
+
+// matchFilters check a book against list filters the way the mongo query does
+func matchFilters(book *model.Book, filters *model.BookFilter) bool {
+	if (book.DeletedAt != nil) != filters.Trashed {
+		return false
+	}
+
+	equal := map[string]interface{}{
+		"title":            filters.Title,
+		"subtitle":         filters.Subtitle,
+		"genre":            filters.Genre,
+		"series":           filters.Series,
+		"isbn_13":          filters.ISBN13,
+		"publisher":        filters.Publisher,
+		"language":         filters.Language,
+		"edition":          filters.Edition,
+		"number_of_pages":  filters.NumberOfPages,
+		"publication_year": filters.YearOfPublication,
+		"rating":           filters.Rating,
+	}
+	if !filters.ID.IsZero() {
+		equal["_id"] = filters.ID
+	}
+	if !filters.WorkID.IsZero() {
+		equal["work_id"] = filters.WorkID
+	}
+	if !filters.AuthorID.IsZero() && !book.HasAuthor(filters.AuthorID) {
+		return false
+	}
+	if filters.Author != "" && book.Author != filters.Author && !book.HasContributor(model.RoleAuthor, filters.Author) {
+		return false
+	}
+	if len(filters.Genres) > 0 && !containsString(filters.Genres, book.Genre) {
+		return false
+	}
+	for _, contributor := range filters.Contributors {
+		if !book.HasContributor(contributor.Role, contributor.Name) {
+			return false
+		}
+	}
+	for field, value := range equal {
+		if value == "" || value == int64(0) {
+			continue
+		}
+		if book.FieldValue(field) != value {
+			return false
+		}
+	}
+
+	for _, condition := range filters.Conditions {
+		if !matchCondition(book, condition) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCondition check an operator condition, missing fields only match ne and nin like with mongo
+func matchCondition(book *model.Book, condition model.FilterCondition) bool {
+	value := book.FieldValue(condition.Field)
+	missing := isMissing(book, condition.Field)
+
+	values, _ := condition.Value.([]interface{})
+	in := func() bool {
+		for _, v := range values {
+			if compareValues(value, v) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch condition.Operator {
+	case model.OpNe:
+		return missing || compareValues(value, condition.Value) != 0
+	case model.OpNin:
+		return missing || !in()
+	}
+	if missing {
+		return false
+	}
+
+	switch condition.Operator {
+	case model.OpEq:
+		return compareValues(value, condition.Value) == 0
+	case model.OpGt:
+		return compareValues(value, condition.Value) > 0
+	case model.OpGte:
+		return compareValues(value, condition.Value) >= 0
+	case model.OpLt:
+		return compareValues(value, condition.Value) < 0
+	case model.OpLte:
+		return compareValues(value, condition.Value) <= 0
+	case model.OpIn:
+		return in()
+	case model.OpBetween:
+		return compareValues(value, values[0]) >= 0 && compareValues(value, values[1]) <= 0
+	}
+	return false
+}
+
+// isMissing check if a field isn't stored, empty ISBN and live books deletion date are unset in mongo
+func isMissing(book *model.Book, field string) bool {
+	switch field {
+	case "isbn_10", "isbn_13":
+		return book.FieldValue(field) == ""
+	case "deleted_at":
+		return book.DeletedAt == nil
+	}
+	return false
+}
+
+// afterCursor check if book comes after the cursor position in the walk order, `_id` breaks ties
+func afterCursor(book *model.Book, walk *model.Sorting, cursor *model.Cursor) bool {
+	values := cursor.Values()
+	for i, key := range walk.Keys() {
+		if key.Field == "_id" {
+			break
+		}
+		if c := compareValues(walk.Value(book, key.Field), values[i]); c != 0 {
+			return c*int(key.Direction) > 0
+		}
+	}
+	return compareValues(book.ID, cursor.ID)*int(walk.TieDirection()) > 0
+}
+
+// sortBooks sort books by every key of sorting then `_id` in the direction of the last key
+func sortBooks(books []model.Book, sorting *model.Sorting) {
+	if sorting.Sort == "" {
+		sorting = &model.Sorting{Sort: "_id", Direction: 1}
+	}
+	if sorting.Direction == 0 {
+		sorting = &model.Sorting{Sort: sorting.Sort, Direction: 1, Then: sorting.Then, IgnoreArticles: sorting.IgnoreArticles}
+	}
+	keys := sorting.Keys()
+	sort.SliceStable(books, func(i, j int) bool {
+		for _, key := range keys {
+			if c := compareValues(sorting.Value(&books[i], key.Field), sorting.Value(&books[j], key.Field)); c != 0 {
+				return c*int(key.Direction) < 0
+			}
+		}
+		return compareValues(books[i].ID, books[j].ID)*int(sorting.TieDirection()) < 0
+	})
+}
+
+// compareValues compare two values of the same field type, -1, 0 or 1
+func compareValues(a interface{}, b interface{}) int {
+	switch a := a.(type) {
+	case string:
+		b, _ := b.(string)
+		return model.CompareStrings(a, b)
+	case int64:
+		b, _ := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case float64:
+		b, _ := b.(float64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case bool:
+		b, _ := b.(bool)
+		switch {
+		case !a && b:
+			return -1
+		case a && !b:
+			return 1
+		}
+	case time.Time:
+		b, _ := b.(time.Time)
+		switch {
+		case a.Before(b):
+			return -1
+		case a.After(b):
+			return 1
+		}
+	case objectid.ObjectID:
+		b, _ := b.(objectid.ObjectID)
+		return bytes.Compare(a[:], b[:])
+	}
+	return 0
+}
+
+// cloneBook copy a book so stored books are never shared with callers
+func cloneBook(book *model.Book) *model.Book {
+	clone := *book
+	clone.AuthorIDs = append([]objectid.ObjectID(nil), book.AuthorIDs...)
+	clone.Contributors = append([]model.Contributor(nil), book.Contributors...)
+	if book.DeletedAt != nil {
+		deletedAt := *book.DeletedAt
+		clone.DeletedAt = &deletedAt
+	}
+	return &clone
+}
+
+// containsString check if values has value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
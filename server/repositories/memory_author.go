@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// MemoryAuthorRepo authors repository keeping authors in memory
+type MemoryAuthorRepo struct {
+	interfaces.IAuthorRepository
+
+	mu      sync.RWMutex
+	authors map[objectid.ObjectID]*model.Author
+}
+
+// NewMemoryAuthorRepo create an empty in memory authors repository
+func NewMemoryAuthorRepo() *MemoryAuthorRepo {
+	return &MemoryAuthorRepo{authors: map[objectid.ObjectID]*model.Author{}}
+}
+
+// List get every author sorted by sort name, only the ones named name (or aliased) when it isn't empty
+func (repo *MemoryAuthorRepo) List(ctx context.Context, name string) ([]model.Author, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	authors := []model.Author{}
+	for _, author := range repo.authors {
+		if name == "" || author.HasName(name) {
+			authors = append(authors, *cloneAuthor(author))
+		}
+	}
+	model.SortAuthors(authors)
+
+	return authors, nil
+}
+
+// Get get an author by ID
+func (repo *MemoryAuthorRepo) Get(ctx context.Context, ID string) (*model.Author, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	author, ok := repo.authors[objectID]
+	if !ok {
+		return nil, model.ErrAuthorNotFound
+	}
+
+	return cloneAuthor(author), nil
+}
+
+// GetMany get authors by ID in the order of IDs, missing authors are left out
+func (repo *MemoryAuthorRepo) GetMany(ctx context.Context, IDs []objectid.ObjectID) ([]model.Author, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	authors := []model.Author{}
+	for _, ID := range IDs {
+		if author, ok := repo.authors[ID]; ok {
+			authors = append(authors, *cloneAuthor(author))
+		}
+	}
+
+	return authors, nil
+}
+
+// Create add a new author
+func (repo *MemoryAuthorRepo) Create(ctx context.Context, author *model.Author) (*model.Author, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	author.ID = objectid.New()
+	author.CreatedAt = now()
+	author.UpdatedAt = author.CreatedAt
+	repo.authors[author.ID] = cloneAuthor(author)
+
+	return author, nil
+}
+
+// Update replace an author by ID
+func (repo *MemoryAuthorRepo) Update(ctx context.Context, author *model.Author) (*model.Author, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.authors[author.ID]
+	if !ok {
+		return nil, model.ErrAuthorNotFound
+	}
+
+	author.CreatedAt = stored.CreatedAt
+	author.UpdatedAt = now()
+	repo.authors[author.ID] = cloneAuthor(author)
+
+	return author, nil
+}
+
+// Delete remove an author by ID
+func (repo *MemoryAuthorRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.authors[ID]; !ok {
+		return 0, nil
+	}
+	delete(repo.authors, ID)
+
+	return 1, nil
+}
+
+// Put keep a copy of author under its id as is, replacing the stored one
+func (repo *MemoryAuthorRepo) Put(ctx context.Context, author *model.Author) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.authors[author.ID] = cloneAuthor(author)
+
+	return nil
+}
+
+// cloneAuthor copy an author so stored authors are never shared with callers
+func cloneAuthor(author *model.Author) *model.Author {
+	clone := *author
+	clone.Aliases = append([]string{}, author.Aliases...)
+	return &clone
+}
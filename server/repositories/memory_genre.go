@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// MemoryGenreRepo genres repository keeping genres in memory
+type MemoryGenreRepo struct {
+	interfaces.IGenreRepository
+
+	mu     sync.RWMutex
+	genres map[objectid.ObjectID]*model.Genre
+}
+
+// NewMemoryGenreRepo create an empty in memory genres repository
+func NewMemoryGenreRepo() *MemoryGenreRepo {
+	return &MemoryGenreRepo{genres: map[objectid.ObjectID]*model.Genre{}}
+}
+
+// List get every genre sorted by name
+func (repo *MemoryGenreRepo) List(ctx context.Context) ([]model.Genre, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	genres := []model.Genre{}
+	for _, genre := range repo.genres {
+		genres = append(genres, *cloneGenre(genre))
+	}
+	model.SortGenres(genres)
+
+	return genres, nil
+}
+
+// Get get a genre by ID
+func (repo *MemoryGenreRepo) Get(ctx context.Context, ID string) (*model.Genre, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	genre, ok := repo.genres[objectID]
+	if !ok {
+		return nil, model.ErrGenreNotFound
+	}
+
+	return cloneGenre(genre), nil
+}
+
+// Create add a new genre
+func (repo *MemoryGenreRepo) Create(ctx context.Context, genre *model.Genre) (*model.Genre, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	genre.ID = objectid.New()
+	genre.CreatedAt = now()
+	genre.UpdatedAt = genre.CreatedAt
+	repo.genres[genre.ID] = cloneGenre(genre)
+
+	return genre, nil
+}
+
+// Update replace a genre by ID
+func (repo *MemoryGenreRepo) Update(ctx context.Context, genre *model.Genre) (*model.Genre, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.genres[genre.ID]
+	if !ok {
+		return nil, model.ErrGenreNotFound
+	}
+
+	genre.CreatedAt = stored.CreatedAt
+	genre.UpdatedAt = now()
+	repo.genres[genre.ID] = cloneGenre(genre)
+
+	return genre, nil
+}
+
+// Delete remove a genre by ID
+func (repo *MemoryGenreRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.genres[ID]; !ok {
+		return 0, nil
+	}
+	delete(repo.genres, ID)
+
+	return 1, nil
+}
+
+// Put keep a copy of genre under its id as is, replacing the stored one
+func (repo *MemoryGenreRepo) Put(ctx context.Context, genre *model.Genre) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.genres[genre.ID] = cloneGenre(genre)
+
+	return nil
+}
+
+// cloneGenre copy a genre so stored genres are never shared with callers
+func cloneGenre(genre *model.Genre) *model.Genre {
+	clone := *genre
+	clone.Synonyms = append([]string{}, genre.Synonyms...)
+	return &clone
+}
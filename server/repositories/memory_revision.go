@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// MemoryRevisionRepo book revisions repository keeping revisions in memory
+type MemoryRevisionRepo struct {
+	interfaces.IRevisionRepository
+
+	mu        sync.RWMutex
+	revisions map[objectid.ObjectID][]model.Revision
+}
+
+// NewMemoryRevisionRepo create an empty in memory revisions repository
+func NewMemoryRevisionRepo() *MemoryRevisionRepo {
+	return &MemoryRevisionRepo{revisions: map[objectid.ObjectID][]model.Revision{}}
+}
+
+// Append store a new revision, a revision number can't be written twice
+func (repo *MemoryRevisionRepo) Append(ctx context.Context, revision *model.Revision) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	revisions := repo.revisions[revision.BookID]
+	for _, stored := range revisions {
+		if stored.Number == revision.Number {
+			return model.ErrVersionConflict
+		}
+	}
+
+	revision.ID = objectid.New()
+	// revisions are kept sorted by number
+	i := len(revisions)
+	for i > 0 && revisions[i-1].Number > revision.Number {
+		i--
+	}
+	revisions = append(revisions, model.Revision{})
+	copy(revisions[i+1:], revisions[i:])
+	revisions[i] = *revision
+	repo.revisions[revision.BookID] = revisions
+
+	return nil
+}
+
+// List get every revision of a book, oldest first
+func (repo *MemoryRevisionRepo) List(ctx context.Context, bookID objectid.ObjectID) ([]model.Revision, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	return append([]model.Revision{}, repo.revisions[bookID]...), nil
+}
+
+// Get get a single revision of a book by number
+func (repo *MemoryRevisionRepo) Get(ctx context.Context, bookID objectid.ObjectID, number int64) (*model.Revision, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	for _, revision := range repo.revisions[bookID] {
+		if revision.Number == number {
+			return &revision, nil
+		}
+	}
+
+	return nil, model.ErrRevisionNotFound
+}
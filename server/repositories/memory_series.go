@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// MemorySeriesRepo series repository keeping series in memory
+type MemorySeriesRepo struct {
+	interfaces.ISeriesRepository
+
+	mu     sync.RWMutex
+	series map[objectid.ObjectID]*model.Series
+}
+
+// NewMemorySeriesRepo create an empty in memory series repository
+func NewMemorySeriesRepo() *MemorySeriesRepo {
+	return &MemorySeriesRepo{series: map[objectid.ObjectID]*model.Series{}}
+}
+
+// List get every series sorted by name
+func (repo *MemorySeriesRepo) List(ctx context.Context) ([]model.Series, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	list := []model.Series{}
+	for _, series := range repo.series {
+		list = append(list, *cloneSeries(series))
+	}
+	model.SortSeries(list)
+
+	return list, nil
+}
+
+// Get get a series by ID
+func (repo *MemorySeriesRepo) Get(ctx context.Context, ID string) (*model.Series, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	series, ok := repo.series[objectID]
+	if !ok {
+		return nil, model.ErrSeriesNotFound
+	}
+
+	return cloneSeries(series), nil
+}
+
+// Create add a new series
+func (repo *MemorySeriesRepo) Create(ctx context.Context, series *model.Series) (*model.Series, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	series.ID = objectid.New()
+	series.CreatedAt = now()
+	series.UpdatedAt = series.CreatedAt
+	repo.series[series.ID] = cloneSeries(series)
+
+	return series, nil
+}
+
+// Update replace a series by ID
+func (repo *MemorySeriesRepo) Update(ctx context.Context, series *model.Series) (*model.Series, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.series[series.ID]
+	if !ok {
+		return nil, model.ErrSeriesNotFound
+	}
+
+	series.CreatedAt = stored.CreatedAt
+	series.UpdatedAt = now()
+	repo.series[series.ID] = cloneSeries(series)
+
+	return series, nil
+}
+
+// Delete remove a series by ID
+func (repo *MemorySeriesRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.series[ID]; !ok {
+		return 0, nil
+	}
+	delete(repo.series, ID)
+
+	return 1, nil
+}
+
+// Put keep a copy of series under its id as is, replacing the stored one
+func (repo *MemorySeriesRepo) Put(ctx context.Context, series *model.Series) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.series[series.ID] = cloneSeries(series)
+
+	return nil
+}
+
+// cloneSeries copy a series so stored series are never shared with callers
+func cloneSeries(series *model.Series) *model.Series {
+	clone := *series
+	return &clone
+}
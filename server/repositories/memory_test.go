@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRepoList(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepo()
+	for _, book := range []*model.Book{
+		{Title: "Sardines", Rating: 5, YearOfPublication: 2001, ISBN13: "9780306406157"},
+		{Title: "Trawler", Rating: 4, YearOfPublication: 1999},
+		{Title: "Seagulls", Rating: 4, YearOfPublication: 2005},
+		{Title: "Boat", Rating: 2, YearOfPublication: 1999},
+	} {
+		_, err := repo.Create(ctx, book)
+		assert.NoError(t, err)
+	}
+
+	page, err := repo.List(ctx, &model.BookFilter{Rating: 4}, &model.Sorting{Sort: "title", Direction: 1}, &model.Pagination{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total)
+	assert.Equal(t, "Seagulls", page.Books[0].Title)
+
+	gte, _ := model.NewFilterCondition("rating", model.OpGte, "4")
+	ne, _ := model.NewFilterCondition("isbn_13", model.OpNe, "9780306406157")
+	page, _ = repo.List(ctx, &model.BookFilter{Conditions: []model.FilterCondition{*gte, *ne}}, &model.Sorting{}, &model.Pagination{})
+	assert.Equal(t, int64(2), page.Total, "It should match missing ISBN with ne")
+
+	sorting := &model.Sorting{Sort: "publication_year", Direction: 1}
+	page, _ = repo.List(ctx, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2})
+	assert.Equal(t, []string{"Trawler", "Boat"}, titles(page), "It should break ties with _id")
+	assert.NotEmpty(t, page.NextCursor)
+	assert.Empty(t, page.PrevCursor)
+
+	cursor, err := model.DecodeCursor(page.NextCursor, sorting)
+	assert.NoError(t, err)
+	page, _ = repo.List(ctx, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Cursor: cursor})
+	assert.Equal(t, []string{"Sardines", "Seagulls"}, titles(page))
+	assert.Empty(t, page.NextCursor)
+
+	cursor, _ = model.DecodeCursor(page.PrevCursor, sorting)
+	page, _ = repo.List(ctx, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Cursor: cursor})
+	assert.Equal(t, []string{"Trawler", "Boat"}, titles(page))
+
+	page, _ = repo.List(ctx, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Offset: 3})
+	assert.Equal(t, []string{"Seagulls"}, titles(page))
+	assert.NotEmpty(t, page.PrevCursor)
+}
+
+func TestMemoryRepoWrites(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepo()
+
+	created, _ := repo.Create(ctx, &model.Book{Title: "Sardines", ISBN13: "9780306406157"})
+	assert.False(t, created.ID.IsZero())
+	assert.Equal(t, int64(1), created.Version)
+
+	_, err := repo.Create(ctx, &model.Book{Title: "Copy", ISBN13: "9780306406157"})
+	assert.Equal(t, model.ErrDuplicateISBN, err)
+
+	_, err = repo.Get(ctx, objectid.New().Hex())
+	assert.Equal(t, model.ErrNotFound, err)
+	_, err = repo.Get(ctx, "nope")
+	assert.Error(t, err)
+
+	book, _ := repo.Get(ctx, created.ID.Hex())
+	book.Title = "Sardines, revised"
+	book.Rating = 5
+	_, err = repo.Patch(ctx, book, []string{"title"})
+	assert.NoError(t, err)
+
+	stored, _ := repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, "Sardines, revised", stored.Title)
+	assert.Equal(t, int64(0), stored.Rating, "It should only change patched fields")
+	assert.Equal(t, int64(2), stored.Version)
+
+	_, err = repo.Update(ctx, &model.Book{ID: created.ID, Version: 1})
+	assert.Equal(t, model.ErrVersionConflict, err)
+
+	deleted, err := repo.Delete(ctx, created.ID, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, model.ErrNotFound, err)
+	trashed, _ := repo.GetTrashed(ctx, created.ID.Hex())
+	assert.NotNil(t, trashed.DeletedAt)
+
+	restored, err := repo.Restore(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, restored.DeletedAt)
+	assert.Equal(t, int64(4), restored.Version)
+
+	repo.Delete(ctx, created.ID, 4)
+	purged, _ := repo.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	assert.Equal(t, int64(1), purged)
+}
+
+func titles(page *model.BookPage) []string {
+	var titles []string
+	for _, book := range page.Books {
+		titles = append(titles, book.Title)
+	}
+	return titles
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// MemoryWorkRepo work repository keeping works in memory
+type MemoryWorkRepo struct {
+	interfaces.IWorkRepository
+
+	mu    sync.RWMutex
+	works map[objectid.ObjectID]*model.Work
+}
+
+// NewMemoryWorkRepo create an empty in memory work repository
+func NewMemoryWorkRepo() *MemoryWorkRepo {
+	return &MemoryWorkRepo{works: map[objectid.ObjectID]*model.Work{}}
+}
+
+// List get every work sorted by title
+func (repo *MemoryWorkRepo) List(ctx context.Context) ([]model.Work, error) {
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	works := []model.Work{}
+	for _, work := range repo.works {
+		works = append(works, *cloneWork(work))
+	}
+	model.SortWorks(works)
+
+	return works, nil
+}
+
+// Get get a work by ID
+func (repo *MemoryWorkRepo) Get(ctx context.Context, ID string) (*model.Work, error) {
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	repo.mu.RLock()
+	defer repo.mu.RUnlock()
+
+	work, ok := repo.works[objectID]
+	if !ok {
+		return nil, model.ErrWorkNotFound
+	}
+
+	return cloneWork(work), nil
+}
+
+// Create add a new work
+func (repo *MemoryWorkRepo) Create(ctx context.Context, work *model.Work) (*model.Work, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	work.ID = objectid.New()
+	work.CreatedAt = now()
+	work.UpdatedAt = work.CreatedAt
+	repo.works[work.ID] = cloneWork(work)
+
+	return work, nil
+}
+
+// Update replace a work by ID
+func (repo *MemoryWorkRepo) Update(ctx context.Context, work *model.Work) (*model.Work, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	stored, ok := repo.works[work.ID]
+	if !ok {
+		return nil, model.ErrWorkNotFound
+	}
+
+	work.CreatedAt = stored.CreatedAt
+	work.UpdatedAt = now()
+	repo.works[work.ID] = cloneWork(work)
+
+	return work, nil
+}
+
+// Delete remove a work by ID
+func (repo *MemoryWorkRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	if _, ok := repo.works[ID]; !ok {
+		return 0, nil
+	}
+	delete(repo.works, ID)
+
+	return 1, nil
+}
+
+// Put keep a copy of work under its id as is, replacing the stored one
+func (repo *MemoryWorkRepo) Put(ctx context.Context, work *model.Work) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.works[work.ID] = cloneWork(work)
+
+	return nil
+}
+
+// cloneWork copy a work so stored works are never shared with callers
+func cloneWork(work *model.Work) *model.Work {
+	clone := *work
+	return &clone
+}
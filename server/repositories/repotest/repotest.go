@@ -0,0 +1,1143 @@
+// Package repotest is a conformance suite checking `interfaces.IBookRepository` and `interfaces.IAuthorRepository`
+// implementations behave like the mongo ones.
+package repotest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+)
+
+// Factory return an empty repository and a function releasing it, it is called once per test
+type Factory func(t *testing.T) (interfaces.IBookRepository, func())
+
+// RunBookRepository run every contract test against repositories built by factory
+func RunBookRepository(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo interfaces.IBookRepository)
+	}{
+		{"Create", testCreate},
+		{"Get", testGet},
+		{"Update", testUpdate},
+		{"Patch", testPatch},
+		{"DuplicateISBN", testDuplicateISBN},
+		{"Filters", testFilters},
+		{"AuthorFilter", testAuthorFilter},
+		{"ContributorFilter", testContributorFilter},
+		{"GenreFilter", testGenreFilter},
+		{"WorkFilter", testWorkFilter},
+		{"SeriesOrder", testSeriesOrder},
+		{"Sort", testSort},
+		{"MultiSort", testMultiSort},
+		{"Collation", testCollation},
+		{"Limits", testLimits},
+		{"Cursors", testCursors},
+		{"Trash", testTrash},
+		{"Search", testSearch},
+		{"Stream", testStream},
+		{"ConcurrentCreates", testConcurrentCreates},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			repo, cleanup := factory(t)
+			defer cleanup()
+			tt.test(t, repo)
+		})
+	}
+}
+
+// fixtures books shared by list tests, inserted in this order
+func fixtures() []*model.Book {
+	return []*model.Book{
+		{Title: "Sardines", Author: "Mathieu Doyon", Genre: "Fiction", ISBN13: "9780306406157", Language: "fr", Rating: 5, YearOfPublication: 2001, NumberOfPages: 345},
+		{Title: "Trawler", Author: "Mathieu Doyon", Genre: "Fiction", Rating: 4, YearOfPublication: 1999, NumberOfPages: 120},
+		{Title: "Seagulls", Author: "Eric Cantona", Genre: "Poetry", Rating: 4, YearOfPublication: 2005, NumberOfPages: 80},
+		{Title: "Boat", Author: "Mathieu", Genre: "Fiction", Rating: 2, YearOfPublication: 1999, NumberOfPages: 200},
+		{Title: "Anchor", Author: "Eric Cantona", Genre: "Essay", Rating: 3, YearOfPublication: 2010, NumberOfPages: 150},
+	}
+}
+
+// insert create fixtures into repo, returned in insertion order
+func insert(t *testing.T, repo interfaces.IBookRepository, books []*model.Book) []*model.Book {
+	ctx := context.Background()
+	created := make([]*model.Book, len(books))
+	for i, book := range books {
+		var err error
+		created[i], err = repo.Create(ctx, book)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+	return created
+}
+
+// list list books, failing the test on error
+func list(t *testing.T, repo interfaces.IBookRepository, filters *model.BookFilter, sorting *model.Sorting, pagination *model.Pagination) *model.BookPage {
+	ctx := context.Background()
+	page, err := repo.List(ctx, filters, sorting, pagination)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return page
+}
+
+// condition build an operator condition, failing the test on error
+func condition(t *testing.T, field string, operator string, raw string) model.FilterCondition {
+	c, err := model.NewFilterCondition(field, operator, raw)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return *c
+}
+
+// titles titles of a page of books, in order
+func titles(page *model.BookPage) []string {
+	titles := []string{}
+	for _, book := range page.Books {
+		titles = append(titles, book.Title)
+	}
+	return titles
+}
+
+func testCreate(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	before := time.Now().Add(-time.Second)
+	book, err := repo.Create(ctx, &model.Book{Title: "Sardines", Rating: 5, Version: 42})
+
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.False(t, book.ID.IsZero(), "It should generate an id")
+	assert.Equal(t, int64(1), book.Version, "It should start at version 1")
+	assert.True(t, book.CreatedAt.After(before))
+	assert.Equal(t, book.CreatedAt, book.UpdatedAt)
+	assert.Nil(t, book.DeletedAt)
+
+	other, _ := repo.Create(ctx, &model.Book{Title: "Trawler"})
+	assert.NotEqual(t, book.ID, other.ID)
+}
+
+func testGet(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	created := insert(t, repo, fixtures())[0]
+
+	book, err := repo.Get(ctx, created.ID.Hex())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, created.ID, book.ID)
+	assert.Equal(t, "Sardines", book.Title)
+	assert.Equal(t, "9780306406157", book.ISBN13)
+	assert.Equal(t, int64(345), book.NumberOfPages)
+	assert.Equal(t, int64(1), book.Version)
+	assert.True(t, created.CreatedAt.Equal(book.CreatedAt))
+
+	_, err = repo.Get(ctx, objectid.New().Hex())
+	assert.Equal(t, model.ErrNotFound, err, "It should not find unknown ids")
+
+	_, err = repo.Get(ctx, "not-an-id")
+	assert.Equal(t, model.ErrInvalidID, err, "It should reject invalid ids")
+
+	_, err = repo.GetTrashed(ctx, created.ID.Hex())
+	assert.Equal(t, model.ErrNotFound, err, "It should not find live books into trash")
+}
+
+func testUpdate(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	created := insert(t, repo, fixtures())[0]
+	time.Sleep(2 * time.Millisecond)
+
+	book, _ := repo.Get(ctx, created.ID.Hex())
+	book.Title = "Sardines, revised"
+	book.Genre = ""
+	updated, err := repo.Update(ctx, book)
+
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(2), updated.Version)
+	assert.True(t, updated.UpdatedAt.After(created.UpdatedAt))
+
+	stored, _ := repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, "Sardines, revised", stored.Title)
+	assert.Equal(t, "", stored.Genre, "It should replace every field")
+	assert.Equal(t, "Mathieu Doyon", stored.Author)
+	assert.Equal(t, int64(2), stored.Version)
+	assert.True(t, created.CreatedAt.Equal(stored.CreatedAt), "It should keep the creation date")
+
+	book.Version = 1
+	_, err = repo.Update(ctx, book)
+	assert.Equal(t, model.ErrVersionConflict, err, "It should reject stale versions")
+
+	_, err = repo.Update(ctx, &model.Book{ID: objectid.New(), Version: 1})
+	assert.Equal(t, model.ErrVersionConflict, err, "It should not create unknown books")
+}
+
+func testPatch(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	created := insert(t, repo, fixtures())[0]
+
+	patched, err := repo.Patch(ctx, &model.Book{ID: created.ID, Version: 1, Rating: 1, Title: "Ignored"}, []string{"rating"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(2), patched.Version)
+
+	stored, _ := repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, int64(1), stored.Rating)
+	assert.Equal(t, "Sardines", stored.Title, "It should only change patched fields")
+
+	unchanged, err := repo.Patch(ctx, stored, nil)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(2), unchanged.Version, "It should not write without fields")
+
+	_, err = repo.Patch(ctx, &model.Book{ID: created.ID, Version: 1}, []string{"rating"})
+	assert.Equal(t, model.ErrVersionConflict, err)
+}
+
+func testDuplicateISBN(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	created := insert(t, repo, fixtures())
+
+	_, err := repo.Create(ctx, &model.Book{Title: "Copy", ISBN13: "9780306406157"})
+	assert.Equal(t, model.ErrDuplicateISBN, err)
+
+	other, _ := repo.Get(ctx, created[1].ID.Hex())
+	other.ISBN13 = "9780306406157"
+	_, err = repo.Update(ctx, other)
+	assert.Equal(t, model.ErrDuplicateISBN, err)
+
+	_, err = repo.Create(ctx, &model.Book{Title: "No ISBN"})
+	assert.NoError(t, err, "It should allow many books without ISBN")
+}
+
+func testFilters(t *testing.T, repo interfaces.IBookRepository) {
+	created := insert(t, repo, fixtures())
+	sorting := &model.Sorting{Sort: "title", Direction: 1}
+
+	tests := []struct {
+		name     string
+		filters  *model.BookFilter
+		expected []string
+	}{
+		{"none", &model.BookFilter{}, []string{"Anchor", "Boat", "Sardines", "Seagulls", "Trawler"}},
+		{"id", &model.BookFilter{ID: created[1].ID}, []string{"Trawler"}},
+		{"exact author", &model.BookFilter{Author: "Mathieu"}, []string{"Boat"}},
+		{"author and rating", &model.BookFilter{Author: "Mathieu Doyon", Rating: 4}, []string{"Trawler"}},
+		{"genre and year", &model.BookFilter{Genre: "Fiction", YearOfPublication: 1999}, []string{"Boat", "Trawler"}},
+		{"isbn", &model.BookFilter{ISBN13: "9780306406157"}, []string{"Sardines"}},
+		{"language", &model.BookFilter{Language: "fr"}, []string{"Sardines"}},
+		{"gte", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "rating", model.OpGte, "4"),
+		}}, []string{"Sardines", "Seagulls", "Trawler"}},
+		{"gt and lt", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "publication_year", model.OpGt, "1999"),
+			condition(t, "number_of_pages", model.OpLt, "200"),
+		}}, []string{"Anchor", "Seagulls"}},
+		{"lte", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "rating", model.OpLte, "2"),
+		}}, []string{"Boat"}},
+		{"in", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "genre", model.OpIn, "Poetry,Essay"),
+		}}, []string{"Anchor", "Seagulls"}},
+		{"in repeated values", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "rating", model.OpIn, "3,3"),
+		}}, []string{"Anchor"}},
+		{"in repeated authors", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "author", model.OpIn, "Eric Cantona,Mathieu,Eric Cantona"),
+		}}, []string{"Anchor", "Boat", "Seagulls"}},
+		{"in repeated genres", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "genre", model.OpIn, "Essay,Essay"),
+		}}, []string{"Anchor"}},
+		{"indexed number range", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "rating", model.OpGt, "2"),
+			condition(t, "rating", model.OpLt, "5"),
+		}}, []string{"Anchor", "Seagulls", "Trawler"}},
+		{"indexed between", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "rating", model.OpBetween, "3,4"),
+		}}, []string{"Anchor", "Seagulls", "Trawler"}},
+		{"genre range", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "genre", model.OpBetween, "e,g"),
+		}}, []string{"Anchor", "Boat", "Sardines", "Trawler"}},
+		{"genre gte", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "genre", model.OpGte, "f"),
+		}}, []string{"Boat", "Sardines", "Seagulls", "Trawler"}},
+		{"nin", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "genre", model.OpNin, "Poetry,Essay"),
+		}}, []string{"Boat", "Sardines", "Trawler"}},
+		{"between and equality", &model.BookFilter{Genre: "Fiction", Conditions: []model.FilterCondition{
+			condition(t, "publication_year", model.OpBetween, "1999,2001"),
+			condition(t, "rating", model.OpNe, "2"),
+		}}, []string{"Sardines", "Trawler"}},
+		{"ne on missing isbn", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "isbn_13", model.OpNe, "9780306406157"),
+		}}, []string{"Anchor", "Boat", "Seagulls", "Trawler"}},
+		{"string range", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "title", model.OpGte, "S"),
+		}}, []string{"Sardines", "Seagulls", "Trawler"}},
+		{"indexed string range", &model.BookFilter{Conditions: []model.FilterCondition{
+			condition(t, "author", model.OpGt, "e"),
+			condition(t, "author", model.OpLt, "mathieu d"),
+		}}, []string{"Anchor", "Boat", "Seagulls"}},
+		{"no match", &model.BookFilter{Author: "Nobody"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		page := list(t, repo, tt.filters, sorting, &model.Pagination{})
+		assert.Equal(t, tt.expected, titles(page), tt.name)
+		assert.Equal(t, int64(len(tt.expected)), page.Total, tt.name)
+	}
+
+	since := condition(t, "created_at", model.OpLte, time.Now().Add(time.Minute).UTC().Format(time.RFC3339Nano))
+	page := list(t, repo, &model.BookFilter{Conditions: []model.FilterCondition{since}}, sorting, &model.Pagination{})
+	assert.Equal(t, int64(5), page.Total, "It should filter dates")
+}
+
+func testAuthorFilter(t *testing.T, repo interfaces.IBookRepository) {
+	doyon, cantona := objectid.New(), objectid.New()
+	insert(t, repo, []*model.Book{
+		{Title: "Sardines", AuthorIDs: []objectid.ObjectID{doyon}},
+		{Title: "Seagulls", AuthorIDs: []objectid.ObjectID{cantona, doyon}},
+		{Title: "Anchor", AuthorIDs: []objectid.ObjectID{cantona}},
+		{Title: "Boat"},
+	})
+	sorting := &model.Sorting{Sort: "title", Direction: 1}
+
+	page := list(t, repo, &model.BookFilter{AuthorID: doyon}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Sardines", "Seagulls"}, titles(page), "It should list books linking to the author")
+
+	book, err := repo.Get(context.Background(), page.Books[1].ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, []objectid.ObjectID{cantona, doyon}, book.AuthorIDs, "It should keep authors in link order")
+	}
+
+	page = list(t, repo, &model.BookFilter{AuthorID: objectid.New()}, sorting, &model.Pagination{})
+	assert.Empty(t, page.Books)
+}
+
+func testContributorFilter(t *testing.T, repo interfaces.IBookRepository) {
+	doyon := objectid.New()
+	insert(t, repo, []*model.Book{
+		{Title: "Sardinas", Author: "Eric Cantona", Contributors: []model.Contributor{
+			{Name: "Eric Cantona", Role: model.RoleAuthor},
+			{AuthorID: doyon, Name: "Mathieu Doyon", Role: model.RoleTranslator},
+			{Name: "Jane Roe", Role: model.RoleIllustrator},
+		}},
+		{Title: "Seagulls", Author: "Eric Cantona", Contributors: []model.Contributor{
+			{Name: "Eric Cantona", Role: model.RoleAuthor},
+			{Name: "Mathieu Doyon", Role: model.RoleEditor},
+		}},
+		{Title: "Sardines", Author: "Mathieu Doyon"},
+	})
+	sorting := &model.Sorting{Sort: "title", Direction: 1}
+
+	filter := &model.BookFilter{Contributors: []model.Contributor{{Name: "Mathieu Doyon", Role: model.RoleTranslator}}}
+	page := list(t, repo, filter, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Sardinas"}, titles(page), "It should match the contributor role")
+	if assert.Len(t, page.Books, 1) && assert.Len(t, page.Books[0].Contributors, 3) {
+		assert.Equal(t, doyon, page.Books[0].Contributors[1].AuthorID)
+	}
+
+	filter.Contributors = append(filter.Contributors, model.Contributor{Name: "Jane Roe", Role: model.RoleIllustrator})
+	page = list(t, repo, filter, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Sardinas"}, titles(page), "It should match every contributor")
+
+	filter.Contributors[1].Role = model.RoleNarrator
+	page = list(t, repo, filter, sorting, &model.Pagination{})
+	assert.Empty(t, page.Books)
+
+	page = list(t, repo, &model.BookFilter{AuthorID: doyon}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Sardinas"}, titles(page), "It should list books having the author as contributor")
+
+	page = list(t, repo, &model.BookFilter{Author: "Eric Cantona"}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Sardinas", "Seagulls"}, titles(page))
+
+	insert(t, repo, []*model.Book{
+		{Title: "Boat", Author: "Eric Cantona & Jane Roe", Contributors: []model.Contributor{
+			{Name: "Eric Cantona", Role: model.RoleAuthor},
+			{Name: "Jane Roe", Role: model.RoleAuthor},
+		}},
+	})
+	page = list(t, repo, &model.BookFilter{Author: "Jane Roe"}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Boat"}, titles(page), "It should match one of several authors, not other roles")
+
+	page = list(t, repo, &model.BookFilter{Author: "Eric Cantona"}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Boat", "Sardinas", "Seagulls"}, titles(page))
+
+	page = list(t, repo, &model.BookFilter{Author: "Eric Cantona & Jane Roe"}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Boat"}, titles(page), "It should still match the author text")
+}
+
+func testGenreFilter(t *testing.T, repo interfaces.IBookRepository) {
+	insert(t, repo, fixtures())
+	sorting := &model.Sorting{Sort: "title", Direction: 1}
+
+	page := list(t, repo, &model.BookFilter{Genres: []string{"Poetry", "Essay"}}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Anchor", "Seagulls"}, titles(page), "It should match any of the genres")
+
+	page = list(t, repo, &model.BookFilter{Genres: []string{"Essay"}, Author: "Mathieu Doyon"}, sorting, &model.Pagination{})
+	assert.Empty(t, page.Books, "It should match the other filters too")
+
+	page = list(t, repo, &model.BookFilter{Genres: []string{"Poetry", "Poetry"}}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Seagulls"}, titles(page), "It should list books once when genres repeat")
+	assert.Equal(t, int64(1), page.Total)
+
+	page = list(t, repo, &model.BookFilter{Genres: []string{"Drama"}}, sorting, &model.Pagination{})
+	assert.Empty(t, page.Books)
+}
+
+func testWorkFilter(t *testing.T, repo interfaces.IBookRepository) {
+	work := objectid.New()
+	insert(t, repo, []*model.Book{
+		{Title: "Sardines", WorkID: work},
+		{Title: "Sardines", WorkID: work, Edition: 2},
+		{Title: "Seagulls", WorkID: objectid.New()},
+		{Title: "Boat"},
+	})
+	sorting := &model.Sorting{Sort: "title", Direction: 1, Then: []model.SortKey{{Field: "edition", Direction: 1}}}
+
+	page := list(t, repo, &model.BookFilter{WorkID: work}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Sardines", "Sardines"}, titles(page), "It should list the editions of the work")
+	if assert.Len(t, page.Books, 2) {
+		assert.Equal(t, work, page.Books[1].WorkID, "It should keep the work of the books")
+	}
+
+	page = list(t, repo, &model.BookFilter{WorkID: objectid.New()}, sorting, &model.Pagination{})
+	assert.Empty(t, page.Books)
+}
+
+func testSeriesOrder(t *testing.T, repo interfaces.IBookRepository) {
+	insert(t, repo, []*model.Book{
+		{Title: "Trawler", Series: "Sea", SeriesPosition: 2},
+		{Title: "Seagulls", Series: "Birds", SeriesPosition: 1},
+		{Title: "Sardines", Series: "Sea", SeriesPosition: 1},
+		{Title: "Anchor", Series: "Sea", SeriesPosition: 1.5, Read: true},
+		{Title: "Boat"},
+	})
+	sorting := &model.Sorting{Sort: "series", Direction: 1, Then: []model.SortKey{{Field: "series_position", Direction: 1}}}
+
+	page := list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Boat", "Seagulls", "Sardines", "Anchor", "Trawler"}, titles(page), "It should sort by series then position")
+
+	readingOrder := model.ReadingOrder
+	var seen []string
+	page = list(t, repo, &model.BookFilter{Series: "Sea"}, &readingOrder, &model.Pagination{Limit: 2})
+	for {
+		seen = append(seen, titles(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor, err := model.DecodeCursor(page.NextCursor, &readingOrder)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		page = list(t, repo, &model.BookFilter{Series: "Sea"}, &readingOrder, &model.Pagination{Limit: 2, Cursor: cursor})
+	}
+	assert.Equal(t, []string{"Sardines", "Anchor", "Trawler"}, seen, "It should walk pages of fractional positions")
+
+	page = list(t, repo, &model.BookFilter{Conditions: []model.FilterCondition{condition(t, "read", "eq", "true")}}, sorting, &model.Pagination{})
+	assert.Equal(t, []string{"Anchor"}, titles(page))
+}
+
+func testSort(t *testing.T, repo interfaces.IBookRepository) {
+	created := insert(t, repo, fixtures())
+
+	tests := []struct {
+		sorting  *model.Sorting
+		expected []string
+	}{
+		{&model.Sorting{Sort: "title", Direction: 1}, []string{"Anchor", "Boat", "Sardines", "Seagulls", "Trawler"}},
+		{&model.Sorting{Sort: "title", Direction: -1}, []string{"Trawler", "Seagulls", "Sardines", "Boat", "Anchor"}},
+		// ties are broken by _id in the same direction
+		{&model.Sorting{Sort: "rating", Direction: -1}, []string{"Sardines", "Seagulls", "Trawler", "Anchor", "Boat"}},
+		{&model.Sorting{Sort: "publication_year", Direction: 1}, []string{"Trawler", "Boat", "Sardines", "Seagulls", "Anchor"}},
+		{&model.Sorting{Sort: "author", Direction: 1}, []string{"Seagulls", "Anchor", "Boat", "Sardines", "Trawler"}},
+		// _id descending by default
+		{&model.Sorting{}, []string{"Anchor", "Boat", "Seagulls", "Trawler", "Sardines"}},
+	}
+
+	for _, tt := range tests {
+		page := list(t, repo, &model.BookFilter{}, tt.sorting, &model.Pagination{})
+		assert.Equal(t, tt.expected, titles(page), "%s %d", tt.sorting.Sort, tt.sorting.Direction)
+	}
+
+	// created in order, so _id order is creation order
+	assert.True(t, created[0].ID.Hex() < created[4].ID.Hex())
+}
+
+func testMultiSort(t *testing.T, repo interfaces.IBookRepository) {
+	insert(t, repo, fixtures())
+	sorting := &model.Sorting{Sort: "rating", Direction: -1, Then: []model.SortKey{{Field: "author", Direction: 1}, {Field: "title", Direction: -1}}}
+	expected := []string{"Sardines", "Seagulls", "Trawler", "Anchor", "Boat"}
+
+	page := list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{})
+	assert.Equal(t, expected, titles(page), "It should sort ties by the following keys")
+
+	var seen []string
+	page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2})
+	for {
+		seen = append(seen, titles(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor, err := model.DecodeCursor(page.NextCursor, sorting)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Cursor: cursor})
+	}
+	assert.Equal(t, expected, seen, "It should walk pages of several keys")
+
+	cursor, err := model.DecodeCursor(page.PrevCursor, sorting)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Cursor: cursor})
+	assert.Equal(t, []string{"Trawler", "Anchor"}, titles(page), "It should walk back in order")
+}
+
+func testCollation(t *testing.T, repo interfaces.IBookRepository) {
+	insert(t, repo, []*model.Book{
+		{Title: "The Trawler", Author: "Émile Zola"},
+		{Title: "Anchor", Author: "Eric Cantona"},
+		{Title: "Le Boat", Author: "Emile Ajar"},
+		{Title: "Zebra", Author: "Albert Camus"},
+		{Title: "La Mer", Author: "Zola"},
+	})
+
+	page := list(t, repo, &model.BookFilter{}, &model.Sorting{Sort: "author", Direction: 1}, &model.Pagination{})
+	assert.Equal(t, []string{"Zebra", "Le Boat", "The Trawler", "Anchor", "La Mer"}, titles(page), "It should sort accents with their letter")
+
+	page = list(t, repo, &model.BookFilter{}, &model.Sorting{Sort: "title", Direction: 1}, &model.Pagination{})
+	assert.Equal(t, []string{"Anchor", "La Mer", "Le Boat", "The Trawler", "Zebra"}, titles(page))
+
+	sorting := &model.Sorting{Sort: "title", Direction: 1, IgnoreArticles: true}
+	expected := []string{"Anchor", "Le Boat", "La Mer", "The Trawler", "Zebra"}
+	page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{})
+	assert.Equal(t, expected, titles(page), "It should sort titles without their article")
+
+	var seen []string
+	page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2})
+	for {
+		seen = append(seen, titles(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor, err := model.DecodeCursor(page.NextCursor, sorting)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Cursor: cursor})
+	}
+	assert.Equal(t, expected, seen)
+
+	// ICU "en" breaks ties on accents, then on case with lower case first
+	insert(t, repo, []*model.Book{
+		{Title: "Upper", Author: "Bob", Rating: 1},
+		{Title: "Accent", Author: "bób", Rating: 1},
+		{Title: "Lower", Author: "bob", Rating: 1},
+	})
+	page = list(t, repo, &model.BookFilter{Rating: 1}, &model.Sorting{Sort: "author", Direction: 1}, &model.Pagination{})
+	assert.Equal(t, []string{"Lower", "Upper", "Accent"}, titles(page), "It should break ties like ICU en")
+}
+
+func testLimits(t *testing.T, repo interfaces.IBookRepository) {
+	var books []*model.Book
+	for i := 0; i < 12; i++ {
+		books = append(books, &model.Book{Title: "Book", Rating: int64(i)})
+	}
+	insert(t, repo, books)
+	sorting := &model.Sorting{Sort: "rating", Direction: 1}
+
+	page := list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{})
+	assert.Len(t, page.Books, int(model.DefaultLimit), "It should default the limit")
+	assert.Equal(t, int64(12), page.Total)
+	assert.Equal(t, model.DefaultLimit, page.Limit)
+
+	page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 5, Offset: 10})
+	assert.Len(t, page.Books, 2)
+	assert.Equal(t, int64(10), page.Books[0].Rating)
+	assert.Equal(t, int64(10), page.Offset)
+	assert.Empty(t, page.NextCursor)
+	assert.NotEmpty(t, page.PrevCursor)
+
+	page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 5, Offset: 20})
+	assert.Empty(t, page.Books, "It should return an empty page past the end")
+	assert.Equal(t, int64(12), page.Total)
+}
+
+func testCursors(t *testing.T, repo interfaces.IBookRepository) {
+	insert(t, repo, fixtures())
+	sorting := &model.Sorting{Sort: "rating", Direction: -1}
+
+	var seen []string
+	page := list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2})
+	assert.Empty(t, page.PrevCursor)
+	for {
+		seen = append(seen, titles(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor, err := model.DecodeCursor(page.NextCursor, sorting)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Cursor: cursor})
+	}
+	assert.Equal(t, []string{"Sardines", "Seagulls", "Trawler", "Anchor", "Boat"}, seen)
+
+	cursor, err := model.DecodeCursor(page.PrevCursor, sorting)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	page = list(t, repo, &model.BookFilter{}, sorting, &model.Pagination{Limit: 2, Cursor: cursor})
+	assert.Equal(t, []string{"Trawler", "Anchor"}, titles(page), "It should walk back in order")
+	assert.NotEmpty(t, page.NextCursor)
+	assert.NotEmpty(t, page.PrevCursor)
+}
+
+func testTrash(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	created := insert(t, repo, fixtures())[0]
+
+	_, err := repo.Delete(ctx, created.ID, 2)
+	assert.Equal(t, model.ErrVersionConflict, err, "It should reject stale versions")
+
+	deleted, err := repo.Delete(ctx, created.ID, 1)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(1), deleted)
+
+	_, err = repo.Delete(ctx, created.ID, 2)
+	assert.Error(t, err, "It should not trash a book twice")
+
+	_, err = repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, model.ErrNotFound, err, "It should hide trashed books")
+	trashed, err := repo.GetTrashed(ctx, created.ID.Hex())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.NotNil(t, trashed.DeletedAt)
+	assert.Equal(t, int64(2), trashed.Version)
+
+	page := list(t, repo, &model.BookFilter{}, &model.Sorting{}, &model.Pagination{})
+	assert.Equal(t, int64(4), page.Total)
+	page = list(t, repo, &model.BookFilter{Trashed: true}, &model.Sorting{}, &model.Pagination{})
+	assert.Equal(t, []string{"Sardines"}, titles(page))
+
+	restored, err := repo.Restore(ctx, created.ID)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Nil(t, restored.DeletedAt)
+	assert.Equal(t, int64(3), restored.Version)
+
+	repo.Delete(ctx, created.ID, 3)
+	purged, err := repo.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(0), purged, "It should keep books trashed after the date")
+	purged, err = repo.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(1), purged)
+	_, err = repo.GetTrashed(ctx, created.ID.Hex())
+	assert.Equal(t, model.ErrNotFound, err)
+
+	other, _ := repo.Create(ctx, &model.Book{Title: "Live"})
+	purged, err = repo.Purge(ctx, other.ID)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(1), purged, "It should purge live books")
+	purged, err = repo.Purge(ctx, other.ID)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, int64(0), purged)
+}
+
+func testSearch(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	created := insert(t, repo, fixtures())
+	repo.Delete(ctx, created[2].ID, 1)
+
+	page, err := repo.Search(ctx, "cantona", 10)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if !assert.Len(t, page.Results, 1, "It should not find trashed books") {
+		t.FailNow()
+	}
+	assert.Equal(t, "Anchor", page.Results[0].Book.Title)
+
+	page, err = repo.Search(ctx, "   ", 10)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Empty(t, page.Results)
+}
+
+func testStream(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	insert(t, repo, fixtures())
+
+	var streamed []string
+	err := repo.Stream(ctx, &model.BookFilter{Genre: "Fiction"}, &model.Sorting{Sort: "title", Direction: 1}, func(book *model.Book) error {
+		streamed = append(streamed, book.Title)
+		return nil
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"Boat", "Sardines", "Trawler"}, streamed, "It should not be limited")
+
+	stop := model.ErrVersionConflict
+	count := 0
+	err = repo.Stream(ctx, &model.BookFilter{}, &model.Sorting{Sort: "_id", Direction: 1}, func(book *model.Book) error {
+		count++
+		return stop
+	})
+	assert.Equal(t, stop, err, "It should stop at the first error")
+	assert.Equal(t, 1, count)
+}
+
+func testConcurrentCreates(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	const writers = 20
+
+	var wg sync.WaitGroup
+	ids := make(chan objectid.ObjectID, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			book, err := repo.Create(ctx, &model.Book{Title: "Concurrent"})
+			if assert.NoError(t, err) {
+				ids <- book.ID
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	unique := map[objectid.ObjectID]bool{}
+	for ID := range ids {
+		unique[ID] = true
+	}
+	assert.Len(t, unique, writers)
+
+	page := list(t, repo, &model.BookFilter{Title: "Concurrent"}, &model.Sorting{}, &model.Pagination{Limit: model.MaxLimit})
+	assert.Equal(t, int64(writers), page.Total)
+}
+
+func testConcurrentUpdates(t *testing.T, repo interfaces.IBookRepository) {
+	ctx := context.Background()
+	const writers = 20
+	created := insert(t, repo, fixtures())[0]
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	succeeded := 0
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(rating int64) {
+			defer wg.Done()
+			_, err := repo.Patch(ctx, &model.Book{ID: created.ID, Version: 1, Rating: rating}, []string{"rating"})
+			if err == nil {
+				mu.Lock()
+				succeeded++
+				mu.Unlock()
+			} else {
+				assert.Equal(t, model.ErrVersionConflict, err)
+			}
+		}(int64(i))
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, succeeded, "It should let a single writer win")
+	stored, _ := repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, int64(2), stored.Version)
+}
+
+// AuthorFactory return an empty author repository and a function releasing it, it is called once per test
+type AuthorFactory func(t *testing.T) (interfaces.IAuthorRepository, func())
+
+// RunAuthorRepository run every author contract test against repositories built by factory
+func RunAuthorRepository(t *testing.T, factory AuthorFactory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo interfaces.IAuthorRepository)
+	}{
+		{"CRUD", testAuthorCRUD},
+		{"List", testAuthorList},
+		{"GetMany", testAuthorGetMany},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			repo, cleanup := factory(t)
+			defer cleanup()
+			tt.test(t, repo)
+		})
+	}
+}
+
+// createAuthors create authors into repo, returned in creation order
+func createAuthors(t *testing.T, repo interfaces.IAuthorRepository, authors []*model.Author) []*model.Author {
+	ctx := context.Background()
+	created := make([]*model.Author, len(authors))
+	for i, author := range authors {
+		author.Normalize()
+		var err error
+		created[i], err = repo.Create(ctx, author)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+	return created
+}
+
+func testAuthorCRUD(t *testing.T, repo interfaces.IAuthorRepository) {
+	ctx := context.Background()
+
+	created := createAuthors(t, repo, []*model.Author{{Name: "Mathieu Doyon", BirthYear: 1984, Aliases: []string{"M. Doyon"}}})[0]
+	assert.False(t, created.ID.IsZero())
+	assert.False(t, created.CreatedAt.IsZero())
+
+	author, err := repo.Get(ctx, created.ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Doyon, Mathieu", author.SortName)
+		assert.Equal(t, int64(1984), author.BirthYear)
+		assert.Equal(t, []string{"M. Doyon"}, author.Aliases)
+	}
+
+	author.Name = "Mathieu Doyon-Leblanc"
+	author.Aliases = []string{}
+	_, err = repo.Update(ctx, author)
+	assert.NoError(t, err)
+	author, err = repo.Get(ctx, created.ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Mathieu Doyon-Leblanc", author.Name)
+		assert.Empty(t, author.Aliases)
+		assert.Equal(t, created.CreatedAt.Unix(), author.CreatedAt.Unix(), "It should keep the creation date")
+	}
+
+	_, err = repo.Update(ctx, &model.Author{ID: objectid.New(), Name: "Nobody"})
+	assert.Equal(t, model.ErrAuthorNotFound, err)
+
+	deleted, err := repo.Delete(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, model.ErrAuthorNotFound, err)
+
+	_, err = repo.Get(ctx, "sardines")
+	assert.Equal(t, model.ErrInvalidID, err)
+}
+
+func testAuthorList(t *testing.T, repo interfaces.IAuthorRepository) {
+	ctx := context.Background()
+	createAuthors(t, repo, []*model.Author{
+		{Name: "Mathieu Doyon", Aliases: []string{"M. Doyon"}},
+		{Name: "Émile Zola"},
+		{Name: "Eric Cantona"},
+	})
+
+	authors, err := repo.List(ctx, "")
+	if assert.NoError(t, err) {
+		names := []string{}
+		for _, author := range authors {
+			names = append(names, author.Name)
+		}
+		assert.Equal(t, []string{"Eric Cantona", "Mathieu Doyon", "Émile Zola"}, names, "It should sort by sort name")
+	}
+
+	for _, name := range []string{"m. doyon", "Mathieu Doyon", "emile zola"} {
+		authors, err := repo.List(ctx, name)
+		if assert.NoError(t, err) && assert.Len(t, authors, 1, name) {
+			assert.True(t, authors[0].HasName(name))
+		}
+	}
+
+	authors, err = repo.List(ctx, "Doyon")
+	assert.NoError(t, err)
+	assert.Empty(t, authors, "It should only match whole names")
+}
+
+func testAuthorGetMany(t *testing.T, repo interfaces.IAuthorRepository) {
+	created := createAuthors(t, repo, []*model.Author{{Name: "Mathieu Doyon"}, {Name: "Eric Cantona"}})
+
+	authors, err := repo.GetMany(context.Background(), []objectid.ObjectID{created[1].ID, objectid.New(), created[0].ID})
+	if assert.NoError(t, err) && assert.Len(t, authors, 2, "It should leave out missing authors") {
+		assert.Equal(t, "Eric Cantona", authors[0].Name, "It should keep the order of ids")
+		assert.Equal(t, "Mathieu Doyon", authors[1].Name)
+	}
+}
+
+// GenreFactory return an empty genre repository and a function releasing it, it is called once per test
+type GenreFactory func(t *testing.T) (interfaces.IGenreRepository, func())
+
+// RunGenreRepository run every genre contract test against repositories built by factory
+func RunGenreRepository(t *testing.T, factory GenreFactory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo interfaces.IGenreRepository)
+	}{
+		{"CRUD", testGenreCRUD},
+		{"List", testGenreList},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			repo, cleanup := factory(t)
+			defer cleanup()
+			tt.test(t, repo)
+		})
+	}
+}
+
+func testGenreCRUD(t *testing.T, repo interfaces.IGenreRepository) {
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &model.Genre{Name: "Science Fiction", Parent: "Fiction", Synonyms: []string{"Sci-Fi", "SF"}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.False(t, created.ID.IsZero())
+	assert.False(t, created.CreatedAt.IsZero())
+
+	genre, err := repo.Get(ctx, created.ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Fiction", genre.Parent)
+		assert.Equal(t, []string{"Sci-Fi", "SF"}, genre.Synonyms)
+	}
+
+	genre.Name = "Speculative Fiction"
+	genre.Synonyms = []string{}
+	_, err = repo.Update(ctx, genre)
+	assert.NoError(t, err)
+	genre, err = repo.Get(ctx, created.ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Speculative Fiction", genre.Name)
+		assert.Empty(t, genre.Synonyms)
+		assert.Equal(t, created.CreatedAt.Unix(), genre.CreatedAt.Unix(), "It should keep the creation date")
+	}
+
+	_, err = repo.Update(ctx, &model.Genre{ID: objectid.New(), Name: "Nothing"})
+	assert.Equal(t, model.ErrGenreNotFound, err)
+
+	deleted, err := repo.Delete(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, model.ErrGenreNotFound, err)
+
+	_, err = repo.Get(ctx, "sardines")
+	assert.Equal(t, model.ErrInvalidID, err)
+}
+
+func testGenreList(t *testing.T, repo interfaces.IGenreRepository) {
+	ctx := context.Background()
+	for _, name := range []string{"Poetry", "Fiction", "Essay"} {
+		_, err := repo.Create(ctx, &model.Genre{Name: name, Synonyms: []string{}})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	genres, err := repo.List(ctx)
+	if assert.NoError(t, err) {
+		names := []string{}
+		for _, genre := range genres {
+			names = append(names, genre.Name)
+		}
+		assert.Equal(t, []string{"Essay", "Fiction", "Poetry"}, names, "It should sort by name")
+	}
+}
+
+// SeriesFactory return an empty series repository and a function releasing it, it is called once per test
+type SeriesFactory func(t *testing.T) (interfaces.ISeriesRepository, func())
+
+// RunSeriesRepository run every series contract test against repositories built by factory
+func RunSeriesRepository(t *testing.T, factory SeriesFactory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo interfaces.ISeriesRepository)
+	}{
+		{"CRUD", testSeriesCRUD},
+		{"List", testSeriesList},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			repo, cleanup := factory(t)
+			defer cleanup()
+			tt.test(t, repo)
+		})
+	}
+}
+
+func testSeriesCRUD(t *testing.T, repo interfaces.ISeriesRepository) {
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &model.Series{Name: "Sea", Description: "Fishes and boats", Volumes: 3})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.False(t, created.ID.IsZero())
+	assert.False(t, created.CreatedAt.IsZero())
+
+	series, err := repo.Get(ctx, created.ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Fishes and boats", series.Description)
+		assert.Equal(t, int64(3), series.Volumes)
+	}
+
+	series.Name = "Ocean"
+	series.Volumes = 0
+	_, err = repo.Update(ctx, series)
+	assert.NoError(t, err)
+	series, err = repo.Get(ctx, created.ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Ocean", series.Name)
+		assert.Equal(t, int64(0), series.Volumes)
+		assert.Equal(t, created.CreatedAt.Unix(), series.CreatedAt.Unix(), "It should keep the creation date")
+	}
+
+	_, err = repo.Update(ctx, &model.Series{ID: objectid.New(), Name: "Nothing"})
+	assert.Equal(t, model.ErrSeriesNotFound, err)
+
+	deleted, err := repo.Delete(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, model.ErrSeriesNotFound, err)
+
+	_, err = repo.Get(ctx, "sardines")
+	assert.Equal(t, model.ErrInvalidID, err)
+}
+
+func testSeriesList(t *testing.T, repo interfaces.ISeriesRepository) {
+	ctx := context.Background()
+	for _, name := range []string{"Sea", "birds", "Ants"} {
+		_, err := repo.Create(ctx, &model.Series{Name: name})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	list, err := repo.List(ctx)
+	if assert.NoError(t, err) {
+		names := []string{}
+		for _, series := range list {
+			names = append(names, series.Name)
+		}
+		assert.Equal(t, []string{"Ants", "birds", "Sea"}, names, "It should sort by name")
+	}
+}
+
+// WorkFactory return an empty work repository and a function releasing it, it is called once per test
+type WorkFactory func(t *testing.T) (interfaces.IWorkRepository, func())
+
+// RunWorkRepository run every work contract test against repositories built by factory
+func RunWorkRepository(t *testing.T, factory WorkFactory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repo interfaces.IWorkRepository)
+	}{
+		{"CRUD", testWorkCRUD},
+		{"List", testWorkList},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			repo, cleanup := factory(t)
+			defer cleanup()
+			tt.test(t, repo)
+		})
+	}
+}
+
+func testWorkCRUD(t *testing.T, repo interfaces.IWorkRepository) {
+	ctx := context.Background()
+
+	created, err := repo.Create(ctx, &model.Work{Title: "Sardines", Author: "Mathieu Doyon"})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.False(t, created.ID.IsZero())
+	assert.False(t, created.CreatedAt.IsZero())
+
+	work, err := repo.Get(ctx, created.ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Sardines", work.Title)
+		assert.Equal(t, "Mathieu Doyon", work.Author)
+	}
+
+	work.Title = "Sardinas"
+	work.Author = ""
+	_, err = repo.Update(ctx, work)
+	assert.NoError(t, err)
+	work, err = repo.Get(ctx, created.ID.Hex())
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Sardinas", work.Title)
+		assert.Equal(t, "", work.Author)
+		assert.Equal(t, created.CreatedAt.Unix(), work.CreatedAt.Unix(), "It should keep the creation date")
+	}
+
+	_, err = repo.Update(ctx, &model.Work{ID: objectid.New(), Title: "Nothing"})
+	assert.Equal(t, model.ErrWorkNotFound, err)
+
+	deleted, err := repo.Delete(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	_, err = repo.Get(ctx, created.ID.Hex())
+	assert.Equal(t, model.ErrWorkNotFound, err)
+
+	_, err = repo.Get(ctx, "sardines")
+	assert.Equal(t, model.ErrInvalidID, err)
+}
+
+func testWorkList(t *testing.T, repo interfaces.IWorkRepository) {
+	ctx := context.Background()
+	for _, work := range []model.Work{
+		{Title: "Sardines", Author: "Mathieu Doyon"},
+		{Title: "anchor"},
+		{Title: "Sardines", Author: "Eric Cantona"},
+	} {
+		work := work
+		_, err := repo.Create(ctx, &work)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	list, err := repo.List(ctx)
+	if assert.NoError(t, err) {
+		names := []string{}
+		for _, work := range list {
+			names = append(names, work.Title+"/"+work.Author)
+		}
+		assert.Equal(t, []string{"anchor/", "Sardines/Eric Cantona", "Sardines/Mathieu Doyon"}, names, "It should sort by title then author")
+	}
+}
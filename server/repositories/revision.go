@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/MathieuDoyon/bookshelf/server/db"
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/findopt"
+)
+
+// revisionIndexName name of the unique book revision number index
+const revisionIndexName = "revisions_book_number"
+
+var revisionIndexOnce sync.Once
+
+// RevisionRepo book revisions repository
+type RevisionRepo struct {
+	interfaces.IRevisionRepository
+}
+
+// Append store a new revision, a revision number can't be written twice
+func (repo *RevisionRepo) Append(ctx context.Context, revision *model.Revision) error {
+	collection := db.Database.Collection("revisions")
+
+	ensureRevisionIndex()
+
+	revision.ID = objectid.New()
+	_, err := collection.InsertOne(ctx, revision)
+	if isDuplicateKey(err) {
+		return model.ErrVersionConflict
+	}
+
+	return mongoError(err)
+}
+
+// List get every revision of a book, oldest first
+func (repo *RevisionRepo) List(ctx context.Context, bookID objectid.ObjectID) ([]model.Revision, error) {
+	collection := db.Database.Collection("revisions")
+
+	cur, err := collection.Find(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("book_id", bookID)),
+		findopt.Sort(bson.NewDocument(bson.EC.Int32("number", 1))),
+	)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	defer cur.Close(context.Background())
+
+	revisions := []model.Revision{}
+
+	for cur.Next(ctx) {
+		revision := model.Revision{}
+		if err := cur.Decode(&revision); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, mongoError(err)
+	}
+
+	return revisions, nil
+}
+
+// Get get a single revision of a book by number
+func (repo *RevisionRepo) Get(ctx context.Context, bookID objectid.ObjectID, number int64) (*model.Revision, error) {
+	collection := db.Database.Collection("revisions")
+
+	var revision *model.Revision
+
+	err := collection.FindOne(ctx, bson.NewDocument(
+		bson.EC.ObjectID("book_id", bookID),
+		bson.EC.Int64("number", number),
+	)).Decode(&revision)
+	if err == mongo.ErrNoDocuments {
+		return nil, model.ErrRevisionNotFound
+	}
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return revision, nil
+}
+
+// ensureRevisionIndex create the unique index on book id and revision number
+func ensureRevisionIndex() {
+	revisionIndexOnce.Do(func() {
+		collection := db.Database.Collection("revisions")
+
+		index := mongo.IndexModel{
+			Keys: bson.NewDocument(
+				bson.EC.Int32("book_id", 1),
+				bson.EC.Int32("number", 1),
+			),
+			Options: mongo.NewIndexOptionsBuilder().
+				Name(revisionIndexName).
+				Unique(true).
+				Build(),
+		}
+
+		if _, err := collection.Indexes().CreateOne(nil, index); err != nil {
+			log.Printf("Error creating revision index: %s", err)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/db"
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/replaceopt"
+)
+
+// SeriesRepo series repository
+type SeriesRepo struct {
+	interfaces.ISeriesRepository
+}
+
+// List get every series sorted by name
+func (repo *SeriesRepo) List(ctx context.Context) ([]model.Series, error) {
+	collection := db.Database.Collection("series")
+
+	cur, err := collection.Find(ctx, bson.NewDocument())
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cur.Close(context.Background())
+
+	list := []model.Series{}
+	for cur.Next(ctx) {
+		series := model.Series{}
+		if err := cur.Decode(&series); err != nil {
+			return nil, err
+		}
+		list = append(list, series)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, mongoError(err)
+	}
+
+	// sorted like the other backends, a collection sort doesn't follow CompareStrings
+	model.SortSeries(list)
+	return list, nil
+}
+
+// Get get a series by ID
+func (repo *SeriesRepo) Get(ctx context.Context, ID string) (*model.Series, error) {
+	collection := db.Database.Collection("series")
+
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	var series *model.Series
+	err = collection.FindOne(ctx, bson.NewDocument(bson.EC.ObjectID("_id", objectID))).Decode(&series)
+	if err == mongo.ErrNoDocuments {
+		return nil, model.ErrSeriesNotFound
+	}
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return series, nil
+}
+
+// Create add a new series
+func (repo *SeriesRepo) Create(ctx context.Context, series *model.Series) (*model.Series, error) {
+	collection := db.Database.Collection("series")
+
+	series.ID = objectid.New()
+	series.CreatedAt = now()
+	series.UpdatedAt = series.CreatedAt
+	if _, err := collection.InsertOne(ctx, series); err != nil {
+		return nil, mongoError(err)
+	}
+
+	return series, nil
+}
+
+// Update replace a series by ID
+func (repo *SeriesRepo) Update(ctx context.Context, series *model.Series) (*model.Series, error) {
+	collection := db.Database.Collection("series")
+
+	updatedAt := now()
+
+	res, err := collection.UpdateOne(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("_id", series.ID)),
+		bson.NewDocument(bson.EC.SubDocumentFromElements("$set",
+			bson.EC.String("name", series.Name),
+			bson.EC.String("description", series.Description),
+			bson.EC.Int64("volumes", series.Volumes),
+			bson.EC.Time("updated_at", updatedAt),
+		)),
+	)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, model.ErrSeriesNotFound
+	}
+
+	series.UpdatedAt = updatedAt
+	return series, nil
+}
+
+// Delete remove a series by ID
+func (repo *SeriesRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	collection := db.Database.Collection("series")
+
+	res, err := collection.DeleteOne(ctx, bson.NewDocument(bson.EC.ObjectID("_id", ID)))
+	if err != nil {
+		return 0, mongoError(err)
+	}
+
+	return res.DeletedCount, nil
+}
+
+// Put replace the series document having the id of series, inserting it when missing. Dates are written as is
+func (repo *SeriesRepo) Put(ctx context.Context, series *model.Series) error {
+	collection := db.Database.Collection("series")
+
+	_, err := collection.ReplaceOne(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("_id", series.ID)),
+		series,
+		replaceopt.Upsert(true),
+	)
+
+	return mongoError(err)
+}
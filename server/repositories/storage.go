@@ -0,0 +1,373 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/MathieuDoyon/bookshelf/server/db"
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+)
+
+// Storage backends
+const (
+	StorageMongo  = "mongo"
+	StorageBolt   = "bolt"
+	StorageMemory = "memory"
+)
+
+// Storage book, revision, author, genre, series and work repositories of a storage backend
+type Storage struct {
+	Books     interfaces.IBookRepository
+	Revisions interfaces.IRevisionRepository
+	Authors   interfaces.IAuthorRepository
+	Genres    interfaces.IGenreRepository
+	Series    interfaces.ISeriesRepository
+	Works     interfaces.IWorkRepository
+	// Close release the backend connection or file
+	Close func()
+}
+
+// Putter repository able to store a book as is, keeping its id, version and dates
+type Putter interface {
+	Put(ctx context.Context, book *model.Book) error
+}
+
+// AuthorPutter repository able to store an author as is, keeping its id and dates
+type AuthorPutter interface {
+	Put(ctx context.Context, author *model.Author) error
+}
+
+// GenrePutter repository able to store a genre as is, keeping its id and dates
+type GenrePutter interface {
+	Put(ctx context.Context, genre *model.Genre) error
+}
+
+// SeriesPutter repository able to store a series as is, keeping its id and dates
+type SeriesPutter interface {
+	Put(ctx context.Context, series *model.Series) error
+}
+
+// WorkPutter repository able to store a work as is, keeping its id and dates
+type WorkPutter interface {
+	Put(ctx context.Context, work *model.Work) error
+}
+
+// Options settings of the storage backends
+type Options struct {
+	// BoltPath file used by the bolt backend
+	BoltPath string
+	// StrictDecoding fail reads on stored books which can't be decoded instead of skipping them
+	StrictDecoding bool
+	// Collation locale mongo sorts strings with, the other backends approximate it
+	Collation string
+}
+
+// OpenStorage open a storage backend by name
+func OpenStorage(name string, options Options) (*Storage, error) {
+	switch name {
+	case StorageMongo:
+		db.Configure()
+		if err := ensureISBNIndex(context.Background()); err != nil {
+			return nil, err
+		}
+		if err := ensureTextIndex(context.Background()); err != nil {
+			return nil, err
+		}
+		if err := ensureSortIndexes(context.Background(), options.Collation); err != nil {
+			return nil, err
+		}
+		// books stored before created_at and updated_at are dated before anything can drop modifiedAt
+		if count, err := backfillTimestamps(context.Background()); err != nil {
+			log.Printf("Error dating legacy books: %s", err)
+		} else if count > 0 {
+			log.Printf("Dated %d legacy books", count)
+		}
+		return &Storage{
+			Books:     &BookRepo{Strict: options.StrictDecoding, Collation: options.Collation},
+			Revisions: &RevisionRepo{},
+			Authors:   &AuthorRepo{Collation: options.Collation},
+			Genres:    &GenreRepo{},
+			Series:    &SeriesRepo{},
+			Works:     &WorkRepo{},
+			Close:     func() { db.Client.Disconnect(nil) },
+		}, nil
+	case StorageBolt:
+		boltDB, err := OpenBolt(options.BoltPath)
+		if err != nil {
+			return nil, err
+		}
+		return &Storage{
+			Books:     &BoltRepo{DB: boltDB, Strict: options.StrictDecoding},
+			Revisions: &BoltRevisionRepo{DB: boltDB},
+			Authors:   &BoltAuthorRepo{DB: boltDB},
+			Genres:    &BoltGenreRepo{DB: boltDB},
+			Series:    &BoltSeriesRepo{DB: boltDB},
+			Works:     &BoltWorkRepo{DB: boltDB},
+			Close:     func() { boltDB.Close() },
+		}, nil
+	case StorageMemory:
+		return &Storage{
+			Books:     NewMemoryRepo(),
+			Revisions: NewMemoryRevisionRepo(),
+			Authors:   NewMemoryAuthorRepo(),
+			Genres:    NewMemoryGenreRepo(),
+			Series:    NewMemorySeriesRepo(),
+			Works:     NewMemoryWorkRepo(),
+			Close:     func() {},
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown storage %q, expected mongo, bolt or memory", name)
+}
+
+// Migrate copy every author, genre, series and work, every book, trashed ones too, and their revisions from one storage to another.
+//
+// Books keep their id, version and dates, a book already copied is overwritten so a migration can run again.
+func Migrate(ctx context.Context, from *Storage, to *Storage) (int64, error) {
+	putter, ok := to.Books.(Putter)
+	if !ok {
+		return 0, fmt.Errorf("books can't be copied into %T", to.Books)
+	}
+	authorPutter, ok := to.Authors.(AuthorPutter)
+	if !ok {
+		return 0, fmt.Errorf("authors can't be copied into %T", to.Authors)
+	}
+	genrePutter, ok := to.Genres.(GenrePutter)
+	if !ok {
+		return 0, fmt.Errorf("genres can't be copied into %T", to.Genres)
+	}
+	seriesPutter, ok := to.Series.(SeriesPutter)
+	if !ok {
+		return 0, fmt.Errorf("series can't be copied into %T", to.Series)
+	}
+	workPutter, ok := to.Works.(WorkPutter)
+	if !ok {
+		return 0, fmt.Errorf("works can't be copied into %T", to.Works)
+	}
+
+	// authors first, so copied books never link to a missing author
+	authors, err := from.Authors.List(ctx, "")
+	if err != nil {
+		return 0, err
+	}
+	for i := range authors {
+		if err := authorPutter.Put(ctx, &authors[i]); err != nil {
+			return 0, fmt.Errorf("author %s: %s", authors[i].ID.Hex(), err)
+		}
+	}
+	genres, err := from.Genres.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range genres {
+		if err := genrePutter.Put(ctx, &genres[i]); err != nil {
+			return 0, fmt.Errorf("genre %s: %s", genres[i].ID.Hex(), err)
+		}
+	}
+	series, err := from.Series.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range series {
+		if err := seriesPutter.Put(ctx, &series[i]); err != nil {
+			return 0, fmt.Errorf("series %s: %s", series[i].ID.Hex(), err)
+		}
+	}
+	works, err := from.Works.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range works {
+		if err := workPutter.Put(ctx, &works[i]); err != nil {
+			return 0, fmt.Errorf("work %s: %s", works[i].ID.Hex(), err)
+		}
+	}
+
+	var copied int64
+	sorting := &model.Sorting{Sort: "_id", Direction: 1}
+	for _, trashed := range []bool{false, true} {
+		err := from.Books.Stream(ctx, &model.BookFilter{Trashed: trashed}, sorting, func(book *model.Book) error {
+			if err := putter.Put(ctx, book); err != nil {
+				return fmt.Errorf("book %s: %s", book.ID.Hex(), err)
+			}
+
+			revisions, err := from.Revisions.List(ctx, book.ID)
+			if err != nil {
+				return err
+			}
+			for i := range revisions {
+				// already copied by a previous migration
+				if err := to.Revisions.Append(ctx, &revisions[i]); err != nil && err != model.ErrVersionConflict {
+					return err
+				}
+			}
+
+			copied++
+			return nil
+		})
+		if err != nil {
+			return copied, err
+		}
+	}
+
+	return copied, nil
+}
+
+// MigrateAuthors link books still crediting authors as free text to author records, trashed books too.
+//
+// Each name of the text, "A & B" credits two authors, links the author having this name or alias,
+// an author is created when none has it. Contributors are linked the same way, whatever their role.
+// Linked books are skipped so a migration can run again.
+func MigrateAuthors(ctx context.Context, books interfaces.IBookRepository, authors interfaces.IAuthorRepository) (int64, error) {
+	// books are changed once read, a stream can't be written into
+	unlinked := []model.Book{}
+	for _, trashed := range []bool{false, true} {
+		err := books.Stream(ctx, &model.BookFilter{Trashed: trashed}, idSorting, func(book *model.Book) error {
+			if hasUnlinkedCredit(book) {
+				unlinked = append(unlinked, *book)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var linked int64
+	for i := range unlinked {
+		book := &unlinked[i]
+		fields := []string{"author", "author_ids"}
+
+		if len(book.Contributors) > 0 {
+			contributors := make([]model.Contributor, len(book.Contributors))
+			for j, contributor := range book.Contributors {
+				if !contributor.IsLinked() {
+					author, err := findOrCreateAuthor(ctx, authors, contributor.Name)
+					if err != nil {
+						return linked, err
+					}
+					contributor.AuthorID = author.ID
+					contributor.Name = author.Name
+				}
+				contributors[j] = contributor
+			}
+			book.Contributors = contributors
+			book.ProjectAuthors()
+			fields = append(fields, "contributors")
+		} else {
+			names := []model.AuthorName{}
+			for _, name := range model.SplitAuthorNames(book.Author) {
+				author, err := findOrCreateAuthor(ctx, authors, name)
+				if err != nil {
+					return linked, err
+				}
+				if !book.HasAuthor(author.ID) {
+					book.AuthorIDs = append(book.AuthorIDs, author.ID)
+					names = append(names, model.AuthorName{ID: author.ID, Name: author.Name})
+				}
+			}
+			book.Author = model.JoinAuthorNames(names)
+		}
+
+		if _, err := books.Patch(ctx, book, fields); err != nil {
+			return linked, fmt.Errorf("book %s: %s", book.ID.Hex(), err)
+		}
+		linked++
+	}
+
+	return linked, nil
+}
+
+// hasUnlinkedCredit check if a book credits an author or a contributor by name only
+func hasUnlinkedCredit(book *model.Book) bool {
+	if len(book.Contributors) == 0 {
+		return len(book.AuthorIDs) == 0 && len(model.SplitAuthorNames(book.Author)) > 0
+	}
+	for _, contributor := range book.Contributors {
+		if !contributor.IsLinked() {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateWorks group books which aren't an edition of a work yet into works, trashed books too.
+//
+// A book joins the oldest work having its title and authors, a work is created when none has them.
+// Grouped books are skipped so a migration can run again.
+func MigrateWorks(ctx context.Context, books interfaces.IBookRepository, works interfaces.IWorkRepository) (int64, error) {
+	// books are changed once read, a stream can't be written into
+	ungrouped := []model.Book{}
+	for _, trashed := range []bool{false, true} {
+		err := books.Stream(ctx, &model.BookFilter{Trashed: trashed}, idSorting, func(book *model.Book) error {
+			if book.WorkID.IsZero() {
+				ungrouped = append(ungrouped, *book)
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	existing, err := works.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var grouped int64
+	for i := range ungrouped {
+		book := &ungrouped[i]
+		work := model.FindWork(existing, book)
+		if work == nil {
+			if work, err = works.Create(ctx, model.NewWork(book)); err != nil {
+				return grouped, err
+			}
+			existing = append(existing, *work)
+		}
+
+		book.WorkID = work.ID
+		if _, err := books.Patch(ctx, book, []string{"work_id"}); err != nil {
+			return grouped, fmt.Errorf("book %s: %s", book.ID.Hex(), err)
+		}
+		grouped++
+	}
+
+	return grouped, nil
+}
+
+// SeedGenres create the default genres when there is no genre yet, returns how many were created
+func SeedGenres(ctx context.Context, genres interfaces.IGenreRepository) (int64, error) {
+	existing, err := genres.List(ctx)
+	if err != nil || len(existing) > 0 {
+		return 0, err
+	}
+
+	var created int64
+	for _, seed := range model.DefaultGenres {
+		genre := seed
+		genre.Synonyms = append([]string{}, seed.Synonyms...)
+		if _, err := genres.Create(ctx, &genre); err != nil {
+			return created, err
+		}
+		created++
+	}
+	return created, nil
+}
+
+// findOrCreateAuthor first author having name as name or alias, created when there is none
+func findOrCreateAuthor(ctx context.Context, authors interfaces.IAuthorRepository, name string) (*model.Author, error) {
+	found, err := authors.List(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) > 0 {
+		return &found[0], nil
+	}
+
+	author := &model.Author{Name: name}
+	author.Normalize()
+	return authors.Create(ctx, author)
+}
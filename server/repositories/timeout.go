@@ -0,0 +1,426 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+)
+
+// Timeouts maximum duration of each kind of repository operation, zero means no timeout
+type Timeouts struct {
+	// Read get and list books, revisions, authors, genres, series or works
+	Read time.Duration
+	// Search full text search
+	Search time.Duration
+	// Write every operation changing books, authors, genres, series or works, or appending revisions
+	Write time.Duration
+	// Stream whole stream of books, the time spent by the callback included
+	Stream time.Duration
+}
+
+// TimeoutRepo book repository bounding the duration of each operation of another one
+type TimeoutRepo struct {
+	interfaces.IBookRepository
+	Timeouts Timeouts
+}
+
+// NewTimeoutRepo bound the duration of each operation of repo
+func NewTimeoutRepo(repo interfaces.IBookRepository, timeouts Timeouts) *TimeoutRepo {
+	return &TimeoutRepo{IBookRepository: repo, Timeouts: timeouts}
+}
+
+// List return a page of books within the read timeout
+func (repo *TimeoutRepo) List(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, pagination *model.Pagination) (*model.BookPage, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	page, err := repo.IBookRepository.List(ctx, filters, sorting, pagination)
+	return page, contextErr(ctx, err)
+}
+
+// Stream call fn for each book within the stream timeout
+func (repo *TimeoutRepo) Stream(ctx context.Context, filters *model.BookFilter, sorting *model.Sorting, fn func(book *model.Book) error) error {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Stream)
+	defer cancel()
+
+	return contextErr(ctx, repo.IBookRepository.Stream(ctx, filters, sorting, fn))
+}
+
+// Search full text search books within the search timeout
+func (repo *TimeoutRepo) Search(ctx context.Context, query string, limit int64) (*model.SearchPage, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Search)
+	defer cancel()
+
+	page, err := repo.IBookRepository.Search(ctx, query, limit)
+	return page, contextErr(ctx, err)
+}
+
+// Create add a new book within the write timeout
+func (repo *TimeoutRepo) Create(ctx context.Context, book *model.Book) (*model.Book, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	created, err := repo.IBookRepository.Create(ctx, book)
+	return created, contextErr(ctx, err)
+}
+
+// Get get a book by ID within the read timeout
+func (repo *TimeoutRepo) Get(ctx context.Context, ID string) (*model.Book, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	book, err := repo.IBookRepository.Get(ctx, ID)
+	return book, contextErr(ctx, err)
+}
+
+// GetTrashed get a trashed book by ID within the read timeout
+func (repo *TimeoutRepo) GetTrashed(ctx context.Context, ID string) (*model.Book, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	book, err := repo.IBookRepository.GetTrashed(ctx, ID)
+	return book, contextErr(ctx, err)
+}
+
+// Update update a book within the write timeout
+func (repo *TimeoutRepo) Update(ctx context.Context, book *model.Book) (*model.Book, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	updated, err := repo.IBookRepository.Update(ctx, book)
+	return updated, contextErr(ctx, err)
+}
+
+// Patch update the given fields of a book within the write timeout
+func (repo *TimeoutRepo) Patch(ctx context.Context, book *model.Book, fields []string) (*model.Book, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	patched, err := repo.IBookRepository.Patch(ctx, book, fields)
+	return patched, contextErr(ctx, err)
+}
+
+// Delete move a book to trash within the write timeout
+func (repo *TimeoutRepo) Delete(ctx context.Context, ID objectid.ObjectID, version int64) (int64, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	deleted, err := repo.IBookRepository.Delete(ctx, ID, version)
+	return deleted, contextErr(ctx, err)
+}
+
+// Restore move a book out of trash within the write timeout
+func (repo *TimeoutRepo) Restore(ctx context.Context, ID objectid.ObjectID) (*model.Book, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	restored, err := repo.IBookRepository.Restore(ctx, ID)
+	return restored, contextErr(ctx, err)
+}
+
+// Purge permanently remove a book within the write timeout
+func (repo *TimeoutRepo) Purge(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	purged, err := repo.IBookRepository.Purge(ctx, ID)
+	return purged, contextErr(ctx, err)
+}
+
+// PurgeTrash permanently remove books trashed before a date within the write timeout
+func (repo *TimeoutRepo) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	purged, err := repo.IBookRepository.PurgeTrash(ctx, before)
+	return purged, contextErr(ctx, err)
+}
+
+// TimeoutRevisionRepo revision repository bounding the duration of each operation of another one
+type TimeoutRevisionRepo struct {
+	Revisions interfaces.IRevisionRepository
+	Timeouts  Timeouts
+}
+
+// NewTimeoutRevisionRepo bound the duration of each operation of revisions
+func NewTimeoutRevisionRepo(revisions interfaces.IRevisionRepository, timeouts Timeouts) *TimeoutRevisionRepo {
+	return &TimeoutRevisionRepo{Revisions: revisions, Timeouts: timeouts}
+}
+
+// Append store a revision within the write timeout
+func (repo *TimeoutRevisionRepo) Append(ctx context.Context, revision *model.Revision) error {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	return contextErr(ctx, repo.Revisions.Append(ctx, revision))
+}
+
+// List return every revision of a book within the read timeout
+func (repo *TimeoutRevisionRepo) List(ctx context.Context, bookID objectid.ObjectID) ([]model.Revision, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	revisions, err := repo.Revisions.List(ctx, bookID)
+	return revisions, contextErr(ctx, err)
+}
+
+// Get get a revision of a book within the read timeout
+func (repo *TimeoutRevisionRepo) Get(ctx context.Context, bookID objectid.ObjectID, number int64) (*model.Revision, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	revision, err := repo.Revisions.Get(ctx, bookID, number)
+	return revision, contextErr(ctx, err)
+}
+
+// TimeoutAuthorRepo author repository bounding the duration of each operation of another one
+type TimeoutAuthorRepo struct {
+	Authors  interfaces.IAuthorRepository
+	Timeouts Timeouts
+}
+
+// NewTimeoutAuthorRepo bound the duration of each operation of authors
+func NewTimeoutAuthorRepo(authors interfaces.IAuthorRepository, timeouts Timeouts) *TimeoutAuthorRepo {
+	return &TimeoutAuthorRepo{Authors: authors, Timeouts: timeouts}
+}
+
+// List return authors within the read timeout
+func (repo *TimeoutAuthorRepo) List(ctx context.Context, name string) ([]model.Author, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	authors, err := repo.Authors.List(ctx, name)
+	return authors, contextErr(ctx, err)
+}
+
+// Get get an author within the read timeout
+func (repo *TimeoutAuthorRepo) Get(ctx context.Context, ID string) (*model.Author, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	author, err := repo.Authors.Get(ctx, ID)
+	return author, contextErr(ctx, err)
+}
+
+// GetMany get authors within the read timeout
+func (repo *TimeoutAuthorRepo) GetMany(ctx context.Context, IDs []objectid.ObjectID) ([]model.Author, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	authors, err := repo.Authors.GetMany(ctx, IDs)
+	return authors, contextErr(ctx, err)
+}
+
+// Create add an author within the write timeout
+func (repo *TimeoutAuthorRepo) Create(ctx context.Context, author *model.Author) (*model.Author, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	created, err := repo.Authors.Create(ctx, author)
+	return created, contextErr(ctx, err)
+}
+
+// Update replace an author within the write timeout
+func (repo *TimeoutAuthorRepo) Update(ctx context.Context, author *model.Author) (*model.Author, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	updated, err := repo.Authors.Update(ctx, author)
+	return updated, contextErr(ctx, err)
+}
+
+// Delete remove an author within the write timeout
+func (repo *TimeoutAuthorRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	deleted, err := repo.Authors.Delete(ctx, ID)
+	return deleted, contextErr(ctx, err)
+}
+
+// TimeoutGenreRepo genre repository bounding the duration of each operation of another one
+type TimeoutGenreRepo struct {
+	Genres   interfaces.IGenreRepository
+	Timeouts Timeouts
+}
+
+// NewTimeoutGenreRepo bound the duration of each operation of genres
+func NewTimeoutGenreRepo(genres interfaces.IGenreRepository, timeouts Timeouts) *TimeoutGenreRepo {
+	return &TimeoutGenreRepo{Genres: genres, Timeouts: timeouts}
+}
+
+// List return genres within the read timeout
+func (repo *TimeoutGenreRepo) List(ctx context.Context) ([]model.Genre, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	genres, err := repo.Genres.List(ctx)
+	return genres, contextErr(ctx, err)
+}
+
+// Get get a genre within the read timeout
+func (repo *TimeoutGenreRepo) Get(ctx context.Context, ID string) (*model.Genre, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	genre, err := repo.Genres.Get(ctx, ID)
+	return genre, contextErr(ctx, err)
+}
+
+// Create add a genre within the write timeout
+func (repo *TimeoutGenreRepo) Create(ctx context.Context, genre *model.Genre) (*model.Genre, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	created, err := repo.Genres.Create(ctx, genre)
+	return created, contextErr(ctx, err)
+}
+
+// Update replace a genre within the write timeout
+func (repo *TimeoutGenreRepo) Update(ctx context.Context, genre *model.Genre) (*model.Genre, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	updated, err := repo.Genres.Update(ctx, genre)
+	return updated, contextErr(ctx, err)
+}
+
+// Delete remove a genre within the write timeout
+func (repo *TimeoutGenreRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	deleted, err := repo.Genres.Delete(ctx, ID)
+	return deleted, contextErr(ctx, err)
+}
+
+// TimeoutSeriesRepo series repository bounding the duration of each operation of another one
+type TimeoutSeriesRepo struct {
+	Series   interfaces.ISeriesRepository
+	Timeouts Timeouts
+}
+
+// NewTimeoutSeriesRepo bound the duration of each operation of series
+func NewTimeoutSeriesRepo(series interfaces.ISeriesRepository, timeouts Timeouts) *TimeoutSeriesRepo {
+	return &TimeoutSeriesRepo{Series: series, Timeouts: timeouts}
+}
+
+// List return series within the read timeout
+func (repo *TimeoutSeriesRepo) List(ctx context.Context) ([]model.Series, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	list, err := repo.Series.List(ctx)
+	return list, contextErr(ctx, err)
+}
+
+// Get get a series within the read timeout
+func (repo *TimeoutSeriesRepo) Get(ctx context.Context, ID string) (*model.Series, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	series, err := repo.Series.Get(ctx, ID)
+	return series, contextErr(ctx, err)
+}
+
+// Create add a series within the write timeout
+func (repo *TimeoutSeriesRepo) Create(ctx context.Context, series *model.Series) (*model.Series, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	created, err := repo.Series.Create(ctx, series)
+	return created, contextErr(ctx, err)
+}
+
+// Update replace a series within the write timeout
+func (repo *TimeoutSeriesRepo) Update(ctx context.Context, series *model.Series) (*model.Series, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	updated, err := repo.Series.Update(ctx, series)
+	return updated, contextErr(ctx, err)
+}
+
+// Delete remove a series within the write timeout
+func (repo *TimeoutSeriesRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	deleted, err := repo.Series.Delete(ctx, ID)
+	return deleted, contextErr(ctx, err)
+}
+
+// TimeoutWorkRepo work repository bounding the duration of each operation of another one
+type TimeoutWorkRepo struct {
+	Works    interfaces.IWorkRepository
+	Timeouts Timeouts
+}
+
+// NewTimeoutWorkRepo bound the duration of each operation of works
+func NewTimeoutWorkRepo(works interfaces.IWorkRepository, timeouts Timeouts) *TimeoutWorkRepo {
+	return &TimeoutWorkRepo{Works: works, Timeouts: timeouts}
+}
+
+// List return works within the read timeout
+func (repo *TimeoutWorkRepo) List(ctx context.Context) ([]model.Work, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	works, err := repo.Works.List(ctx)
+	return works, contextErr(ctx, err)
+}
+
+// Get get a work within the read timeout
+func (repo *TimeoutWorkRepo) Get(ctx context.Context, ID string) (*model.Work, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Read)
+	defer cancel()
+
+	work, err := repo.Works.Get(ctx, ID)
+	return work, contextErr(ctx, err)
+}
+
+// Create add a work within the write timeout
+func (repo *TimeoutWorkRepo) Create(ctx context.Context, work *model.Work) (*model.Work, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	created, err := repo.Works.Create(ctx, work)
+	return created, contextErr(ctx, err)
+}
+
+// Update replace a work within the write timeout
+func (repo *TimeoutWorkRepo) Update(ctx context.Context, work *model.Work) (*model.Work, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	updated, err := repo.Works.Update(ctx, work)
+	return updated, contextErr(ctx, err)
+}
+
+// Delete remove a work within the write timeout
+func (repo *TimeoutWorkRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	ctx, cancel := withTimeout(ctx, repo.Timeouts.Write)
+	defer cancel()
+
+	deleted, err := repo.Works.Delete(ctx, ID)
+	return deleted, contextErr(ctx, err)
+}
+
+// withTimeout derive a context ending after timeout, or when ctx ends if timeout is zero
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// contextErr replace err by the context error once ctx ended, drivers wrap it into their own errors
+func contextErr(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/MathieuDoyon/bookshelf/server/interfaces/mocks"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTimeoutRepo(t *testing.T) {
+	bookMock := &mocks.IBookRepository{}
+	bookMock.On("Get", mock.Anything, "slow").Return(func(ctx context.Context, ID string) *model.Book {
+		<-ctx.Done()
+		return nil
+	}, errors.New("connection(localhost:27017) failed to read"))
+	bookMock.On("Get", mock.Anything, "fast").Return(&model.Book{Title: "Sardines"}, nil)
+	bookMock.On("Search", mock.Anything, "sardines", int64(10)).Return(func(ctx context.Context, query string, limit int64) *model.SearchPage {
+		_, ok := ctx.Deadline()
+		assert.False(t, ok, "It should not set a deadline without timeout")
+		return &model.SearchPage{}
+	}, nil)
+
+	repo := NewTimeoutRepo(bookMock, Timeouts{Read: 10 * time.Millisecond})
+
+	_, err := repo.Get(context.Background(), "slow")
+	assert.Equal(t, context.DeadlineExceeded, err, "It should unwrap deadline errors")
+
+	book, err := repo.Get(context.Background(), "fast")
+	assert.NoError(t, err)
+	assert.Equal(t, "Sardines", book.Title)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = repo.Get(ctx, "slow")
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = repo.Search(context.Background(), "sardines", 10)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/MathieuDoyon/bookshelf/server/db"
+	"github.com/MathieuDoyon/bookshelf/server/interfaces"
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/replaceopt"
+)
+
+// WorkRepo work repository
+type WorkRepo struct {
+	interfaces.IWorkRepository
+}
+
+// List get every work sorted by title
+func (repo *WorkRepo) List(ctx context.Context) ([]model.Work, error) {
+	collection := db.Database.Collection("works")
+
+	cur, err := collection.Find(ctx, bson.NewDocument())
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cur.Close(context.Background())
+
+	works := []model.Work{}
+	for cur.Next(ctx) {
+		work := model.Work{}
+		if err := cur.Decode(&work); err != nil {
+			return nil, err
+		}
+		works = append(works, work)
+	}
+	if err := cur.Err(); err != nil {
+		return nil, mongoError(err)
+	}
+
+	// sorted like the other backends, a collection sort doesn't follow CompareStrings
+	model.SortWorks(works)
+	return works, nil
+}
+
+// Get get a work by ID
+func (repo *WorkRepo) Get(ctx context.Context, ID string) (*model.Work, error) {
+	collection := db.Database.Collection("works")
+
+	objectID, err := objectid.FromHex(ID)
+	if err != nil {
+		return nil, model.ErrInvalidID
+	}
+
+	var work *model.Work
+	err = collection.FindOne(ctx, bson.NewDocument(bson.EC.ObjectID("_id", objectID))).Decode(&work)
+	if err == mongo.ErrNoDocuments {
+		return nil, model.ErrWorkNotFound
+	}
+	if err != nil {
+		return nil, mongoError(err)
+	}
+
+	return work, nil
+}
+
+// Create add a new work
+func (repo *WorkRepo) Create(ctx context.Context, work *model.Work) (*model.Work, error) {
+	collection := db.Database.Collection("works")
+
+	work.ID = objectid.New()
+	work.CreatedAt = now()
+	work.UpdatedAt = work.CreatedAt
+	if _, err := collection.InsertOne(ctx, work); err != nil {
+		return nil, mongoError(err)
+	}
+
+	return work, nil
+}
+
+// Update replace a work by ID
+func (repo *WorkRepo) Update(ctx context.Context, work *model.Work) (*model.Work, error) {
+	collection := db.Database.Collection("works")
+
+	updatedAt := now()
+
+	res, err := collection.UpdateOne(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("_id", work.ID)),
+		bson.NewDocument(bson.EC.SubDocumentFromElements("$set",
+			bson.EC.String("title", work.Title),
+			bson.EC.String("author", work.Author),
+			bson.EC.Time("updated_at", updatedAt),
+		)),
+	)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, model.ErrWorkNotFound
+	}
+
+	work.UpdatedAt = updatedAt
+	return work, nil
+}
+
+// Delete remove a work by ID
+func (repo *WorkRepo) Delete(ctx context.Context, ID objectid.ObjectID) (int64, error) {
+	collection := db.Database.Collection("works")
+
+	res, err := collection.DeleteOne(ctx, bson.NewDocument(bson.EC.ObjectID("_id", ID)))
+	if err != nil {
+		return 0, mongoError(err)
+	}
+
+	return res.DeletedCount, nil
+}
+
+// Put replace the work document having the id of work, inserting it when missing. Dates are written as is
+func (repo *WorkRepo) Put(ctx context.Context, work *model.Work) error {
+	collection := db.Database.Collection("works")
+
+	_, err := collection.ReplaceOne(
+		ctx,
+		bson.NewDocument(bson.EC.ObjectID("_id", work.ID)),
+		work,
+		replaceopt.Upsert(true),
+	)
+
+	return mongoError(err)
+}
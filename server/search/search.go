@@ -0,0 +1,193 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"golang.org/x/text/unicode/norm"
+)
+
+// HighlightStart and HighlightEnd wrap matched terms into highlights, the rest of a highlight is HTML escaped
+const (
+	HighlightStart = "<em>"
+	HighlightEnd   = "</em>"
+)
+
+// DefaultWeight weight of text fields missing from Weights
+const DefaultWeight = 1
+
+// Weights relative importance of text fields, shared with the mongo text index
+var Weights = map[string]int32{
+	"title":    5,
+	"author":   3,
+	"subtitle": 2,
+	"genre":    2,
+}
+
+// Fields text fields searched, every string field of `model.Book`
+func Fields() []string {
+	var fields []string
+	for _, field := range model.FieldNames() {
+		if model.BookFields[field] == model.FieldString {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Weight weight of a text field
+func Weight(field string) int32 {
+	if weight, ok := Weights[field]; ok {
+		return weight
+	}
+	return DefaultWeight
+}
+
+// Normalize lower case s and strip its accents
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// Terms split a query into its distinct normalized terms
+func Terms(query string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, token := range tokenize(query) {
+		term := Normalize(token.text)
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Search rank books matching at least one query term, best match first
+func Search(books []model.Book, query string, limit int64) *model.SearchPage {
+	page := &model.SearchPage{
+		Query:   query,
+		Results: []model.SearchResult{},
+	}
+
+	terms := Terms(query)
+	if len(terms) == 0 {
+		return page
+	}
+
+	for _, book := range books {
+		if result, ok := Score(&book, terms); ok {
+			page.Results = append(page.Results, *result)
+		}
+	}
+
+	sort.SliceStable(page.Results, func(i, j int) bool {
+		if page.Results[i].Score != page.Results[j].Score {
+			return page.Results[i].Score > page.Results[j].Score
+		}
+		return page.Results[i].Book.ID.Hex() < page.Results[j].Book.ID.Hex()
+	})
+
+	page.Total = int64(len(page.Results))
+	if limit > 0 && page.Total > limit {
+		page.Results = page.Results[:limit]
+	}
+	return page
+}
+
+// Score compute book relevance for terms and highlight matches.
+//
+// Every matching term adds `weight * (0.5 + 0.5 * count / tokens)` per
+// field, the same formula mongo uses to compute its text score.
+func Score(book *model.Book, terms []string) (*model.SearchResult, bool) {
+	wanted := map[string]bool{}
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	result := &model.SearchResult{
+		Book:       *book,
+		Highlights: map[string]string{},
+	}
+
+	for _, field := range Fields() {
+		text, _ := book.FieldValue(field).(string)
+		tokens := tokenize(text)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		counts := map[string]int{}
+		for _, token := range tokens {
+			if term := Normalize(token.text); wanted[term] {
+				counts[term]++
+			}
+		}
+		if len(counts) == 0 {
+			continue
+		}
+
+		for _, count := range counts {
+			result.Score += float64(Weight(field)) * (0.5 + 0.5*float64(count)/float64(len(tokens)))
+		}
+		result.Highlights[field] = highlight(text, tokens, wanted)
+	}
+
+	return result, result.Score > 0
+}
+
+// token word of a text and its byte position
+type token struct {
+	text  string
+	start int
+	end   int
+}
+
+// tokenize split text into words made of letters and digits
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+		if isWord && start < 0 {
+			start = i
+		}
+		if !isWord && start >= 0 {
+			tokens = append(tokens, token{text: text[start:i], start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{text: text[start:], start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// highlight wrap tokens matching a wanted term, keeping original text.
+//
+// The text is HTML escaped so only the markers are markup, book fields are user input.
+func highlight(text string, tokens []token, wanted map[string]bool) string {
+	var b strings.Builder
+	last := 0
+	for _, token := range tokens {
+		if !wanted[Normalize(token.text)] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:token.start]))
+		b.WriteString(HighlightStart)
+		b.WriteString(html.EscapeString(token.text))
+		b.WriteString(HighlightEnd)
+		last = token.end
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/MathieuDoyon/bookshelf/server/model"
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "emile zola", Normalize("Émile ZOLA"))
+}
+
+func TestTerms(t *testing.T) {
+	assert.Equal(t, []string{"science", "fiction"}, Terms("Science-Fiction, science!"))
+}
+
+func TestSearch(t *testing.T) {
+	books := []model.Book{
+		{ID: objectid.New(), Author: "Émile Zola", Genre: "Fiction"},
+		{ID: objectid.New(), Author: "Mathieu Doyon", Genre: "Fantasy"},
+		{ID: objectid.New(), Author: "Jules Verne", Genre: "Science Fiction"},
+	}
+
+	page := Search(books, "emile fiction", 10)
+
+	assert.Equal(t, int64(2), page.Total)
+	assert.Equal(t, "Émile Zola", page.Results[0].Book.Author, "It should rank author matches first")
+	assert.Equal(t, "<em>Émile</em> Zola", page.Results[0].Highlights["author"])
+	assert.Equal(t, "<em>Fiction</em>", page.Results[0].Highlights["genre"])
+	assert.Equal(t, "Science <em>Fiction</em>", page.Results[1].Highlights["genre"])
+
+	page = Search(books, "fiction", 1)
+
+	assert.Equal(t, int64(2), page.Total)
+	assert.Len(t, page.Results, 1)
+	assert.Equal(t, "Émile Zola", page.Results[0].Book.Author, "It should rank shorter fields first")
+}
+
+func TestSearchHighlightEscaped(t *testing.T) {
+	books := []model.Book{{ID: objectid.New(), Title: `<script>alert("sardines")</script> & Trawler`}}
+
+	page := Search(books, "sardines", 10)
+
+	if assert.Len(t, page.Results, 1) {
+		assert.Equal(t, "&lt;script&gt;alert(&#34;<em>sardines</em>&#34;)&lt;/script&gt; &amp; Trawler", page.Results[0].Highlights["title"])
+	}
+}
+
+func TestSearchNoTerms(t *testing.T) {
+	page := Search([]model.Book{{Author: "Mathieu Doyon"}}, "  !! ", 10)
+
+	assert.Equal(t, int64(0), page.Total)
+	assert.Empty(t, page.Results)
+}
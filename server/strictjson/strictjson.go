@@ -0,0 +1,264 @@
+package strictjson
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Error decoding error at a position of the document, line and column start at 1
+type Error struct {
+	Offset int64
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Decode decode a single JSON value into v, rejecting unknown fields, duplicate keys, wrong types and trailing data.
+//
+// Keys must match the json names of the fields of v exactly, encoding/json would also accept them in another case.
+func Decode(data []byte, v interface{}) error {
+	keys, err := scan(data, reflect.TypeOf(v))
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(v)
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *json.SyntaxError:
+		return syntaxError(data, 0, e)
+	case *json.UnmarshalTypeError:
+		// pointing at the key is more helpful than the end of the value
+		offset := e.Offset
+		if key, ok := keys[e.Field[strings.LastIndex(e.Field, ".")+1:]]; ok {
+			offset = key
+		}
+		return newError(data, offset, fmt.Sprintf("%s expects %s, got %s", e.Field, e.Type, e.Value))
+	}
+
+	// unknown fields have no offset, they are found back by name
+	if name := strings.TrimPrefix(err.Error(), "json: unknown field "); name != err.Error() {
+		if field, unquoteErr := strconv.Unquote(name); unquoteErr == nil {
+			return newError(data, keys[field], fmt.Sprintf("unknown field %q", field))
+		}
+	}
+	return err
+}
+
+// Check check data is a single JSON value without duplicate keys, its fields aren't checked
+func Check(data []byte) error {
+	_, err := scan(data, nil)
+	return err
+}
+
+// frame object or array being scanned
+type frame struct {
+	object    bool
+	expectKey bool
+	keys      map[string]bool
+	// typ type the object or array is decoded into, nil when its keys aren't checked
+	typ reflect.Type
+	// value type the next value is decoded into
+	value reflect.Type
+}
+
+// scan walk every token of data, returning the offset of the first occurrence of each object key.
+//
+// Object keys decoded into a struct of typ must be the json name of one of its fields, nil typ checks no key.
+func scan(data []byte, typ reflect.Type) (map[string]int64, error) {
+	offsets := map[string]int64{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var stack []*frame
+	value := decodedType(typ)
+
+	for {
+		start := skipSpace(data, decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF && len(stack) == 0 {
+			return nil, newError(data, start, "empty document")
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, newError(data, start, "unexpected end of the document")
+		}
+		if err != nil {
+			return nil, syntaxError(data, start, err)
+		}
+
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if top != nil && top.object && top.expectKey {
+			if key, ok := token.(string); ok {
+				if top.keys[key] {
+					return nil, newError(data, start, fmt.Sprintf("duplicate key %q", key))
+				}
+				top.keys[key] = true
+				if _, ok := offsets[key]; !ok {
+					offsets[key] = start
+				}
+				top.expectKey = false
+				if top.value, ok = keyType(top.typ, key); !ok {
+					return nil, newError(data, start, fmt.Sprintf("unknown field %q", key))
+				}
+				continue
+			}
+		}
+
+		if top != nil {
+			value = top.value
+		}
+		switch token {
+		case json.Delim('{'):
+			stack = append(stack, &frame{object: true, expectKey: true, keys: map[string]bool{}, typ: value})
+			continue
+		case json.Delim('['):
+			var elem reflect.Type
+			if value != nil && (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) {
+				elem = decodedType(value.Elem())
+			}
+			stack = append(stack, &frame{typ: value, value: elem})
+			continue
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+		}
+
+		// a value is complete
+		if len(stack) == 0 {
+			break
+		}
+		if top := stack[len(stack)-1]; top.object {
+			top.expectKey = true
+		}
+	}
+
+	end := skipSpace(data, decoder.InputOffset())
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, newError(data, end, "trailing data after the JSON value")
+	}
+	return offsets, nil
+}
+
+var (
+	unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodedType type a value is decoded into, pointers dereferenced. nil when the keys of the value aren't checked,
+// for interfaces and types decoding themselves
+func decodedType(typ reflect.Type) reflect.Type {
+	if typ == nil {
+		return nil
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if reflect.PtrTo(typ).Implements(unmarshalerType) || reflect.PtrTo(typ).Implements(textUnmarshalerType) {
+		return nil
+	}
+	switch typ.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return typ
+	}
+	return nil
+}
+
+// keyType type the value of key is decoded into in an object decoded into typ, false when a struct has no field
+// named key
+func keyType(typ reflect.Type, key string) (reflect.Type, bool) {
+	switch {
+	case typ == nil:
+		return nil, true
+	case typ.Kind() == reflect.Map:
+		return decodedType(typ.Elem()), true
+	case typ.Kind() == reflect.Struct:
+		field, ok := jsonFields(typ)[key]
+		return decodedType(field), ok
+	}
+	return nil, true
+}
+
+// jsonFields type of every field of a struct by json name, fields of embedded structs included unless an outer
+// field has the same name
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	var embedded []reflect.Type
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded = append(embedded, fieldType)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+
+	for _, typ := range embedded {
+		for name, fieldType := range jsonFields(typ) {
+			if _, ok := fields[name]; !ok {
+				fields[name] = fieldType
+			}
+		}
+	}
+	return fields
+}
+
+// syntaxError position a decoder error, json syntax errors are positioned on the invalid character
+func syntaxError(data []byte, offset int64, err error) error {
+	if e, ok := err.(*json.SyntaxError); ok && e.Offset > 0 {
+		offset = e.Offset - 1
+	}
+	return newError(data, offset, strings.TrimPrefix(err.Error(), "json: "))
+}
+
+// newError error at offset of data
+func newError(data []byte, offset int64, msg string) *Error {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	column := int(offset) - bytes.LastIndexByte(data[:offset], '\n')
+	return &Error{Offset: offset, Line: line, Column: column, Msg: msg}
+}
+
+// skipSpace offset of the next token, separators are skipped too
+func skipSpace(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
@@ -0,0 +1,52 @@
+package strictjson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type book struct {
+	Title   string   `json:"title"`
+	Rating  int64    `json:"rating"`
+	Authors []author `json:"authors,omitempty"`
+}
+
+type author struct {
+	Name string `json:"name"`
+}
+
+func TestDecode(t *testing.T) {
+	decoded := &book{}
+	assert.NoError(t, Decode([]byte(`{"title":"Sardines","rating":5}`), decoded))
+	assert.Equal(t, &book{Title: "Sardines", Rating: 5}, decoded)
+
+	cases := []struct {
+		body     string
+		expected string
+	}{
+		{"{\n  \"title\": \"Sardines\",\n  \"ratting\": 5\n}", `line 3, column 3: unknown field "ratting"`},
+		{`{"title":"Sardines","title":"Trawler"}`, `line 1, column 21: duplicate key "title"`},
+		{`{"title":"Sardines","Title":"Trawler"}`, `line 1, column 21: unknown field "Title"`},
+		{`{"Rating":5}`, `line 1, column 2: unknown field "Rating"`},
+		{`{"authors":[{"name":"Doyon"},{"NAME":"Cantona"}]}`, `line 1, column 31: unknown field "NAME"`},
+		{`{"rating":"five"}`, `line 1, column 2: rating expects int64, got string`},
+		{`{"title":"Sardines"} {}`, `line 1, column 22: trailing data after the JSON value`},
+		{`{"title":`, `line 1, column 10: unexpected end of the document`},
+		{`{"title" "Sardines"}`, `line 1, column 10: invalid character '"' after object key`},
+		{``, `line 1, column 1: empty document`},
+	}
+
+	for _, c := range cases {
+		err := Decode([]byte(c.body), &book{})
+		if assert.IsType(t, &Error{}, err, c.body) {
+			assert.Equal(t, c.expected, err.Error(), c.body)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	assert.NoError(t, Check([]byte(`{"a":{"b":1},"b":[{"b":2}]}`)), "It should only compare keys of the same object")
+	assert.Error(t, Check([]byte(`[{"a":1,"a":2}]`)))
+	assert.Error(t, Check([]byte(`[] []`)))
+}
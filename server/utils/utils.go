@@ -54,3 +54,17 @@ func ParseInt(s string, dest interface{}) error {
 	*d = n
 	return nil
 }
+
+// ParseInt64 Parse string to Int64
+func ParseInt64(s string, dest interface{}) error {
+	d, ok := dest.(*int64)
+	if !ok {
+		return fmt.Errorf("wrong type for ParseInt64: %T", dest)
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*d = n
+	return nil
+}
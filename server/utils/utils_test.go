@@ -43,3 +43,13 @@ func TestParseInt(t *testing.T) {
 	assert.Equal(t, expected, res, "It should return type int")
 	assert.Nil(t, err)
 }
+
+func TestParseInt64(t *testing.T) {
+	var res int64
+	var expected int64 = 123
+
+	err := ParseInt64("123", &res)
+
+	assert.Equal(t, expected, res, "It should return type int64")
+	assert.Nil(t, err)
+}